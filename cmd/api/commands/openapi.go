@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/server"
+
+	"github.com/spf13/cobra"
+)
+
+func OpenAPICommand() *cobra.Command {
+	return &cobra.Command{
+		Use:           "openapi",
+		Short:         "Print the OpenAPI document, e.g. app openapi > spec.json",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE:          execOpenAPI,
+	}
+}
+
+// execOpenAPI writes the document to stdout. It needs no database, so it can run in CI.
+func execOpenAPI(cmd *cobra.Command, args []string) error {
+	cfg := config.LoadConfigFromEnv()
+
+	doc, err := server.OpenAPIDocument(cfg)
+	if err != nil {
+		return fmt.Errorf("generating the OpenAPI document: %w", err)
+	}
+
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(doc)
+}
@@ -5,19 +5,30 @@ import (
 	"fmt"
 	"go-web-starter/internal/config"
 	"go-web-starter/internal/database"
+	"go-web-starter/internal/jsonlog"
+	"go-web-starter/internal/mailer"
 	"go-web-starter/internal/queries"
 	"go-web-starter/internal/service"
 	"log"
+	"os"
 
 	"github.com/spf13/cobra"
 )
 
 func SeedCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "seed",
 		Short: "Seed the database with test data",
-		Run:   execSeed,
+		Long: `Seed the database with test data.
+
+With --admin the existing user with the email address is promoted to admin
+instead, e.g. app seed --admin jane@example.com`,
+		Run: execSeed,
 	}
+
+	cmd.Flags().String("admin", "", "email address of a user to promote to admin")
+
+	return cmd
 }
 
 func execSeed(cmd *cobra.Command, args []string) {
@@ -25,10 +36,24 @@ func execSeed(cmd *cobra.Command, args []string) {
 	db := database.New(cfg.Database)
 	defer db.Close(cfg.Database)
 
+	mail, err := mailer.New(cfg.Mailer, mailer.NewBrand(cfg), jsonlog.New(os.Stdout, jsonlog.LevelInfo))
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	q := queries.New(db.GetDB())
-	authService := service.NewAuthService(q, db)
+	authService := service.NewAuthService(q, db, mail, cfg.Auth)
 	ctx := context.Background()
 
+	if email, _ := cmd.Flags().GetString("admin"); email != "" {
+		user, err := authService.PromoteToAdmin(ctx, email)
+		if err != nil {
+			log.Fatalf("Failed to promote %s to admin: %v", email, err)
+		}
+		fmt.Printf("Promoted user to admin: %s (ID: %d)\n", user.Email, user.ID)
+		return
+	}
+
 	users := []struct {
 		name     string
 		email    string
@@ -41,7 +66,7 @@ func execSeed(cmd *cobra.Command, args []string) {
 	fmt.Println("Seeding users with accounts...")
 
 	for _, userData := range users {
-		createdUser, err := authService.SignUp(ctx, userData.name, userData.email, userData.password, true)
+		createdUser, err := authService.CreateUser(ctx, userData.name, userData.email, userData.password)
 		if err != nil {
 			log.Printf("Failed to create user %s: %v", userData.email, err)
 			continue
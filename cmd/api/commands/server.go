@@ -22,11 +22,24 @@ func ServerCommand() *cobra.Command {
 }
 
 func execServer(cmd *cobra.Command, args []string) {
-	server := server.NewHttpServer()
+	app := server.NewFromEnv()
+	server := app.HTTPServer()
 
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
 
+	// the workers stop with the server, after finishing the jobs they are running
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	workerDone := make(chan bool, 1)
+	if app.Config.Jobs.InServer {
+		go func() {
+			runBackground(workerCtx, app)
+			workerDone <- true
+		}()
+	} else {
+		workerDone <- true
+	}
+
 	// Run graceful shutdown in a separate goroutine
 	go gracefulShutdown(server, done)
 
@@ -37,6 +50,8 @@ func execServer(cmd *cobra.Command, args []string) {
 
 	// Wait for the graceful shutdown to complete
 	<-done
+	stopWorker()
+	<-workerDone
 	log.Println("Graceful shutdown complete.")
 
 }
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"go-web-starter/internal/server"
+	"log"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+func WorkerCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "worker",
+		Short: "Run the background jobs, the outbox and scheduled tasks",
+		Long: `Run the background jobs, deliver the emails of the outbox and run the
+scheduled tasks until interrupted.
+
+Any number of workers can run next to each other and to servers. Set
+JOBS_IN_SERVER=false to only run the jobs with this command, and
+SCHEDULER_ENABLED=false to leave out the scheduled tasks.`,
+		Args: cobra.NoArgs,
+		Run:  execWorker,
+	}
+}
+
+func execWorker(cmd *cobra.Command, args []string) {
+	app := server.NewFromEnv()
+	defer app.Db.Close(app.Config.Database)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("running jobs with %d workers", max(app.Config.Jobs.Workers, 1))
+
+	runBackground(ctx, app)
+
+	log.Println("Worker stopped.")
+}
+
+// runBackground runs the job workers, the outbox dispatcher and the scheduler,
+// unless it is disabled, until ctx is done and the jobs, emails and tasks they are
+// working on are finished
+func runBackground(ctx context.Context, app *server.Server) {
+	tasks, err := app.NewScheduler()
+	if err != nil {
+		app.Logger.PrintFatal(err, nil)
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		app.NewWorker().Run(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		app.NewDispatcher().Run(ctx)
+	}()
+
+	if app.Config.Jobs.Scheduler {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tasks.Run(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"go-web-starter/cmd/api/commands"
+	"os"
 
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/spf13/cobra"
@@ -11,6 +13,8 @@ func main() {
 	rootCmd := &cobra.Command{
 		Use:   "app",
 		Short: "A web application built with Go.",
+		// errors are printed once, below
+		SilenceErrors: true,
 	}
 
 	// CLI commands
@@ -19,7 +23,13 @@ func main() {
 		commands.SeedCommand(),
 		commands.PingCommand(),
 		commands.MigrateCommand(),
+		commands.OpenAPICommand(),
+		commands.AuditCommand(),
+		commands.WorkerCommand(),
 	)
 
-	rootCmd.Execute()
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,115 @@
+// Passkey registration and login with the WebAuthn browser API.
+// Buttons opt in with data-passkey-action="register" or "login" and carry the
+// CSRF token in data-csrf-token. Errors are written to data-error-target.
+(() => {
+  const toBuffer = (value) => {
+    const base64 = value.replace(/-/g, "+").replace(/_/g, "/");
+    const padded = base64 + "=".repeat((4 - (base64.length % 4)) % 4);
+    return Uint8Array.from(atob(padded), (c) => c.charCodeAt(0)).buffer;
+  };
+
+  const toBase64URL = (buffer) => {
+    if (!buffer) return undefined;
+    const bytes = String.fromCharCode(...new Uint8Array(buffer));
+    return btoa(bytes).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+  };
+
+  const post = async (url, csrfToken, body) => {
+    const response = await fetch(url, {
+      method: "POST",
+      credentials: "same-origin",
+      headers: {
+        "Content-Type": "application/json",
+        "X-CSRF-Token": csrfToken,
+      },
+      body: body ? JSON.stringify(body) : undefined,
+    });
+
+    const data = await response.json().catch(() => ({}));
+    if (!response.ok) {
+      throw new Error(data.error || "Something went wrong!");
+    }
+    return data;
+  };
+
+  const register = async (button) => {
+    const csrfToken = button.dataset.csrfToken;
+    const name = document.getElementById("passkey-name")?.value || "";
+
+    const { publicKey } = await post("/profile/passkeys/register/begin", csrfToken);
+    publicKey.challenge = toBuffer(publicKey.challenge);
+    publicKey.user.id = toBuffer(publicKey.user.id);
+    (publicKey.excludeCredentials || []).forEach((c) => (c.id = toBuffer(c.id)));
+
+    const credential = await navigator.credentials.create({ publicKey });
+
+    await post("/profile/passkeys/register/finish?name=" + encodeURIComponent(name), csrfToken, {
+      id: credential.id,
+      rawId: toBase64URL(credential.rawId),
+      type: credential.type,
+      authenticatorAttachment: credential.authenticatorAttachment,
+      response: {
+        clientDataJSON: toBase64URL(credential.response.clientDataJSON),
+        attestationObject: toBase64URL(credential.response.attestationObject),
+        transports: credential.response.getTransports?.() || [],
+      },
+    });
+
+    htmx.ajax("GET", "/profile/passkeys", { target: "#passkeys", swap: "innerHTML" });
+  };
+
+  const login = async (button) => {
+    const csrfToken = button.dataset.csrfToken;
+
+    const { publicKey } = await post("/login/passkey/begin", csrfToken);
+    publicKey.challenge = toBuffer(publicKey.challenge);
+    (publicKey.allowCredentials || []).forEach((c) => (c.id = toBuffer(c.id)));
+
+    const credential = await navigator.credentials.get({ publicKey });
+
+    const next = new URLSearchParams(window.location.search).get("next");
+    const url = "/login/passkey/finish" + (next ? "?next=" + encodeURIComponent(next) : "");
+
+    const result = await post(url, csrfToken, {
+      id: credential.id,
+      rawId: toBase64URL(credential.rawId),
+      type: credential.type,
+      authenticatorAttachment: credential.authenticatorAttachment,
+      response: {
+        clientDataJSON: toBase64URL(credential.response.clientDataJSON),
+        authenticatorData: toBase64URL(credential.response.authenticatorData),
+        signature: toBase64URL(credential.response.signature),
+        userHandle: toBase64URL(credential.response.userHandle),
+      },
+    });
+
+    window.location.href = result.redirect;
+  };
+
+  const actions = { register, login };
+
+  document.addEventListener("click", async (event) => {
+    const button = event.target.closest("[data-passkey-action]");
+    if (!button) return;
+
+    event.preventDefault();
+
+    const errorTarget = document.getElementById(button.dataset.errorTarget);
+    if (errorTarget) errorTarget.textContent = "";
+
+    if (!window.PublicKeyCredential) {
+      if (errorTarget) errorTarget.textContent = "Your browser does not support passkeys.";
+      return;
+    }
+
+    button.disabled = true;
+    try {
+      await actions[button.dataset.passkeyAction](button);
+    } catch (err) {
+      // NotAllowedError is raised when the user cancels the browser dialog
+      if (errorTarget && err.name !== "NotAllowedError") errorTarget.textContent = err.message;
+    } finally {
+      button.disabled = false;
+    }
+  });
+})();
@@ -3,3 +3,141 @@ package config
 const ScopeActivation = "activation"
 const ScopeAuthentication = "authentication"
 const ScopePasswordReset = "password-reset"
+const ScopeLogin = "login"
+const ScopeAccountLink = "account-link"
+const ScopeEmailChange = "email-change"
+const ScopeEmailChangeCancel = "email-change-cancel"
+const ScopeInvitation = "invitation"
+
+// Scopes of personal access tokens
+const (
+	// AccessTokenScopeRead allows GET and HEAD requests.
+	AccessTokenScopeRead = "read"
+	// AccessTokenScopeWrite allows requests that change data.
+	AccessTokenScopeWrite = "write"
+)
+
+// AccessTokenScopes are the scopes users can pick from when creating a token
+var AccessTokenScopes = []string{AccessTokenScopeRead, AccessTokenScopeWrite}
+
+// Policies for users that have not verified their email address yet
+const (
+	// UnverifiedPolicyAllow lets unverified users use the app like everyone else.
+	UnverifiedPolicyAllow = "allow"
+	// UnverifiedPolicyLimit lets unverified users browse (GET) but not change anything.
+	UnverifiedPolicyLimit = "limit"
+	// UnverifiedPolicyBlock only lets unverified users reach the verify email page.
+	UnverifiedPolicyBlock = "block"
+)
+
+// Drivers of the mailer
+const (
+	// MailDriverSMTP sends emails through the SMTP server.
+	MailDriverSMTP = "smtp"
+	// MailDriverFile writes emails as .eml files to a maildir.
+	MailDriverFile = "file"
+	// MailDriverLog prints emails to the log.
+	MailDriverLog = "log"
+	// MailDriverMemory keeps emails in memory, e.g. for tests.
+	MailDriverMemory = "memory"
+)
+
+// RoleAdmin is the role with every permission, seeded by the migrations
+const RoleAdmin = "admin"
+
+// Permissions checked by requirePermission, named resource.action. They are seeded
+// by the migrations and granted to roles in role_permissions.
+const (
+	// PermissionUsersView allows seeing all users and their accounts.
+	PermissionUsersView = "users.view"
+	// PermissionUsersManage allows changing, disabling and deleting users.
+	PermissionUsersManage = "users.manage"
+	// PermissionRolesManage allows giving roles to users and taking them away.
+	PermissionRolesManage = "roles.manage"
+	// PermissionUsersImpersonate allows logging in as users without a role.
+	PermissionUsersImpersonate = "users.impersonate"
+	// PermissionAuditView allows seeing the audit log.
+	PermissionAuditView = "audit.view"
+	// PermissionTasksView allows seeing the scheduled tasks and their last runs.
+	PermissionTasksView = "tasks.view"
+	// PermissionOutboxView allows seeing the outbox of emails and their delivery attempts.
+	PermissionOutboxView = "outbox.view"
+)
+
+// Roles of a member within an organization, from most to least privileged
+const (
+	// OrgRoleOwner can do everything in the organization.
+	OrgRoleOwner = "owner"
+	// OrgRoleAdmin manages the members and the data of the organization.
+	OrgRoleAdmin = "admin"
+	// OrgRoleMember works with the data of the organization.
+	OrgRoleMember = "member"
+)
+
+// OrgRoles are the organization roles, from most to least privileged
+var OrgRoles = []string{OrgRoleOwner, OrgRoleAdmin, OrgRoleMember}
+
+// InvitationRoles are the roles an invitation can give, ownership is never handed out
+var InvitationRoles = []string{OrgRoleAdmin, OrgRoleMember}
+
+// States of an invitation. Pending invitations past their expiry are expired.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+	InvitationRevoked  = "revoked"
+)
+
+// Statuses the user list of the back office can be filtered by
+const (
+	UserStatusVerified   = "verified"
+	UserStatusUnverified = "unverified"
+	UserStatusDisabled   = "disabled"
+)
+
+// UserStatuses are the filters of the user list, disabled users are never counted as verified or unverified
+var UserStatuses = []string{UserStatusVerified, UserStatusUnverified, UserStatusDisabled}
+
+// Actions of the audit log, named resource.event
+const (
+	AuditLoginSucceeded     = "login.succeeded"
+	AuditLoginFailed        = "login.failed"
+	AuditSignup             = "user.signed_up"
+	AuditPasswordChanged    = "password.changed"
+	AuditPasswordReset      = "password.reset"
+	AuditSocialLinked       = "social_account.linked"
+	AuditSocialUnlinked     = "social_account.unlinked"
+	AuditAccountDeleted     = "account.deleted"
+	AuditSessionRevoked     = "session.revoked"
+	AuditSessionsRevoked    = "session.revoked_others"
+	AuditUnverifiedPurged   = "account.unverified_purged"
+	AuditUserVerified       = "admin.user_verified"
+	AuditUserDisabled       = "admin.user_disabled"
+	AuditUserEnabled        = "admin.user_enabled"
+	AuditUserPasswordReset  = "admin.password_reset_forced"
+	AuditUserDeleted        = "admin.user_deleted"
+	AuditImpersonationStart = "admin.impersonation_started"
+	AuditImpersonationStop  = "admin.impersonation_stopped"
+)
+
+// AuditActions are the actions the audit log can be filtered by
+var AuditActions = []string{
+	AuditLoginSucceeded,
+	AuditLoginFailed,
+	AuditSignup,
+	AuditPasswordChanged,
+	AuditPasswordReset,
+	AuditSocialLinked,
+	AuditSocialUnlinked,
+	AuditAccountDeleted,
+	AuditSessionRevoked,
+	AuditSessionsRevoked,
+	AuditUnverifiedPurged,
+	AuditUserVerified,
+	AuditUserDisabled,
+	AuditUserEnabled,
+	AuditUserPasswordReset,
+	AuditUserDeleted,
+	AuditImpersonationStart,
+	AuditImpersonationStop,
+}
@@ -7,4 +7,26 @@ const (
 	AuthenticatedUserID       = contextKey("authenticatedUserID")
 	UserContextKey            = contextKey("user")
 	SidebarStateContextKey    = contextKey("sidebarState")
+	// AccessTokenContextKey holds the personal access token of requests authenticated
+	// with an Authorization: Bearer header
+	AccessTokenContextKey = contextKey("accessToken")
+	// PermissionsContextKey holds the permissions of the authenticated user, granted
+	// by their roles
+	PermissionsContextKey = contextKey("permissions")
+	// OrganizationContextKey holds the types.Membership of the current organization
+	// and OrganizationsContextKey all memberships of the authenticated user
+	OrganizationContextKey  = contextKey("organization")
+	OrganizationsContextKey = contextKey("organizations")
+	// CurrentOrganizationID is the session key of the organization the user switched to
+	CurrentOrganizationID = contextKey("currentOrganizationID")
+	// ImpersonatorID is the session key of the admin who logs in as the authenticated
+	// user, ImpersonationID the key of their impersonations row
+	ImpersonatorID  = contextKey("impersonatorID")
+	ImpersonationID = contextKey("impersonationID")
+	// ImpersonatorContextKey holds the queries.User of the admin while they impersonate
+	// the authenticated user
+	ImpersonatorContextKey = contextKey("impersonator")
+	// ClientContextKey holds the types.Client that sent the request, recorded in the
+	// audit log
+	ClientContextKey = contextKey("client")
 )
@@ -5,6 +5,8 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func GetEnv(key string, defaultVal string) string {
@@ -35,6 +37,34 @@ func GetEnvAsBool(key string, defaultVal bool) bool {
 	return defaultVal
 }
 
+func GetEnvAsDuration(key string, defaultVal time.Duration) time.Duration {
+	strVal := GetEnv(key, "")
+
+	if val, err := time.ParseDuration(strVal); err == nil {
+		return val
+	}
+
+	return defaultVal
+}
+
+// GetEnvAsSlice splits a comma separated env variable, e.g. "a, b" becomes []string{"a", "b"}
+func GetEnvAsSlice(key string, defaultVal []string) []string {
+	strVal := GetEnv(key, "")
+
+	var values []string
+	for val := range strings.SplitSeq(strVal, ",") {
+		if val = strings.TrimSpace(val); val != "" {
+			values = append(values, val)
+		}
+	}
+
+	if len(values) == 0 {
+		return defaultVal
+	}
+
+	return values
+}
+
 func GetEnvAsURL(key string, defaultVal string) *url.URL {
 	strVal := GetEnv(key, "")
 
@@ -0,0 +1,8 @@
+package forms
+
+// AdminUserForm is posted by the user actions of the back office. Next is the page
+// to go back to, the page of the user when empty.
+type AdminUserForm struct {
+	Form
+	Next string `form:"next,omitempty"`
+}
@@ -1,17 +1,41 @@
 package forms
 
+import "go-web-starter/internal/forms/validator"
+
+// Forms with a Validate method are shared by the HTMX views and the JSON API, so both
+// check the same rules. The json tags name the fields of the API requests.
+
 type UserLoginForm struct {
 	Form
-	Email    string `form:"email"`
-	Password string `form:"password"`
+	Email    string `form:"email" json:"email"`
+	Password string `form:"password" json:"password"`
+}
+
+func (f *UserLoginForm) Validate() {
+	f.CheckField(validator.NotBlank(f.Email), "email", "This field cannot be blank")
+	f.CheckField(validator.Matches(f.Email, validator.EmailRX), "email", "This field must be a valid email address")
+	f.CheckField(validator.NotBlank(f.Password), "password", "This field cannot be blank")
 }
 
 type UserSignUpForm struct {
 	Form
-	Name            string `form:"name"`
-	Email           string `form:"email"`
-	Password        string `form:"password"`
-	ConfirmPassword string `form:"confirm_password"`
+	Name            string `form:"name" json:"name"`
+	Email           string `form:"email" json:"email"`
+	Password        string `form:"password" json:"password"`
+	ConfirmPassword string `form:"confirm_password" json:"confirm_password"`
+	// Invitation is the token of the invitation the user signs up with
+	Invitation string `form:"invitation,omitempty" json:"-"`
+}
+
+func (f *UserSignUpForm) Validate() {
+	f.CheckField(validator.NotBlank(f.Name), "name", "This field cannot be blank")
+	f.CheckField(validator.NotBlank(f.Email), "email", "This field cannot be blank")
+	f.CheckField(validator.Matches(f.Email, validator.EmailRX), "email", "This field must be a valid email address")
+	f.CheckField(validator.NotBlank(f.Password), "password", "This field cannot be blank")
+	f.CheckField(validator.MinChars(f.Password, 8), "password", "This field must be at least 8 characters long")
+	f.CheckField(validator.NotBlank(f.ConfirmPassword), "confirm_password", "This field cannot be blank")
+	f.CheckField(validator.MinChars(f.ConfirmPassword, 8), "confirm_password", "This field must be at least 8 characters long")
+	f.CheckField(validator.Equals(f.Password, f.ConfirmPassword), "confirm_password", "Passwords do not match")
 }
 
 type ResetPasswordForm struct {
@@ -28,18 +52,83 @@ type ForgotPasswordForm struct {
 
 type UpdateAccountPasswordForm struct {
 	Form
-	CurrentPassword string `form:"current_password" validate:"required"`
-	NewPassword     string `form:"new_password" validate:"required,min=8"`
-	ConfirmPassword string `form:"confirm_password" validate:"required"`
+	CurrentPassword string `form:"current_password" json:"current_password" validate:"required"`
+	NewPassword     string `form:"new_password" json:"new_password" validate:"required,min=8"`
+	ConfirmPassword string `form:"confirm_password" json:"confirm_password" validate:"required"`
+}
+
+func (f *UpdateAccountPasswordForm) Validate() {
+	f.CheckField(validator.NotBlank(f.CurrentPassword), "current_password", "Current password is required")
+	f.CheckField(validator.NotBlank(f.NewPassword), "new_password", "New password is required")
+	f.CheckField(validator.NotBlank(f.ConfirmPassword), "confirm_password", "Confirm password is required")
 }
 
 type UpdateUserNameAndImageForm struct {
 	Form
-	Name  string `form:"name"`
-	Image string `form:"image"`
+	Name  string `form:"name" json:"name"`
+	Image string `form:"image" json:"image"`
+}
+
+func (f *UpdateUserNameAndImageForm) Validate() {
+	f.CheckField(validator.NotBlank(f.Name), "name", "This field cannot be blank")
+	f.CheckField(validator.NotBlank(f.Image), "image", "This field cannot be blank")
+}
+
+type ChangeEmailForm struct {
+	Form
+	Email string `form:"email"`
+}
+
+type EmailChangeLinkForm struct {
+	Form
+	Token string `form:"token"`
 }
 
 type DeleteAccountForm struct {
+	Form
+	Password string `form:"password" json:"password" validate:"required"`
+}
+
+func (f *DeleteAccountForm) Validate() {
+	f.CheckField(validator.NotBlank(f.Password), "password", "Password is required to delete your account")
+}
+
+type TwoFactorCodeForm struct {
+	Form
+	Code string `form:"code" validate:"required"`
+}
+
+type DisableTwoFactorForm struct {
 	Form
 	Password string `form:"password" validate:"required"`
 }
+
+type PasskeyForm struct {
+	Form
+	Name string `form:"name" validate:"required,max=64"`
+}
+
+type AccessTokenForm struct {
+	Form
+	Name   string   `form:"name"`
+	Scopes []string `form:"scopes"`
+	// days until the token expires, 0 for never
+	ExpiresIn int `form:"expires_in"`
+}
+
+type MagicLinkForm struct {
+	Form
+	Email string `form:"email"`
+	Next  string `form:"next,omitempty"`
+}
+
+type MagicLinkLoginForm struct {
+	Form
+	Token string `form:"token"`
+	Next  string `form:"next"`
+}
+
+type AccountLinkConfirmForm struct {
+	Form
+	Token string `form:"token"`
+}
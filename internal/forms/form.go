@@ -17,8 +17,8 @@ type Message struct {
 }
 
 type Form struct {
-	validator.Validator `form:"-"`
-	Message             *Message `form:"-"`
+	validator.Validator `form:"-" json:"-"`
+	Message             *Message `form:"-" json:"-"`
 }
 
 // Helpers methods for form
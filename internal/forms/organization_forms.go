@@ -0,0 +1,30 @@
+package forms
+
+type OrganizationForm struct {
+	Form
+	Name string `form:"name"`
+}
+
+type SwitchOrganizationForm struct {
+	Form
+	OrganizationID int32  `form:"organization_id"`
+	Next           string `form:"next,omitempty"`
+}
+
+type ProjectForm struct {
+	Form
+	Name        string `form:"name"`
+	Description string `form:"description,omitempty"`
+}
+
+type InvitationForm struct {
+	Form
+	Email string `form:"email"`
+	Role  string `form:"role"`
+}
+
+// InvitationTokenForm accepts or declines the invitation of the emailed token
+type InvitationTokenForm struct {
+	Form
+	Token string `form:"token"`
+}
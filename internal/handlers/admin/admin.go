@@ -0,0 +1,252 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"go-web-starter/cmd/web/views"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/handlers"
+	"go-web-starter/internal/handlers/auth"
+	"go-web-starter/internal/service"
+	"go-web-starter/internal/types"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminHandler serves the back office. The routes check the permissions, the
+// handlers don't.
+type AdminHandler struct {
+	handler     *handlers.Handlers
+	authService *service.AuthService
+}
+
+func NewAdminHandler(h *handlers.Handlers, authService *service.AuthService) *AdminHandler {
+	return &AdminHandler{
+		handler:     h,
+		authService: authService,
+	}
+}
+
+// UsersViewHandler lists the users, filtered by the q, status and page query parameters
+func (ah *AdminHandler) UsersViewHandler(w http.ResponseWriter, r *http.Request) {
+	data := ah.handler.NewTemplateData(r)
+	data.PageTitle = "Users"
+
+	query := r.URL.Query()
+	filter := types.UserFilter{
+		Search: query.Get("q"),
+		Status: query.Get("status"),
+	}
+	if !slices.Contains(config.UserStatuses, filter.Status) {
+		filter.Status = ""
+	}
+	// invalid pages show the first one
+	filter.Page, _ = strconv.Atoi(query.Get("page"))
+
+	page, err := ah.authService.ListUsers(r.Context(), filter)
+	if err != nil {
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	views.AdminUsersView(data, page).Render(r.Context(), w)
+}
+
+// UserViewHandler shows a user with their accounts and active sessions
+func (ah *AdminHandler) UserViewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	details, err := ah.authService.GetUserDetails(r.Context(), int32(id))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	data.PageTitle = details.User.Name
+
+	views.AdminUserView(data, details).Render(r.Context(), w)
+}
+
+func (ah *AdminHandler) VerifyUserHandler(w http.ResponseWriter, r *http.Request) {
+	ah.updateUser(w, r, "The email address is verified.", func(id int32) error {
+		return ah.authService.VerifyUser(r.Context(), ah.handler.GetUser(r), id)
+	})
+}
+
+func (ah *AdminHandler) DisableUserHandler(w http.ResponseWriter, r *http.Request) {
+	ah.updateUser(w, r, "The user is disabled and signed out everywhere.", func(id int32) error {
+		return ah.authService.DisableUser(r.Context(), ah.handler.GetUser(r), id)
+	})
+}
+
+func (ah *AdminHandler) EnableUserHandler(w http.ResponseWriter, r *http.Request) {
+	ah.updateUser(w, r, "The user is enabled.", func(id int32) error {
+		return ah.authService.EnableUser(r.Context(), ah.handler.GetUser(r), id)
+	})
+}
+
+// ResetPasswordHandler removes the password of the user and emails them a link to
+// choose a new one
+func (ah *AdminHandler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	ah.updateUser(w, r, "The password is reset, the user was emailed a link to choose a new one.", func(id int32) error {
+		return ah.authService.ForcePasswordReset(r.Context(), ah.handler.GetUser(r), id, ah.handler.Config.AppURL)
+	})
+}
+
+func (ah *AdminHandler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	ah.updateUser(w, r, "The user is deleted.", func(id int32) error {
+		return ah.authService.DeleteUser(r.Context(), ah.handler.GetUser(r), id)
+	})
+}
+
+// ImpersonateHandler logs the admin in as the user. The session stays the one of
+// the admin, who goes back to their own account with StopImpersonatingHandler.
+func (ah *AdminHandler) ImpersonateHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.AdminUserForm
+
+	err := ah.handler.DecodePostForm(r, &form)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	admin := ah.handler.GetUser(r)
+
+	user, impersonation, err := ah.authService.StartImpersonation(r.Context(), admin, int32(id), ip, r.UserAgent())
+	if err != nil {
+		message := ""
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			http.NotFound(w, r)
+			return
+		case errors.Is(err, service.ErrImpersonationNotAllowed):
+			message = "You can only log in as other users without a role."
+		case errors.Is(err, service.ErrUserDisabled):
+			message = "You can't log in as a disabled user."
+		default:
+			ah.handler.ServerError(w, err)
+			return
+		}
+
+		ah.handler.SessionManager.Put(r.Context(), "flash", message)
+		ah.handler.Redirect(w, r, fmt.Sprintf("/admin/users/%d", id))
+		return
+	}
+
+	ctx := r.Context()
+	ah.handler.SessionManager.Put(ctx, string(config.ImpersonatorID), admin.ID)
+	ah.handler.SessionManager.Put(ctx, string(config.ImpersonationID), impersonation.ID)
+	ah.handler.SessionManager.Put(ctx, string(config.AuthenticatedUserID), user.ID)
+	// the organizations of the admin aren't the ones of the user
+	ah.handler.SessionManager.Remove(ctx, string(config.CurrentOrganizationID))
+
+	ah.handler.Logger.PrintInfo("impersonation started", map[string]string{
+		"admin_id": fmt.Sprintf("%d", admin.ID),
+		"user_id":  fmt.Sprintf("%d", user.ID),
+		"ip":       ip,
+	})
+
+	ah.handler.SessionManager.Put(ctx, "flash", fmt.Sprintf("You are now logged in as %s.", user.Name))
+	ah.handler.Redirect(w, r, "/dashboard")
+}
+
+// StopImpersonatingHandler logs the admin back in as themselves
+func (ah *AdminHandler) StopImpersonatingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	admin := ah.handler.GetImpersonator(r)
+	if admin == nil {
+		ah.handler.Redirect(w, r, "/dashboard")
+		return
+	}
+
+	user := ah.handler.GetUser(r)
+
+	impersonationID := ah.handler.SessionManager.GetInt32(ctx, string(config.ImpersonationID))
+	if err := ah.authService.StopImpersonation(ctx, admin, user.ID, impersonationID); err != nil {
+		ah.handler.Logger.PrintError(err, map[string]string{
+			"impersonation_id": fmt.Sprintf("%d", impersonationID),
+		})
+	}
+
+	ah.handler.SessionManager.Put(ctx, string(config.AuthenticatedUserID), admin.ID)
+	ah.handler.SessionManager.Remove(ctx, string(config.ImpersonatorID))
+	ah.handler.SessionManager.Remove(ctx, string(config.ImpersonationID))
+	ah.handler.SessionManager.Remove(ctx, string(config.CurrentOrganizationID))
+
+	ah.handler.Logger.PrintInfo("impersonation stopped", map[string]string{
+		"admin_id": fmt.Sprintf("%d", admin.ID),
+		"user_id":  fmt.Sprintf("%d", user.ID),
+	})
+
+	ah.handler.SessionManager.Put(ctx, "flash", "You are logged in as yourself again.")
+	ah.handler.Redirect(w, r, fmt.Sprintf("/admin/users/%d", user.ID))
+}
+
+// updateUser runs update on the user of the id URL parameter and goes back to the
+// page the action was posted from with message as the flash
+func (ah *AdminHandler) updateUser(w http.ResponseWriter, r *http.Request, message string, update func(int32) error) {
+	var form forms.AdminUserForm
+
+	err := ah.handler.DecodePostForm(r, &form)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = update(int32(id))
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, service.ErrOwnUser):
+		message = "You can't disable or delete your own account."
+	case errors.Is(err, service.ErrNoPasswordAccount):
+		message = "This user logs in without a password, there is nothing to reset."
+	case err != nil:
+		ah.handler.Logger.PrintError(err, map[string]string{
+			"user_id": fmt.Sprintf("%d", id),
+		})
+		message = "Something went wrong. Please try again."
+	}
+
+	ah.handler.SessionManager.Put(r.Context(), "flash", message)
+
+	redirectURL := fmt.Sprintf("/admin/users/%d", id)
+	if form.Next != "" && auth.IsValidRedirectPath(form.Next) {
+		redirectURL = form.Next
+	}
+
+	ah.handler.Redirect(w, r, redirectURL)
+}
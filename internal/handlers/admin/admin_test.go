@@ -0,0 +1,251 @@
+package admin_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/tests"
+)
+
+// makeAdmin gives the admin role to the user
+func makeAdmin(t *testing.T, ts *tests.TestServer, userID int32) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	role, err := ts.Queries.GetRoleByName(ctx, config.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ts.Queries.AssignRole(ctx, queries.AssignRoleParams{UserID: userID, RoleID: role.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAdminUsers(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ctx := context.Background()
+
+	admin, adminUser := ts.CreateAndLoginUser(t, "Admin", "admin@example.com", "Password123!")
+	makeAdmin(t, ts, adminUser.ID)
+
+	member, memberUser := ts.CreateAndLoginUser(t, "Member", "member@example.com", "Password123!")
+
+	userPath := fmt.Sprintf("/admin/users/%d", memberUser.ID)
+
+	t.Run("requires the permission", func(t *testing.T) {
+		status, _, _ := ts.GetWithClient(t, member, "/admin/users")
+		tests.AssertStatus(t, status, http.StatusForbidden)
+
+		status, _, _ = ts.PostFormWithClient(t, member, fmt.Sprintf("/admin/users/%d/delete", adminUser.ID), nil)
+		tests.AssertStatus(t, status, http.StatusForbidden)
+
+		status, headers, _ := ts.Get(t, "/admin/users")
+		tests.AssertRedirect(t, status, headers, "/login")
+	})
+
+	t.Run("list, search and filter", func(t *testing.T) {
+		status, _, body := ts.GetWithClient(t, admin, "/admin/users")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "admin@example.com")
+		tests.AssertContains(t, body, "member@example.com")
+
+		status, _, body = ts.GetWithClient(t, admin, "/admin/users?q=MEMB")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "member@example.com")
+		tests.AssertNotContains(t, body, "admin@example.com")
+
+		// wildcards are matched literally
+		status, _, body = ts.GetWithClient(t, admin, "/admin/users?q=%25")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "No users found.")
+
+		status, _, body = ts.GetWithClient(t, admin, "/admin/users?status=unverified")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "No users found.")
+	})
+
+	t.Run("details show accounts and sessions", func(t *testing.T) {
+		status, _, body := ts.GetWithClient(t, admin, userPath)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Email and password")
+		tests.AssertNotContains(t, body, "This user is not logged in anywhere.")
+
+		status, _, _ = ts.GetWithClient(t, admin, "/admin/users/999999")
+		tests.AssertStatus(t, status, http.StatusNotFound)
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		unverified, err := ts.Queries.CreateUser(ctx, queries.CreateUserParams{
+			Name:  "Unverified",
+			Email: "unverified@example.com",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, headers, _ := ts.PostFormWithClient(t, admin, fmt.Sprintf("/admin/users/%d/verify", unverified.ID), map[string]string{
+			"next": "/admin/users?status=unverified",
+		})
+		tests.AssertRedirect(t, status, headers, "/admin/users?status=unverified")
+
+		user, err := ts.Queries.GetUserById(ctx, unverified.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !user.EmailVerified {
+			t.Error("expected the email address to be verified")
+		}
+	})
+
+	t.Run("disable and enable", func(t *testing.T) {
+		status, headers, _ := ts.PostFormWithClient(t, admin, userPath+"/disable", nil)
+		tests.AssertRedirect(t, status, headers, userPath)
+
+		// signed out everywhere
+		status, headers, _ = ts.GetWithClient(t, member, "/dashboard")
+		tests.AssertRedirect(t, status, headers, "/login")
+
+		status, _, body := ts.PostForm(t, "/login", map[string]string{
+			"email":    "member@example.com",
+			"password": "Password123!",
+		})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "This account has been disabled.")
+
+		status, _, body = ts.GetWithClient(t, admin, "/admin/users?status=disabled")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "member@example.com")
+
+		status, headers, _ = ts.PostFormWithClient(t, admin, userPath+"/enable", nil)
+		tests.AssertRedirect(t, status, headers, userPath)
+
+		status, headers, _ = ts.PostForm(t, "/login", map[string]string{
+			"email":    "member@example.com",
+			"password": "Password123!",
+		})
+		tests.AssertRedirect(t, status, headers, "/dashboard")
+	})
+
+	t.Run("admins can't disable themselves", func(t *testing.T) {
+		adminPath := fmt.Sprintf("/admin/users/%d", adminUser.ID)
+
+		status, headers, _ := ts.PostFormWithClient(t, admin, adminPath+"/disable", nil)
+		tests.AssertRedirect(t, status, headers, adminPath)
+
+		status, _, body := ts.GetWithClient(t, admin, adminPath)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "You can&#39;t disable or delete your own account.")
+	})
+
+	t.Run("force password reset", func(t *testing.T) {
+		status, headers, _ := ts.PostFormWithClient(t, admin, userPath+"/reset-password", nil)
+		tests.AssertRedirect(t, status, headers, userPath)
+
+		ts.RunJobs(t)
+		email := ts.Mailer.Last()
+		if email == nil || email.Recipient() != "member@example.com" {
+			t.Fatalf("expected the password reset email; got %+v", email)
+		}
+		if _, ok := email.Email.(mailer.PasswordResetRequiredEmail); !ok {
+			t.Fatalf("expected the password reset email; got %T", email.Email)
+		}
+
+		account, err := ts.Queries.GetPasswordAccountByUserId(ctx, memberUser.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if account.Password.Valid {
+			t.Error("expected the password to be removed")
+		}
+
+		status, _, body := ts.PostForm(t, "/login", map[string]string{
+			"email":    "member@example.com",
+			"password": "Password123!",
+		})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Invalid email or password")
+	})
+
+	t.Run("impersonate", func(t *testing.T) {
+		status, headers, _ := ts.PostFormWithClient(t, admin, userPath+"/impersonate", nil)
+		tests.AssertRedirect(t, status, headers, "/dashboard")
+
+		status, _, body := ts.GetWithClient(t, admin, "/dashboard")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "impersonation-banner")
+		tests.AssertContains(t, body, "member@example.com")
+
+		// the credentials of the user stay out of reach
+		status, headers, _ = ts.PostFormWithClient(t, admin, "/profile/delete-account", nil)
+		tests.AssertRedirect(t, status, headers, "/profile")
+		if _, err := ts.Queries.GetUserById(ctx, memberUser.ID); err != nil {
+			t.Fatalf("expected the user to still exist; got %v", err)
+		}
+
+		// the user has no permissions to give to the admin
+		status, _, _ = ts.GetWithClient(t, admin, "/admin/users")
+		tests.AssertStatus(t, status, http.StatusForbidden)
+
+		status, headers, _ = ts.PostFormWithClient(t, admin, "/impersonation/stop", nil)
+		tests.AssertRedirect(t, status, headers, userPath)
+
+		status, _, body = ts.GetWithClient(t, admin, userPath)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertNotContains(t, body, "impersonation-banner")
+		tests.AssertContains(t, body, "Impersonations")
+		tests.AssertNotContains(t, body, "No admin has logged in as this user.")
+
+		// users with a role can't be impersonated
+		other, otherUser := ts.CreateAndLoginUser(t, "Other admin", "other-admin@example.com", "Password123!")
+		makeAdmin(t, ts, otherUser.ID)
+
+		status, headers, _ = ts.PostFormWithClient(t, other, fmt.Sprintf("/admin/users/%d/impersonate", adminUser.ID), nil)
+		tests.AssertRedirect(t, status, headers, fmt.Sprintf("/admin/users/%d", adminUser.ID))
+	})
+
+	t.Run("audit log", func(t *testing.T) {
+		status, _, _ := ts.GetWithClient(t, member, "/admin/audit")
+		tests.AssertStatus(t, status, http.StatusForbidden)
+
+		status, _, body := ts.GetWithClient(t, admin, "/admin/audit?action=admin.user_disabled")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "1 event")
+		tests.AssertContains(t, body, "member@example.com")
+
+		// the failed login of the disabled user, without an actor
+		status, _, body = ts.GetWithClient(t, admin, "/admin/audit?action=login.failed&q=member")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "reason=disabled")
+
+		status, _, body = ts.GetWithClient(t, admin, "/admin/audit?action=admin.impersonation_started&until=2000-01-01")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "No events found.")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		status, headers, _ := ts.PostFormWithClient(t, admin, userPath+"/delete", map[string]string{
+			"next": "/admin/users",
+		})
+		tests.AssertRedirect(t, status, headers, "/admin/users")
+
+		_, err := ts.Queries.GetUserById(ctx, memberUser.ID)
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expected the user to be deleted; got %v", err)
+		}
+
+		status, _, _ = ts.PostFormWithClient(t, admin, userPath+"/delete", nil)
+		tests.AssertStatus(t, status, http.StatusNotFound)
+	})
+}
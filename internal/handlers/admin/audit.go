@@ -0,0 +1,38 @@
+package admin
+
+import (
+	"go-web-starter/cmd/web/views"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/types"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// AuditViewHandler lists the audit log, filtered by the action, q, since, until and
+// page query parameters
+func (ah *AdminHandler) AuditViewHandler(w http.ResponseWriter, r *http.Request) {
+	data := ah.handler.NewTemplateData(r)
+	data.PageTitle = "Audit log"
+
+	query := r.URL.Query()
+	filter := types.AuditFilter{
+		Action: query.Get("action"),
+		Search: query.Get("q"),
+		Since:  query.Get("since"),
+		Until:  query.Get("until"),
+	}
+	if !slices.Contains(config.AuditActions, filter.Action) {
+		filter.Action = ""
+	}
+	// invalid pages show the first one
+	filter.Page, _ = strconv.Atoi(query.Get("page"))
+
+	page, err := ah.authService.ListAuditEvents(r.Context(), filter)
+	if err != nil {
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	views.AdminAuditView(data, page).Render(r.Context(), w)
+}
@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"errors"
+	"go-web-starter/cmd/web/views"
+	"go-web-starter/internal/outbox"
+	"go-web-starter/internal/service"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// OutboxViewHandler lists the latest emails of the outbox, filtered by the status
+// query parameter
+func (ah *AdminHandler) OutboxViewHandler(w http.ResponseWriter, r *http.Request) {
+	data := ah.handler.NewTemplateData(r)
+	data.PageTitle = "Outbox"
+
+	status := r.URL.Query().Get("status")
+	if !slices.Contains(outbox.Statuses, status) {
+		status = ""
+	}
+
+	messages, err := ah.authService.ListOutboxMessages(r.Context(), status)
+	if err != nil {
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	views.AdminOutboxView(data, messages, status).Render(r.Context(), w)
+}
+
+// OutboxMessageViewHandler shows an email of the outbox with its delivery attempts
+func (ah *AdminHandler) OutboxMessageViewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	message, attempts, err := ah.authService.GetOutboxMessage(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrOutboxMessageNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	data.PageTitle = message.EmailType
+
+	views.AdminOutboxMessageView(data, message, attempts).Render(r.Context(), w)
+}
@@ -0,0 +1,70 @@
+package admin_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go-web-starter/internal/outbox"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/tests"
+)
+
+func TestOutbox(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ctx := context.Background()
+
+	admin, adminUser := ts.CreateAndLoginUser(t, "Admin", "admin@example.com", "Password123!")
+	makeAdmin(t, ts, adminUser.ID)
+
+	member, _ := ts.CreateAndLoginUser(t, "Member", "member@example.com", "Password123!")
+
+	// the welcome email of a signup goes through the outbox
+	status, _, _ := ts.PostForm(t, "/signup", map[string]string{
+		"name":             "New User",
+		"email":            "new@example.com",
+		"password":         "Password123!",
+		"confirm_password": "Password123!",
+	})
+	tests.AssertStatus(t, status, http.StatusSeeOther)
+
+	messages, err := ts.Queries.ListOutboxMessages(ctx, queries.ListOutboxMessagesParams{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].EmailType != "WelcomeEmail" || messages[0].Status != outbox.StatusPending {
+		t.Fatalf("expected the pending welcome email; got %+v", messages)
+	}
+	message := messages[0]
+
+	t.Run("lists the emails", func(t *testing.T) {
+		status, _, _ := ts.GetWithClient(t, member, "/admin/outbox")
+		tests.AssertStatus(t, status, http.StatusForbidden)
+
+		status, _, body := ts.GetWithClient(t, admin, "/admin/outbox")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "WelcomeEmail")
+		tests.AssertContains(t, body, "new@example.com")
+
+		ts.RunJobs(t)
+
+		_, _, body = ts.GetWithClient(t, admin, "/admin/outbox?status=pending")
+		tests.AssertContains(t, body, "No emails found.")
+
+		_, _, body = ts.GetWithClient(t, admin, "/admin/outbox?status=sent")
+		tests.AssertContains(t, body, "WelcomeEmail")
+	})
+
+	t.Run("shows the delivery attempts", func(t *testing.T) {
+		status, _, body := ts.GetWithClient(t, admin, fmt.Sprintf("/admin/outbox/%d", message.ID))
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, message.IdempotencyKey)
+		tests.AssertContains(t, body, `id="outbox-attempts"`)
+
+		status, _, _ = ts.GetWithClient(t, admin, fmt.Sprintf("/admin/outbox/%d", message.ID+1000))
+		tests.AssertStatus(t, status, http.StatusNotFound)
+	})
+}
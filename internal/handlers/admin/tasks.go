@@ -0,0 +1,20 @@
+package admin
+
+import (
+	"go-web-starter/cmd/web/views"
+	"net/http"
+)
+
+// TasksViewHandler lists the scheduled tasks with the status of their last run
+func (ah *AdminHandler) TasksViewHandler(w http.ResponseWriter, r *http.Request) {
+	data := ah.handler.NewTemplateData(r)
+	data.PageTitle = "Scheduled tasks"
+
+	tasks, err := ah.authService.ListScheduledTasks(r.Context())
+	if err != nil {
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	views.AdminTasksView(data, tasks).Render(r.Context(), w)
+}
@@ -0,0 +1,82 @@
+package admin_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/tests"
+)
+
+func TestScheduledTasks(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ctx := context.Background()
+
+	admin, adminUser := ts.CreateAndLoginUser(t, "Admin", "admin@example.com", "Password123!")
+	makeAdmin(t, ts, adminUser.ID)
+
+	member, _ := ts.CreateAndLoginUser(t, "Member", "member@example.com", "Password123!")
+
+	// an account that was never verified, and one that is still new
+	stale, err := ts.Queries.CreateUser(ctx, queries.CreateUserParams{Name: "Stale", Email: "stale@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ts.DB.ExecContext(ctx, "UPDATE users SET created_at = $1 WHERE id = $2", time.Now().AddDate(0, 0, -31), stale.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh, err := ts.Queries.CreateUser(ctx, queries.CreateUserParams{Name: "Fresh", Email: "fresh@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts.HTTPServer.Config.Auth.UnverifiedAccountTTL = 30 * 24 * time.Hour
+	tasks, err := ts.HTTPServer.NewScheduler()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// store the tasks, then make them due
+	tasks.RunDue(ctx)
+	if _, err := ts.DB.ExecContext(ctx, "UPDATE scheduled_tasks SET next_run_at = now()"); err != nil {
+		t.Fatal(err)
+	}
+	tasks.RunDue(ctx)
+
+	t.Run("purges unverified accounts", func(t *testing.T) {
+		_, err := ts.Queries.GetUserById(ctx, stale.ID)
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expected the stale account to be purged; got %v", err)
+		}
+		if _, err := ts.Queries.GetUserById(ctx, fresh.ID); err != nil {
+			t.Errorf("expected the new account to be kept; got %v", err)
+		}
+
+		count, err := ts.Queries.CountAuditEvents(ctx, queries.CountAuditEventsParams{Action: config.AuditUnverifiedPurged})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("expected the purge to be audited; got %d events", count)
+		}
+	})
+
+	t.Run("lists the last runs", func(t *testing.T) {
+		status, _, _ := ts.GetWithClient(t, member, "/admin/tasks")
+		tests.AssertStatus(t, status, http.StatusForbidden)
+
+		status, _, body := ts.GetWithClient(t, admin, "/admin/tasks")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "expired-sessions")
+		tests.AssertContains(t, body, "unverified-accounts")
+		tests.AssertContains(t, body, "succeeded")
+	})
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/handlers"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/service"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxBodyBytes limits the size of JSON request bodies
+const maxBodyBytes = 1 << 20
+
+// APIHandler serves the JSON API. It goes through the same AuthService as the
+// HTMX views, only the request decoding and the responses differ.
+type APIHandler struct {
+	handler     *handlers.Handlers
+	authService *service.AuthService
+}
+
+func NewAPIHandler(h *handlers.Handlers, authService *service.AuthService) *APIHandler {
+	return &APIHandler{
+		handler:     h,
+		authService: authService,
+	}
+}
+
+// Envelope wraps successful responses: {"data": ...}
+type Envelope[T any] struct {
+	Data T `json:"data"`
+}
+
+// ErrorResponse is the body of every error response of the API:
+//
+//	{"error": {"message": "...", "fields": {"email": "..."}}}
+//
+// fields carries the validator.Validator field errors of invalid requests.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+	Errors  []string          `json:"errors,omitempty"`
+}
+
+// User is the JSON representation of a user
+type User struct {
+	ID            int32     `json:"id"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Image         string    `json:"image"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func newUser(user *queries.User) User {
+	return User{
+		ID:            user.ID,
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Image:         user.Image.String,
+		CreatedAt:     user.CreatedAt,
+	}
+}
+
+// WriteError sends an error response with the given status code. It is exported
+// for the middlewares guarding the API.
+func WriteError(w http.ResponseWriter, status int, message string) {
+	writeErrorBody(w, status, ErrorBody{Message: message})
+}
+
+func writeErrorBody(w http.ResponseWriter, status int, body ErrorBody) {
+	js, err := json.Marshal(ErrorResponse{Error: body})
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(js)
+}
+
+func (ah *APIHandler) writeData(w http.ResponseWriter, status int, data any) {
+	ah.handler.WriteJSON(w, status, Envelope[any]{Data: data})
+}
+
+// validationError answers 422 with the field errors of v
+func (ah *APIHandler) validationError(w http.ResponseWriter, v validator.Validator) {
+	writeErrorBody(w, http.StatusUnprocessableEntity, ErrorBody{
+		Message: "the request contains invalid fields",
+		Fields:  v.FieldErrors,
+		Errors:  v.NonFieldErrors,
+	})
+}
+
+// fieldError answers 422 with a single field error
+func (ah *APIHandler) fieldError(w http.ResponseWriter, field, message string) {
+	var v validator.Validator
+	v.AddFieldError(field, message)
+	ah.validationError(w, v)
+}
+
+func (ah *APIHandler) serverError(w http.ResponseWriter, r *http.Request, err error) {
+	ah.handler.Logger.PrintError(err, map[string]string{
+		"request_method": r.Method,
+		"request_url":    r.URL.String(),
+	})
+
+	WriteError(w, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+}
+
+func (ah *APIHandler) NotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, http.StatusNotFound, "the requested resource could not be found")
+}
+
+func (ah *APIHandler) MethodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, http.StatusMethodNotAllowed, fmt.Sprintf("the %s method is not supported for this resource", r.Method))
+}
+
+// readJSON decodes the JSON body of r into dst. The errors are meant for the client.
+func (ah *APIHandler) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err != nil {
+		var syntaxError *json.SyntaxError
+		var unmarshalTypeError *json.UnmarshalTypeError
+		var maxBytesError *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &syntaxError):
+			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return errors.New("body contains badly-formed JSON")
+		case errors.As(err, &unmarshalTypeError):
+			if unmarshalTypeError.Field != "" {
+				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+			}
+			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+		case errors.Is(err, io.EOF):
+			return errors.New("body must not be empty")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return fmt.Errorf("body contains unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+		case errors.As(err, &maxBytesError):
+			return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+		default:
+			return err
+		}
+	}
+
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return errors.New("body must only contain a single JSON value")
+	}
+
+	return nil
+}
+
+// decodeRequest reads the JSON body into dst and answers 400 when that fails.
+func (ah *APIHandler) decodeRequest(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if err := ah.readJSON(w, r, dst); err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,274 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/tests"
+
+	"github.com/pquerna/otp/totp"
+)
+
+type errorResponse struct {
+	Error struct {
+		Message string            `json:"message"`
+		Fields  map[string]string `json:"fields"`
+	} `json:"error"`
+}
+
+type userResponse struct {
+	Data struct {
+		ID    int32  `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"data"`
+}
+
+type loginResponse struct {
+	Data struct {
+		Token string `json:"token"`
+	} `json:"data"`
+}
+
+func decode[T any](t *testing.T, body string) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		t.Fatalf("invalid JSON %q: %v", body, err)
+	}
+	return v
+}
+
+// assertFieldError checks the error envelope of a 422 response
+func assertFieldError(t *testing.T, status int, body, field string) {
+	t.Helper()
+
+	tests.AssertStatus(t, status, http.StatusUnprocessableEntity)
+	resp := decode[errorResponse](t, body)
+	if resp.Error.Message == "" || resp.Error.Fields[field] == "" {
+		t.Errorf("expected an error for the field %q; got %s", field, body)
+	}
+}
+
+func login(t *testing.T, ts *tests.TestServer, email, password string) string {
+	t.Helper()
+
+	status, _, body := ts.DoJSON(t, http.MethodPost, "/api/v1/login", "", map[string]string{
+		"email":    email,
+		"password": password,
+	})
+	tests.AssertStatus(t, status, http.StatusCreated)
+
+	token := decode[loginResponse](t, body).Data.Token
+	if token == "" {
+		t.Fatalf("expected a token; got %s", body)
+	}
+	return token
+}
+
+func TestAPISignUp(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	t.Run("validation errors", func(t *testing.T) {
+		status, _, body := ts.DoJSON(t, http.MethodPost, "/api/v1/signup", "", map[string]string{
+			"name":             "API User",
+			"email":            "not-an-email",
+			"password":         "short",
+			"confirm_password": "different",
+		})
+		assertFieldError(t, status, body, "email")
+		assertFieldError(t, status, body, "password")
+		assertFieldError(t, status, body, "confirm_password")
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		status, _, body := ts.DoWithToken(t, http.MethodPost, "/api/v1/signup", "", nil)
+		tests.AssertStatus(t, status, http.StatusBadRequest)
+		tests.AssertContains(t, decode[errorResponse](t, body).Error.Message, "body must not be empty")
+
+		status, _, body = ts.DoJSON(t, http.MethodPost, "/api/v1/signup", "", map[string]string{"admin": "true"})
+		tests.AssertStatus(t, status, http.StatusBadRequest)
+		tests.AssertContains(t, decode[errorResponse](t, body).Error.Message, `unknown field "admin"`)
+
+		status, _, body = ts.DoJSON(t, http.MethodGet, "/api/v1/unknown", "", nil)
+		tests.AssertStatus(t, status, http.StatusNotFound)
+		decode[errorResponse](t, body)
+	})
+
+	t.Run("create an account", func(t *testing.T) {
+		ts.Mailer.Clear()
+
+		status, _, body := ts.DoJSON(t, http.MethodPost, "/api/v1/signup", "", map[string]string{
+			"name":             "API User",
+			"email":            "api@example.com",
+			"password":         "Password123!",
+			"confirm_password": "Password123!",
+		})
+		tests.AssertStatus(t, status, http.StatusCreated)
+
+		user := decode[userResponse](t, body)
+		if user.Data.Email != "api@example.com" || user.Data.Name != "API User" {
+			t.Errorf("unexpected user %s", body)
+		}
+
+		ts.RunJobs(t)
+		// the same emails as a signup in the browser
+		if ts.Mailer.Count() != 2 {
+			t.Errorf("expected the welcome and activation emails; got %d emails", ts.Mailer.Count())
+		}
+
+		status, _, body = ts.DoJSON(t, http.MethodPost, "/api/v1/signup", "", map[string]string{
+			"name":             "API User",
+			"email":            "api@example.com",
+			"password":         "Password123!",
+			"confirm_password": "Password123!",
+		})
+		assertFieldError(t, status, body, "email")
+	})
+}
+
+func TestAPILogin(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ts.CreateTestUser(t, "API User", "api-login@example.com", "Password123!")
+
+	status, _, body := ts.DoJSON(t, http.MethodPost, "/api/v1/login", "", map[string]string{
+		"email":    "api-login@example.com",
+		"password": "WrongPassword!",
+	})
+	tests.AssertStatus(t, status, http.StatusUnauthorized)
+	decode[errorResponse](t, body)
+
+	status, _, body = ts.DoJSON(t, http.MethodPost, "/api/v1/login", "", map[string]string{
+		"email": "api-login@example.com",
+	})
+	assertFieldError(t, status, body, "password")
+
+	token := login(t, ts, "api-login@example.com", "Password123!")
+
+	status, _, body = ts.DoJSON(t, http.MethodGet, "/api/v1/profile", token, nil)
+	tests.AssertStatus(t, status, http.StatusOK)
+	if decode[userResponse](t, body).Data.Email != "api-login@example.com" {
+		t.Errorf("unexpected profile %s", body)
+	}
+
+	// a browser session is not enough, the API skips CSRF protection
+	client := ts.LoginUser(t, "api-login@example.com", "Password123!")
+	status, _, body = ts.GetWithClient(t, client, "/api/v1/profile")
+	tests.AssertStatus(t, status, http.StatusUnauthorized)
+	decode[errorResponse](t, body)
+}
+
+func TestAPILoginTwoFactor(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ctx := context.Background()
+
+	user := ts.CreateTestUser(t, "API User", "api-2fa@example.com", "Password123!")
+	const secret = "JBSWY3DPEHPK3PXP"
+	if _, err := ts.Queries.UpsertTOTPSecret(ctx, queries.UpsertTOTPSecretParams{UserID: user.ID, Secret: secret}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.Queries.ConfirmTOTPSecret(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	loginWithCode := func(code string) (int, string) {
+		status, _, body := ts.DoJSON(t, http.MethodPost, "/api/v1/login", "", map[string]string{
+			"email":    "api-2fa@example.com",
+			"password": "Password123!",
+			"code":     code,
+		})
+		return status, body
+	}
+
+	// the correct password doesn't reset the count of invalid codes
+	for range ts.Config.Auth.LockoutThreshold {
+		status, body := loginWithCode("000000")
+		tests.AssertStatus(t, status, http.StatusUnauthorized)
+		decode[errorResponse](t, body)
+	}
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, body := loginWithCode(code)
+	tests.AssertStatus(t, status, http.StatusTooManyRequests)
+	decode[errorResponse](t, body)
+
+	email := ts.Mailer.Last()
+	if email == nil {
+		t.Fatal("expected the owner to be notified of the lockout")
+	}
+	if _, ok := email.Email.(mailer.AccountLockedEmail); !ok {
+		t.Errorf("expected the lockout email; got %T", email.Email)
+	}
+}
+
+func TestAPIProfile(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ts.CreateTestUser(t, "API User", "api-profile@example.com", "Password123!")
+	token := login(t, ts, "api-profile@example.com", "Password123!")
+
+	t.Run("update", func(t *testing.T) {
+		status, _, body := ts.DoJSON(t, http.MethodPatch, "/api/v1/profile", token, map[string]string{
+			"name": "",
+		})
+		assertFieldError(t, status, body, "name")
+
+		status, _, body = ts.DoJSON(t, http.MethodPatch, "/api/v1/profile", token, map[string]string{
+			"name":  "Renamed",
+			"image": "https://example.com/avatar.png",
+		})
+		tests.AssertStatus(t, status, http.StatusOK)
+		if decode[userResponse](t, body).Data.Name != "Renamed" {
+			t.Errorf("expected the new name; got %s", body)
+		}
+	})
+
+	t.Run("change password", func(t *testing.T) {
+		status, _, body := ts.DoJSON(t, http.MethodPut, "/api/v1/profile/password", token, map[string]string{
+			"current_password": "WrongPassword!",
+			"new_password":     "NewPassword123!",
+			"confirm_password": "NewPassword123!",
+		})
+		assertFieldError(t, status, body, "current_password")
+
+		status, _, _ = ts.DoJSON(t, http.MethodPut, "/api/v1/profile/password", token, map[string]string{
+			"current_password": "Password123!",
+			"new_password":     "NewPassword123!",
+			"confirm_password": "NewPassword123!",
+		})
+		tests.AssertStatus(t, status, http.StatusNoContent)
+
+		login(t, ts, "api-profile@example.com", "NewPassword123!")
+	})
+
+	t.Run("delete account", func(t *testing.T) {
+		status, _, body := ts.DoJSON(t, http.MethodDelete, "/api/v1/profile", token, map[string]string{
+			"password": "Password123!",
+		})
+		assertFieldError(t, status, body, "password")
+
+		status, _, _ = ts.DoJSON(t, http.MethodDelete, "/api/v1/profile", token, map[string]string{
+			"password": "NewPassword123!",
+		})
+		tests.AssertStatus(t, status, http.StatusNoContent)
+
+		// the tokens are deleted along with the user
+		status, _, _ = ts.DoJSON(t, http.MethodGet, "/api/v1/profile", token, nil)
+		tests.AssertStatus(t, status, http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/service"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// loginTokenTTL is the lifetime of the access tokens issued by LoginHandler
+const loginTokenTTL = 30 * 24 * time.Hour
+
+type LoginRequest struct {
+	forms.UserLoginForm
+	// Code is the two-factor code, required for users with two-factor authentication
+	Code string `json:"code,omitempty"`
+	// TokenName is shown in the access tokens on the profile page
+	TokenName string `json:"token_name,omitempty"`
+}
+
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      User      `json:"user"`
+}
+
+func (ah *APIHandler) SignUpHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.UserSignUpForm
+
+	if !ah.decodeRequest(w, r, &form) {
+		return
+	}
+
+	form.Validate()
+	if !form.Valid() {
+		ah.validationError(w, form.Validator)
+		return
+	}
+
+	user, err := ah.authService.SignUp(r.Context(), form.Name, form.Email, form.Password, ah.handler.Config.AppURL)
+	if err != nil {
+		if errors.Is(err, service.ErrEmailTaken) {
+			ah.fieldError(w, "email", "Email address is already in use")
+			return
+		}
+
+		ah.serverError(w, r, err)
+		return
+	}
+
+	ah.writeData(w, http.StatusCreated, newUser(user))
+}
+
+// LoginHandler checks the credentials like the login form and answers with a new
+// personal access token for the Authorization: Bearer header.
+func (ah *APIHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+
+	if !ah.decodeRequest(w, r, &req) {
+		return
+	}
+
+	req.Validate()
+	if !req.Valid() {
+		ah.validationError(w, req.Validator)
+		return
+	}
+
+	user, err := ah.authService.Login(r.Context(), req.Email, req.Password, ah.handler.Config.AppURL)
+	if err != nil {
+		if errors.Is(err, service.ErrAccountLocked) {
+			WriteError(w, http.StatusTooManyRequests, "too many failed login attempts, please try again later or reset your password")
+			return
+		}
+
+		if errors.Is(err, service.ErrUserDisabled) {
+			WriteError(w, http.StatusForbidden, "the account is disabled")
+			return
+		}
+
+		WriteError(w, http.StatusUnauthorized, "invalid email or password")
+		return
+	}
+
+	enabled, err := ah.authService.IsTwoFactorEnabled(r.Context(), user.ID)
+	if err != nil {
+		ah.serverError(w, r, err)
+		return
+	}
+
+	if enabled {
+		if strings.TrimSpace(req.Code) == "" {
+			ah.fieldError(w, "code", "Two-factor code is required")
+			return
+		}
+
+		err := ah.authService.VerifyLoginTwoFactor(r.Context(), user.ID, req.Code, ah.handler.Config.AppURL)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrAccountLocked):
+				WriteError(w, http.StatusTooManyRequests, "too many invalid two-factor codes, please try again later")
+			case errors.Is(err, service.ErrInvalidTwoFactorCode):
+				WriteError(w, http.StatusUnauthorized, "invalid two-factor code")
+			default:
+				ah.serverError(w, r, err)
+			}
+			return
+		}
+	}
+
+	name := strings.TrimSpace(req.TokenName)
+	if name == "" {
+		name = "API login"
+	}
+
+	scopes := []string{config.AccessTokenScopeRead, config.AccessTokenScopeWrite}
+
+	token, err := ah.authService.CreateAccessToken(r.Context(), user.ID, name, scopes, loginTokenTTL)
+	if err != nil {
+		ah.serverError(w, r, err)
+		return
+	}
+
+	err = ah.authService.RecordAuditEvent(r.Context(), service.AuditEvent{
+		Action:   config.AuditLoginSucceeded,
+		ActorID:  user.ID,
+		TargetID: user.ID,
+		Metadata: map[string]any{"method": "api"},
+	})
+	if err != nil {
+		ah.serverError(w, r, err)
+		return
+	}
+
+	ah.writeData(w, http.StatusCreated, LoginResponse{
+		Token:     token,
+		ExpiresAt: time.Now().Add(loginTokenTTL),
+		User:      newUser(user),
+	})
+}
@@ -0,0 +1,178 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go-web-starter/internal/openapi"
+	"go-web-starter/internal/server"
+	"go-web-starter/internal/tests"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// assertContract checks a response against the operation of the route pattern
+func assertContract(t *testing.T, doc *openapi.Document, method, pattern string, status int, headers http.Header, body string) {
+	t.Helper()
+
+	if err := doc.ValidateResponse(method, pattern, status, headers.Get("Content-Type"), []byte(body)); err != nil {
+		t.Errorf("response breaks the OpenAPI document: %v\n%s", err, body)
+	}
+}
+
+func TestOpenAPIContract(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	doc, err := server.OpenAPIDocument(ts.Config)
+	if err != nil {
+		t.Fatalf("generating the document: %v", err)
+	}
+
+	t.Run("document is served", func(t *testing.T) {
+		status, headers, body := ts.Get(t, "/api/openapi.json")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, headers.Get("Content-Type"), "application/json")
+
+		expected, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body != string(expected) {
+			t.Error("expected the served document to match the generated one")
+		}
+	})
+
+	t.Run("docs page is disabled by default", func(t *testing.T) {
+		status, _, _ := ts.Get(t, "/api/docs")
+		tests.AssertStatus(t, status, http.StatusNotFound)
+	})
+
+	t.Run("every API route is documented", func(t *testing.T) {
+		routes := ts.HTTPServer.RegisterRoutes().(chi.Routes)
+
+		err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+			if strings.HasPrefix(route, "/api/v1/") && doc.Operation(method, route) == nil {
+				t.Errorf("%s %s is missing from the OpenAPI document", method, route)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("health", func(t *testing.T) {
+		status, headers, body := ts.Get(t, "/health")
+		tests.AssertStatus(t, status, http.StatusOK)
+		assertContract(t, doc, http.MethodGet, "/health", status, headers, body)
+	})
+
+	t.Run("auth forms", func(t *testing.T) {
+		ts.CreateTestUser(t, "Form User", "contract-form@example.com", "Password123!")
+
+		status, headers, body := ts.PostForm(t, "/login", map[string]string{
+			"email":    "contract-form@example.com",
+			"password": "WrongPassword!",
+		})
+		tests.AssertStatus(t, status, http.StatusOK)
+		assertContract(t, doc, http.MethodPost, "/login", status, headers, body)
+
+		status, headers, body = ts.PostForm(t, "/login", map[string]string{
+			"email":    "contract-form@example.com",
+			"password": "Password123!",
+		})
+		tests.AssertStatus(t, status, http.StatusSeeOther)
+		assertContract(t, doc, http.MethodPost, "/login", status, headers, body)
+
+		status, headers, body = ts.PostForm(t, "/signup", map[string]string{
+			"name":  "Form User",
+			"email": "not-an-email",
+		})
+		tests.AssertStatus(t, status, http.StatusOK)
+		assertContract(t, doc, http.MethodPost, "/signup", status, headers, body)
+
+		status, headers, body = ts.PostForm(t, "/forgot-password", map[string]string{
+			"email": "contract-form@example.com",
+		})
+		tests.AssertStatus(t, status, http.StatusOK)
+		assertContract(t, doc, http.MethodPost, "/forgot-password", status, headers, body)
+	})
+
+	t.Run("api", func(t *testing.T) {
+		signUp := map[string]string{
+			"name":             "Contract User",
+			"email":            "contract-api@example.com",
+			"password":         "Password123!",
+			"confirm_password": "Password123!",
+		}
+
+		status, headers, body := ts.DoJSON(t, http.MethodPost, "/api/v1/signup", "", signUp)
+		tests.AssertStatus(t, status, http.StatusCreated)
+		assertContract(t, doc, http.MethodPost, "/api/v1/signup", status, headers, body)
+
+		status, headers, body = ts.DoJSON(t, http.MethodPost, "/api/v1/signup", "", signUp)
+		tests.AssertStatus(t, status, http.StatusUnprocessableEntity)
+		assertContract(t, doc, http.MethodPost, "/api/v1/signup", status, headers, body)
+
+		status, headers, body = ts.DoWithToken(t, http.MethodPost, "/api/v1/signup", "", strings.NewReader(`{"name":`))
+		tests.AssertStatus(t, status, http.StatusBadRequest)
+		assertContract(t, doc, http.MethodPost, "/api/v1/signup", status, headers, body)
+
+		status, headers, body = ts.DoJSON(t, http.MethodPost, "/api/v1/login", "", map[string]string{
+			"email":    "contract-api@example.com",
+			"password": "WrongPassword!",
+		})
+		tests.AssertStatus(t, status, http.StatusUnauthorized)
+		assertContract(t, doc, http.MethodPost, "/api/v1/login", status, headers, body)
+
+		status, headers, body = ts.DoJSON(t, http.MethodPost, "/api/v1/login", "", map[string]string{
+			"email":      "contract-api@example.com",
+			"password":   "Password123!",
+			"token_name": "contract",
+		})
+		tests.AssertStatus(t, status, http.StatusCreated)
+		assertContract(t, doc, http.MethodPost, "/api/v1/login", status, headers, body)
+
+		token := decode[loginResponse](t, body).Data.Token
+
+		status, headers, body = ts.DoJSON(t, http.MethodGet, "/api/v1/profile", "", nil)
+		tests.AssertStatus(t, status, http.StatusUnauthorized)
+		assertContract(t, doc, http.MethodGet, "/api/v1/profile", status, headers, body)
+
+		status, headers, body = ts.DoJSON(t, http.MethodGet, "/api/v1/profile", token, nil)
+		tests.AssertStatus(t, status, http.StatusOK)
+		assertContract(t, doc, http.MethodGet, "/api/v1/profile", status, headers, body)
+
+		status, headers, body = ts.DoJSON(t, http.MethodPatch, "/api/v1/profile", token, map[string]string{
+			"name":  "Renamed",
+			"image": "https://example.com/avatar.png",
+		})
+		tests.AssertStatus(t, status, http.StatusOK)
+		assertContract(t, doc, http.MethodPatch, "/api/v1/profile", status, headers, body)
+
+		status, headers, body = ts.DoJSON(t, http.MethodPut, "/api/v1/profile/password", token, map[string]string{
+			"current_password": "WrongPassword!",
+			"new_password":     "NewPassword123!",
+			"confirm_password": "NewPassword123!",
+		})
+		tests.AssertStatus(t, status, http.StatusUnprocessableEntity)
+		assertContract(t, doc, http.MethodPut, "/api/v1/profile/password", status, headers, body)
+
+		status, headers, body = ts.DoJSON(t, http.MethodPut, "/api/v1/profile/password", token, map[string]string{
+			"current_password": "Password123!",
+			"new_password":     "NewPassword123!",
+			"confirm_password": "NewPassword123!",
+		})
+		tests.AssertStatus(t, status, http.StatusNoContent)
+		assertContract(t, doc, http.MethodPut, "/api/v1/profile/password", status, headers, body)
+
+		status, headers, body = ts.DoJSON(t, http.MethodDelete, "/api/v1/profile", token, map[string]string{
+			"password": "NewPassword123!",
+		})
+		tests.AssertStatus(t, status, http.StatusNoContent)
+		assertContract(t, doc, http.MethodDelete, "/api/v1/profile", status, headers, body)
+	})
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/service"
+	"net/http"
+)
+
+func (ah *APIHandler) ProfileHandler(w http.ResponseWriter, r *http.Request) {
+	ah.writeData(w, http.StatusOK, newUser(ah.handler.GetUser(r)))
+}
+
+func (ah *APIHandler) UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.UpdateUserNameAndImageForm
+
+	if !ah.decodeRequest(w, r, &form) {
+		return
+	}
+
+	form.Validate()
+	if !form.Valid() {
+		ah.validationError(w, form.Validator)
+		return
+	}
+
+	user := ah.handler.GetUser(r)
+
+	updated, err := ah.authService.UpdateUserNameAndImage(r.Context(), user.ID, form.Name, form.Image)
+	if err != nil {
+		ah.serverError(w, r, err)
+		return
+	}
+
+	ah.writeData(w, http.StatusOK, newUser(&updated))
+}
+
+// UpdatePasswordHandler changes the password. There is no session to keep, so all
+// browser sessions of the user are signed out.
+func (ah *APIHandler) UpdatePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.UpdateAccountPasswordForm
+
+	if !ah.decodeRequest(w, r, &form) {
+		return
+	}
+
+	form.Validate()
+	if !form.Valid() {
+		ah.validationError(w, form.Validator)
+		return
+	}
+
+	user := ah.handler.GetUser(r)
+
+	err := ah.authService.UpdateAccountPassword(r.Context(), user.ID, form.CurrentPassword, form.NewPassword, "")
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPassword) {
+			ah.fieldError(w, "current_password", "Current password is incorrect")
+			return
+		}
+
+		ah.serverError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *APIHandler) DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.DeleteAccountForm
+
+	if !ah.decodeRequest(w, r, &form) {
+		return
+	}
+
+	form.Validate()
+	if !form.Valid() {
+		ah.validationError(w, form.Validator)
+		return
+	}
+
+	user := ah.handler.GetUser(r)
+
+	err := ah.authService.DeleteAccount(r.Context(), user.Email, form.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPassword) {
+			ah.fieldError(w, "password", "Password is incorrect")
+			return
+		}
+
+		ah.serverError(w, r, err)
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("account deleted", map[string]string{
+		"user_id": fmt.Sprintf("%d", user.ID),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"go-web-starter/cmd/web/views/auth"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/service"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/angelofallars/htmx-go"
+	"github.com/go-chi/chi/v5"
+)
+
+// accessTokenExpiryDays are the lifetimes users can pick for a token, 0 never expires
+var accessTokenExpiryDays = []int{30, 90, 365, 0}
+
+func (ah *AuthHandler) AccessTokensSectionHandler(w http.ResponseWriter, r *http.Request) {
+	ah.renderAccessTokensSection(w, r, newAccessTokenForm(), "")
+}
+
+// newAccessTokenForm returns the defaults of the create token form
+func newAccessTokenForm() forms.AccessTokenForm {
+	return forms.AccessTokenForm{
+		Scopes:    []string{config.AccessTokenScopeRead},
+		ExpiresIn: accessTokenExpiryDays[0],
+	}
+}
+
+// renderAccessTokensSection renders the tokens of the user. newToken is the plaintext
+// of a token that was just created, it can't be shown again later.
+func (ah *AuthHandler) renderAccessTokensSection(w http.ResponseWriter, r *http.Request, form forms.AccessTokenForm, newToken string) {
+	user := ah.handler.GetUser(r)
+
+	tokens, err := ah.authService.ListAccessTokens(r.Context(), user.ID)
+	if err != nil {
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	htmx.NewResponse().RenderTempl(r.Context(), w, auth.AccessTokensSection(data, tokens, form, accessTokenExpiryDays, newToken))
+}
+
+func (ah *AuthHandler) CreateAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.AccessTokenForm
+
+	err := ah.handler.DecodePostForm(r, &form)
+	if err != nil {
+		form.SetMessage("Invalid form data", forms.MessageTypeError)
+		ah.renderAccessTokensSection(w, r, form, "")
+		return
+	}
+
+	// Validation
+	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Name, 64), "name", "This field cannot be more than 64 characters long")
+	form.CheckField(len(form.Scopes) > 0, "scopes", "Select at least one scope")
+	for _, scope := range form.Scopes {
+		form.CheckField(slices.Contains(config.AccessTokenScopes, scope), "scopes", "Unknown scope")
+	}
+	form.CheckField(validator.PermittedInt(form.ExpiresIn, accessTokenExpiryDays...), "expires_in", "Select an expiration")
+
+	if !form.Valid() {
+		ah.renderAccessTokensSection(w, r, form, "")
+		return
+	}
+
+	user := ah.handler.GetUser(r)
+
+	token, err := ah.authService.CreateAccessToken(r.Context(), user.ID, form.Name, form.Scopes, time.Duration(form.ExpiresIn)*24*time.Hour)
+	if err != nil {
+		ah.handler.Logger.PrintError(err, map[string]string{
+			"user_id": fmt.Sprintf("%d", user.ID),
+		})
+		form.SetMessage("Failed to create the token. Please try again.", forms.MessageTypeError)
+		ah.renderAccessTokensSection(w, r, form, "")
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("access token created", map[string]string{
+		"user_id": fmt.Sprintf("%d", user.ID),
+	})
+
+	ah.renderAccessTokensSection(w, r, newAccessTokenForm(), token)
+}
+
+func (ah *AuthHandler) RevokeAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
+	form := newAccessTokenForm()
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	user := ah.handler.GetUser(r)
+
+	err = ah.authService.RevokeAccessToken(r.Context(), user.ID, int32(id))
+	if err != nil {
+		if !errors.Is(err, service.ErrAccessTokenNotFound) {
+			ah.handler.Logger.PrintError(err, nil)
+		}
+		form.SetMessage("Failed to revoke the token. Please try again.", forms.MessageTypeError)
+		ah.renderAccessTokensSection(w, r, form, "")
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("access token revoked", map[string]string{
+		"user_id":  fmt.Sprintf("%d", user.ID),
+		"token_id": fmt.Sprintf("%d", id),
+	})
+
+	form.SetMessage("The token has been revoked.", forms.MessageTypeSuccess)
+	ah.renderAccessTokensSection(w, r, form, "")
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"go-web-starter/cmd/web/components"
+	"go-web-starter/cmd/web/views/auth"
+	"go-web-starter/internal/service"
+	"net/http"
+
+	"github.com/angelofallars/htmx-go"
+)
+
+func (ah *AuthHandler) ActivateHandler(w http.ResponseWriter, r *http.Request) {
+	plainTextToken := r.URL.Query().Get("token")
+
+	redirectURL := "/login"
+	if ah.handler.IsAuthenticated(r) {
+		redirectURL = "/dashboard"
+	}
+
+	// validate the token format - should be 26 characters (base32 encoded 16 bytes)
+	if len(plainTextToken) != 26 {
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Invalid or expired activation link.")
+		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+		return
+	}
+
+	user, err := ah.authService.ActivateUser(r.Context(), plainTextToken)
+	if err != nil {
+		ah.handler.Logger.PrintError(err, map[string]string{
+			"request_url": r.URL.Path,
+		})
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Invalid or expired activation link. Please request a new one.")
+		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("email address verified", map[string]string{
+		"user_id": fmt.Sprintf("%d", user.ID),
+	})
+
+	ah.handler.SessionManager.Put(r.Context(), "flash", "Your email address has been verified!")
+	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+}
+
+func (ah *AuthHandler) VerifyEmailView(w http.ResponseWriter, r *http.Request) {
+	data := ah.handler.NewTemplateData(r)
+	data.PageTitle = "Verify your email"
+
+	if data.User.EmailVerified {
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	auth.VerifyEmailView(data).Render(r.Context(), w)
+}
+
+func (ah *AuthHandler) ResendActivationHandler(w http.ResponseWriter, r *http.Request) {
+	user := ah.handler.GetUser(r)
+
+	err := ah.authService.SendActivationEmail(r.Context(), user, ah.handler.Config.AppURL)
+	if err != nil {
+		if errors.Is(err, service.ErrEmailAlreadyVerified) {
+			htmx.NewResponse().RenderTempl(r.Context(), w,
+				components.FlashMessage("Your email address is already verified.", components.FlashInfo),
+			)
+			return
+		}
+
+		ah.handler.Logger.PrintError(err, nil)
+		htmx.NewResponse().RenderTempl(r.Context(), w,
+			components.FlashMessage("Could not send the verification email. Please try again later!", components.FlashError),
+		)
+		return
+	}
+
+	htmx.NewResponse().RenderTempl(r.Context(), w,
+		components.FlashMessage("A new verification link has been sent to "+user.Email+".", components.FlashSuccess),
+	)
+}
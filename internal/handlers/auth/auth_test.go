@@ -1,11 +1,24 @@
 package auth_test
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/queries"
 	"go-web-starter/internal/tests"
+
+	"github.com/pquerna/otp/totp"
 )
 
 func TestSignUpHandler_GET(t *testing.T) {
@@ -103,6 +116,7 @@ func TestSignUpHandler_POST(t *testing.T) {
 			ts.Mailer.Clear()
 
 			status, headers, _ := ts.PostForm(t, "/signup", tc.formData)
+			ts.RunJobs(t)
 
 			if status != tc.expectedStatus {
 				t.Errorf("expected status %d; got %d", tc.expectedStatus, status)
@@ -116,20 +130,20 @@ func TestSignUpHandler_POST(t *testing.T) {
 			}
 
 			if tc.expectEmail {
-				emails := ts.Mailer.GetSentEmails()
+				emails := ts.Mailer.Messages()
 				if len(emails) == 0 {
 					t.Errorf("sent emails:%v = expected welcome email to be sent", emails)
 				} else {
 					lastEmail := emails[0]
-					if lastEmail.Recipient != tc.formData["email"] {
-						t.Errorf("email sent to wrong recipient: %s", lastEmail.Recipient)
+					if lastEmail.Recipient() != tc.formData["email"] {
+						t.Errorf("email sent to wrong recipient: %s", lastEmail.Recipient())
 					}
-					if lastEmail.TemplateFile != "user_welcome.tmpl" {
-						t.Errorf("wrong email template: %s", lastEmail.TemplateFile)
+					if _, ok := lastEmail.Email.(mailer.WelcomeEmail); !ok {
+						t.Errorf("wrong email: %T", lastEmail.Email)
 					}
 				}
 			} else {
-				if ts.Mailer.EmailCount() > 0 {
+				if ts.Mailer.Count() > 0 {
 					t.Error("no email should be sent for invalid signup")
 				}
 			}
@@ -364,20 +378,20 @@ func TestForgotPasswordHandler_POST(t *testing.T) {
 			}
 
 			if tc.expectEmail {
-				emails := ts.Mailer.GetSentEmails()
+				emails := ts.Mailer.Messages()
 				if len(emails) == 0 {
 					t.Error("expected password reset email to be sent")
 				} else {
 					lastEmail := emails[0]
-					if lastEmail.Recipient != tc.formData["email"] {
-						t.Errorf("email sent to wrong recipient: %s", lastEmail.Recipient)
+					if lastEmail.Recipient() != tc.formData["email"] {
+						t.Errorf("email sent to wrong recipient: %s", lastEmail.Recipient())
 					}
-					if lastEmail.TemplateFile != "reset_password.tmpl" {
-						t.Errorf("wrong email template: %s", lastEmail.TemplateFile)
+					if _, ok := lastEmail.Email.(mailer.PasswordResetEmail); !ok {
+						t.Errorf("wrong email: %T", lastEmail.Email)
 					}
 				}
 			} else {
-				if ts.Mailer.EmailCount() > 0 {
+				if ts.Mailer.Count() > 0 {
 					t.Error("no email should be sent for invalid request")
 				}
 			}
@@ -610,6 +624,7 @@ func TestDuplicateUserSignup(t *testing.T) {
 	}
 
 	status, _, _ := ts.PostForm(t, "/signup", formData)
+	ts.RunJobs(t)
 
 	// Should fail with status OK (form with error)
 	if status != http.StatusOK {
@@ -617,8 +632,1184 @@ func TestDuplicateUserSignup(t *testing.T) {
 	}
 
 	// No welcome email should be sent
-	emails := ts.Mailer.GetSentEmails()
+	emails := ts.Mailer.Messages()
 	if len(emails) > 0 {
 		t.Error("no email should be sent for duplicate signup")
 	}
 }
+
+func TestActivationFlow(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	formData := map[string]string{
+		"name":             "New User",
+		"email":            "new@example.com",
+		"password":         "Password123!",
+		"confirm_password": "Password123!",
+	}
+
+	status, _, _ := ts.PostForm(t, "/signup", formData)
+	tests.AssertStatus(t, status, http.StatusSeeOther)
+
+	// the emails are sent by the workers, not within the request
+	if ts.Mailer.Count() != 0 {
+		t.Fatalf("expected no email before the jobs ran; got %d", ts.Mailer.Count())
+	}
+	if count := ts.RunJobs(t); count != 2 {
+		t.Fatalf("expected the welcome email and the activation job; got %d", count)
+	}
+
+	email := ts.Mailer.Last()
+	if email == nil {
+		t.Fatal("expected activation email to be sent")
+	}
+
+	activation, ok := email.Email.(mailer.ActivationEmail)
+	if !ok {
+		t.Fatalf("expected activation email to be sent; got %T", email.Email)
+	}
+
+	activationLink := activation.Link
+	activationPath := strings.TrimPrefix(activationLink, ts.Config.AppURL)
+	if !strings.HasPrefix(activationPath, "/activate?token=") || len(activationPath) == len("/activate?token=") {
+		t.Fatalf("activation link is missing its token: %q", activationLink)
+	}
+
+	status, headers, _ := ts.Get(t, activationPath)
+	tests.AssertRedirect(t, status, headers, "/login")
+
+	user, err := ts.Queries.GetUserByEmail(context.Background(), "new@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.EmailVerified {
+		t.Error("expected email to be verified after activation")
+	}
+
+	// the token is single use
+	status, headers, _ = ts.Get(t, activationPath)
+	tests.AssertRedirect(t, status, headers, "/login")
+}
+
+func TestUnverifiedUserPolicy(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	status, _, _ := ts.PostForm(t, "/signup", map[string]string{
+		"name":             "Unverified User",
+		"email":            "unverified@example.com",
+		"password":         "Password123!",
+		"confirm_password": "Password123!",
+	})
+	tests.AssertStatus(t, status, http.StatusSeeOther)
+
+	client := ts.LoginUser(t, "unverified@example.com", "Password123!")
+
+	testCases := []struct {
+		policy         string
+		method         string
+		path           string
+		expectRedirect bool
+	}{
+		{config.UnverifiedPolicyAllow, http.MethodGet, "/dashboard", false},
+		{config.UnverifiedPolicyLimit, http.MethodGet, "/dashboard", false},
+		{config.UnverifiedPolicyLimit, http.MethodPost, "/profile/update", true},
+		{config.UnverifiedPolicyBlock, http.MethodGet, "/dashboard", true},
+		{config.UnverifiedPolicyBlock, http.MethodGet, "/verify-email", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy+" "+tc.method+" "+tc.path, func(t *testing.T) {
+			ts.HTTPServer.Config.Auth.UnverifiedUserPolicy = tc.policy
+
+			var headers http.Header
+			if tc.method == http.MethodGet {
+				status, headers, _ = ts.GetWithClient(t, client, tc.path)
+			} else {
+				status, headers, _ = ts.PostFormWithClient(t, client, tc.path, map[string]string{"name": "New Name"})
+			}
+
+			if tc.expectRedirect {
+				tests.AssertRedirect(t, status, headers, "/verify-email")
+			} else {
+				tests.AssertStatus(t, status, http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestTwoFactorAuthentication(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ts.CreateTestUser(t, "Two Factor", "2fa@example.com", "Password123!")
+	client := ts.LoginUser(t, "2fa@example.com", "Password123!")
+
+	// start the setup and read the secret an authenticator app would scan
+	status, _, body := ts.PostFormWithClient(t, client, "/profile/2fa/setup", nil)
+	tests.AssertStatus(t, status, http.StatusOK)
+
+	match := regexp.MustCompile(`<code id="totp-secret"[^>]*>([A-Z2-7]+)</code>`).FindStringSubmatch(body)
+	if match == nil {
+		t.Fatalf("expected the TOTP secret in the setup form; got %s", body)
+	}
+	secret := match[1]
+
+	status, _, body = ts.PostFormWithClient(t, client, "/profile/2fa/confirm", map[string]string{"code": "abcdef"})
+	tests.AssertStatus(t, status, http.StatusOK)
+	tests.AssertContains(t, body, "The code is not valid")
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, _, body = ts.PostFormWithClient(t, client, "/profile/2fa/confirm", map[string]string{"code": code})
+	tests.AssertStatus(t, status, http.StatusOK)
+
+	recoveryCodes := regexp.MustCompile(`<li>([a-z2-7]{5}-[a-z2-7]{5})</li>`).FindAllStringSubmatch(body, -1)
+	if len(recoveryCodes) != 10 {
+		t.Fatalf("expected 10 recovery codes; got %d", len(recoveryCodes))
+	}
+
+	loginWithPassword := func(t *testing.T) *http.Client {
+		t.Helper()
+
+		client := ts.NewClientWithCookies(t)
+		status, headers, _ := ts.PostFormWithClient(t, client, "/login", map[string]string{
+			"email":    "2fa@example.com",
+			"password": "Password123!",
+		})
+		tests.AssertRedirect(t, status, headers, "/login/2fa")
+
+		// the password alone must not be enough
+		status, headers, _ = ts.GetWithClient(t, client, "/dashboard")
+		tests.AssertRedirect(t, status, headers, "/login?next=/dashboard")
+
+		return client
+	}
+
+	t.Run("recovery code", func(t *testing.T) {
+		client := loginWithPassword(t)
+
+		status, headers, _ := ts.PostFormWithClient(t, client, "/login/2fa", map[string]string{"code": recoveryCodes[0][1]})
+		tests.AssertRedirect(t, status, headers, "/dashboard")
+
+		status, _, _ = ts.GetWithClient(t, client, "/dashboard")
+		tests.AssertStatus(t, status, http.StatusOK)
+
+		// recovery codes are single use
+		client = loginWithPassword(t)
+
+		status, _, body := ts.PostFormWithClient(t, client, "/login/2fa", map[string]string{"code": recoveryCodes[0][1]})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Invalid authentication code")
+	})
+
+	t.Run("authenticator code", func(t *testing.T) {
+		client := loginWithPassword(t)
+
+		// the code used for the confirmation can't be replayed, use the next one
+		code, err := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, headers, _ := ts.PostFormWithClient(t, client, "/login/2fa", map[string]string{"code": code})
+		tests.AssertRedirect(t, status, headers, "/dashboard")
+	})
+
+	t.Run("too many attempts", func(t *testing.T) {
+		client := loginWithPassword(t)
+
+		for range 4 {
+			status, _, _ := ts.PostFormWithClient(t, client, "/login/2fa", map[string]string{"code": "000000"})
+			tests.AssertStatus(t, status, http.StatusOK)
+		}
+
+		status, headers, _ := ts.PostFormWithClient(t, client, "/login/2fa", map[string]string{"code": "000000"})
+		tests.AssertRedirect(t, status, headers, "/login")
+	})
+
+	t.Run("invalid codes lock the account", func(t *testing.T) {
+		// the attempts above reached the threshold, a new password login doesn't reset them
+		client := loginWithPassword(t)
+
+		status, headers, _ := ts.PostFormWithClient(t, client, "/login/2fa", map[string]string{"code": recoveryCodes[1][1]})
+		tests.AssertRedirect(t, status, headers, "/login")
+
+		_, _, body := ts.GetWithClient(t, client, "/login")
+		tests.AssertContains(t, body, "Too many failed login attempts")
+
+		email := ts.Mailer.Last()
+		if email == nil || email.Recipient() != "2fa@example.com" {
+			t.Fatalf("expected the lockout email; got %+v", email)
+		}
+		if _, ok := email.Email.(mailer.AccountLockedEmail); !ok {
+			t.Fatalf("expected the lockout email; got %T", email.Email)
+		}
+	})
+
+	t.Run("a password reset unlocks the second factor", func(t *testing.T) {
+		locked, ok := ts.Mailer.Last().Email.(mailer.AccountLockedEmail)
+		if !ok {
+			t.Fatalf("expected the lockout email; got %T", ts.Mailer.Last().Email)
+		}
+
+		link, err := url.Parse(locked.PasswordResetLink)
+		if err != nil || link.Path != "/reset-password" {
+			t.Fatalf("unexpected password reset link %q", locked.PasswordResetLink)
+		}
+
+		status, _, body := ts.PostForm(t, "/reset-password", map[string]string{
+			"token":    link.Query().Get("token"),
+			"password": "NewPassword123!",
+		})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Password reset successfully!")
+
+		// the reset signed out every session, log in again for the tests below
+		client = ts.NewClientWithCookies(t)
+		status, headers, _ := ts.PostFormWithClient(t, client, "/login", map[string]string{
+			"email":    "2fa@example.com",
+			"password": "NewPassword123!",
+		})
+		tests.AssertRedirect(t, status, headers, "/login/2fa")
+
+		status, headers, _ = ts.PostFormWithClient(t, client, "/login/2fa", map[string]string{"code": recoveryCodes[1][1]})
+		tests.AssertRedirect(t, status, headers, "/dashboard")
+	})
+
+	t.Run("disable", func(t *testing.T) {
+		status, _, body := ts.PostFormWithClient(t, client, "/profile/2fa/disable", map[string]string{"password": "wrong"})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Failed to disable two-factor authentication")
+
+		status, _, body = ts.PostFormWithClient(t, client, "/profile/2fa/disable", map[string]string{"password": "NewPassword123!"})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Two-factor authentication has been disabled.")
+
+		client := ts.NewClientWithCookies(t)
+		status, headers, _ := ts.PostFormWithClient(t, client, "/login", map[string]string{
+			"email":    "2fa@example.com",
+			"password": "NewPassword123!",
+		})
+		tests.AssertRedirect(t, status, headers, "/dashboard")
+	})
+}
+
+func TestPasskeys(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ts.CreateTestUser(t, "Passkey User", "passkey@example.com", "Password123!")
+	client := ts.LoginUser(t, "passkey@example.com", "Password123!")
+
+	authenticator := tests.NewSoftAuthenticator(ts.Config.Auth.WebAuthnOrigins[0])
+
+	// registration ceremony from the profile page
+	status, _, options := ts.PostJSONWithClient(t, client, "/profile/passkeys/register/begin", "")
+	tests.AssertStatus(t, status, http.StatusOK)
+
+	response := authenticator.CreateCredential(t, options)
+
+	status, _, body := ts.PostJSONWithClient(t, client, "/profile/passkeys/register/finish?name=Laptop", response)
+	tests.AssertStatus(t, status, http.StatusCreated)
+
+	var created struct {
+		ID int32 `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(body), &created); err != nil {
+		t.Fatalf("unexpected registration response %q: %v", body, err)
+	}
+
+	status, _, body = ts.GetWithClient(t, client, "/profile/passkeys")
+	tests.AssertStatus(t, status, http.StatusOK)
+	tests.AssertContains(t, body, `value="Laptop"`)
+
+	loginWithPasskey := func(t *testing.T) (*http.Client, int, string) {
+		t.Helper()
+
+		client := ts.NewClientWithCookies(t)
+
+		status, _, options := ts.PostJSONWithClient(t, client, "/login/passkey/begin", "")
+		tests.AssertStatus(t, status, http.StatusOK)
+
+		status, _, body := ts.PostJSONWithClient(t, client, "/login/passkey/finish", authenticator.GetAssertion(t, options))
+		return client, status, body
+	}
+
+	t.Run("login", func(t *testing.T) {
+		passkeyClient, status, body := loginWithPasskey(t)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, `"redirect":"/dashboard"`)
+
+		status, _, _ = ts.GetWithClient(t, passkeyClient, "/dashboard")
+		tests.AssertStatus(t, status, http.StatusOK)
+	})
+
+	t.Run("assertion without a running ceremony", func(t *testing.T) {
+		status, _, options := ts.PostJSONWithClient(t, ts.NewClientWithCookies(t), "/login/passkey/begin", "")
+		tests.AssertStatus(t, status, http.StatusOK)
+
+		// posted from a client that never started the ceremony
+		status, _, _ = ts.PostJSONWithClient(t, ts.NewClientWithCookies(t), "/login/passkey/finish", authenticator.GetAssertion(t, options))
+		tests.AssertStatus(t, status, http.StatusBadRequest)
+	})
+
+	t.Run("user verification is required", func(t *testing.T) {
+		authenticator.NoUserVerification = true
+		defer func() { authenticator.NoUserVerification = false }()
+
+		_, status, _ := loginWithPasskey(t)
+		tests.AssertStatus(t, status, http.StatusUnauthorized)
+
+		status, _, options := ts.PostJSONWithClient(t, client, "/profile/passkeys/register/begin", "")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, options, `"userVerification":"required"`)
+
+		status, _, _ = ts.PostJSONWithClient(t, client, "/profile/passkeys/register/finish?name=Key", authenticator.CreateCredential(t, options))
+		tests.AssertStatus(t, status, http.StatusBadRequest)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		status, _, body := ts.PostFormWithClient(t, client, fmt.Sprintf("/profile/passkeys/%d/rename", created.ID), map[string]string{"name": "YubiKey"})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, `value="YubiKey"`)
+	})
+
+	t.Run("other users can't remove it", func(t *testing.T) {
+		ts.CreateTestUser(t, "Other User", "other@example.com", "Password123!")
+		otherClient := ts.LoginUser(t, "other@example.com", "Password123!")
+
+		status, _, body := ts.PostFormWithClient(t, otherClient, fmt.Sprintf("/profile/passkeys/%d/delete", created.ID), nil)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Failed to remove the passkey")
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		status, _, body := ts.PostFormWithClient(t, client, fmt.Sprintf("/profile/passkeys/%d/delete", created.ID), nil)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "You have not added any passkeys yet.")
+
+		_, status, _ = loginWithPasskey(t)
+		tests.AssertStatus(t, status, http.StatusUnauthorized)
+	})
+}
+
+func TestMagicLinkLogin(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ts.CreateTestUser(t, "Magic User", "magic@example.com", "Password123!")
+
+	requestLink := func(t *testing.T, formData map[string]string) url.Values {
+		t.Helper()
+
+		ts.Mailer.Clear()
+
+		status, _, body := ts.PostForm(t, "/login/magic-link", formData)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "If an account with this email exists, a sign-in link has been sent to it.")
+
+		email := ts.Mailer.Last()
+		if email == nil {
+			t.Fatal("expected sign-in email to be sent")
+		}
+
+		signIn, ok := email.Email.(mailer.LoginLinkEmail)
+		if !ok {
+			t.Fatalf("expected sign-in email to be sent; got %T", email.Email)
+		}
+		loginLink := signIn.Link
+
+		link, err := url.Parse(loginLink)
+		if err != nil || link.Path != "/login/magic" {
+			t.Fatalf("unexpected sign-in link %q", loginLink)
+		}
+
+		return link.Query()
+	}
+
+	t.Run("unknown email", func(t *testing.T) {
+		ts.Mailer.Clear()
+
+		status, _, body := ts.PostForm(t, "/login/magic-link", map[string]string{"email": "nobody@example.com"})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "If an account with this email exists, a sign-in link has been sent to it.")
+
+		if ts.Mailer.Count() != 0 {
+			t.Errorf("expected no email for an unknown address; got %d", ts.Mailer.Count())
+		}
+	})
+
+	t.Run("single use link", func(t *testing.T) {
+		params := requestLink(t, map[string]string{"email": "magic@example.com"})
+		client := ts.NewClientWithCookies(t)
+
+		// opening the link only shows the confirmation, it doesn't use up the token
+		status, _, body := ts.GetWithClient(t, client, "/login/magic?"+params.Encode())
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, params.Get("token"))
+
+		status, headers, _ := ts.PostFormWithClient(t, client, "/login/magic", map[string]string{"token": params.Get("token")})
+		tests.AssertRedirect(t, status, headers, "/dashboard")
+
+		status, _, _ = ts.GetWithClient(t, client, "/dashboard")
+		tests.AssertStatus(t, status, http.StatusOK)
+
+		status, headers, _ = ts.PostFormWithClient(t, ts.NewClientWithCookies(t), "/login/magic", map[string]string{"token": params.Get("token")})
+		tests.AssertRedirect(t, status, headers, "/login")
+	})
+
+	t.Run("only the latest link works", func(t *testing.T) {
+		first := requestLink(t, map[string]string{"email": "magic@example.com"})
+		requestLink(t, map[string]string{"email": "magic@example.com"})
+
+		status, headers, _ := ts.PostFormWithClient(t, ts.NewClientWithCookies(t), "/login/magic", map[string]string{"token": first.Get("token")})
+		tests.AssertRedirect(t, status, headers, "/login")
+	})
+
+	t.Run("next path", func(t *testing.T) {
+		params := requestLink(t, map[string]string{"email": "magic@example.com", "next": "/profile"})
+		if params.Get("next") != "/profile" {
+			t.Fatalf("expected the next path in the sign-in link; got %q", params.Get("next"))
+		}
+
+		status, headers, _ := ts.PostFormWithClient(t, ts.NewClientWithCookies(t), "/login/magic", map[string]string{
+			"token": params.Get("token"),
+			"next":  params.Get("next"),
+		})
+		tests.AssertRedirect(t, status, headers, "/profile")
+	})
+}
+
+func TestActiveSessions(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	user := ts.CreateTestUser(t, "Session User", "sessions@example.com", "Password123!")
+
+	assertLoggedIn := func(t *testing.T, client *http.Client, loggedIn bool) {
+		t.Helper()
+
+		status, headers, _ := ts.GetWithClient(t, client, "/dashboard")
+		if loggedIn {
+			tests.AssertStatus(t, status, http.StatusOK)
+		} else {
+			tests.AssertRedirect(t, status, headers, "/login?next=/dashboard")
+		}
+	}
+
+	t.Run("list and revoke one session", func(t *testing.T) {
+		laptop := ts.LoginUser(t, "sessions@example.com", "Password123!")
+		phone := ts.LoginUser(t, "sessions@example.com", "Password123!")
+
+		status, _, body := ts.GetWithClient(t, laptop, "/profile/sessions")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "This device")
+		tests.AssertContains(t, body, "Sign out everywhere else")
+
+		sessions, err := ts.Queries.ListUserSessions(context.Background(), user.ID)
+		if err != nil || len(sessions) != 2 {
+			t.Fatalf("expected 2 sessions; got %d (%v)", len(sessions), err)
+		}
+
+		// the phone logged in last so it is listed first
+		status, _, body = ts.PostFormWithClient(t, laptop, fmt.Sprintf("/profile/sessions/%d/revoke", sessions[0].ID), nil)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "The session has been signed out.")
+
+		assertLoggedIn(t, phone, false)
+		assertLoggedIn(t, laptop, true)
+	})
+
+	t.Run("sessions of other users can't be revoked", func(t *testing.T) {
+		ts.CreateTestUser(t, "Other User", "other-sessions@example.com", "Password123!")
+		other := ts.LoginUser(t, "other-sessions@example.com", "Password123!")
+		client := ts.LoginUser(t, "sessions@example.com", "Password123!")
+
+		sessions, err := ts.Queries.ListUserSessions(context.Background(), user.ID)
+		if err != nil || len(sessions) == 0 {
+			t.Fatalf("expected sessions; got %v", err)
+		}
+
+		status, _, body := ts.PostFormWithClient(t, other, fmt.Sprintf("/profile/sessions/%d/revoke", sessions[0].ID), nil)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Failed to sign out the session.")
+
+		assertLoggedIn(t, client, true)
+	})
+
+	t.Run("sign out everywhere else", func(t *testing.T) {
+		current := ts.LoginUser(t, "sessions@example.com", "Password123!")
+		other := ts.LoginUser(t, "sessions@example.com", "Password123!")
+
+		status, _, body := ts.PostFormWithClient(t, current, "/profile/sessions/revoke-others", nil)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "All other sessions have been signed out.")
+
+		assertLoggedIn(t, other, false)
+		assertLoggedIn(t, current, true)
+	})
+
+	t.Run("logout removes the session", func(t *testing.T) {
+		client := ts.LoginUser(t, "sessions@example.com", "Password123!")
+		ts.PostFormWithClient(t, client, "/profile/sessions/revoke-others", nil)
+
+		status, headers, _ := ts.PostFormWithClient(t, client, "/logout", nil)
+		tests.AssertRedirect(t, status, headers, "/")
+
+		sessions, err := ts.Queries.ListUserSessions(context.Background(), user.ID)
+		if err != nil || len(sessions) != 0 {
+			t.Errorf("expected no sessions after logout; got %d (%v)", len(sessions), err)
+		}
+	})
+
+	t.Run("password change ends other sessions", func(t *testing.T) {
+		current := ts.LoginUser(t, "sessions@example.com", "Password123!")
+		other := ts.LoginUser(t, "sessions@example.com", "Password123!")
+
+		status, _, _ := ts.PostFormWithClient(t, current, "/profile/update-password", map[string]string{
+			"current_password": "Password123!",
+			"new_password":     "NewPassword123!",
+			"confirm_password": "NewPassword123!",
+		})
+		tests.AssertStatus(t, status, http.StatusOK)
+
+		assertLoggedIn(t, other, false)
+		assertLoggedIn(t, current, true)
+	})
+}
+
+func TestAccountLockout(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ts.CreateTestUser(t, "Locked User", "locked@example.com", "Password123!")
+
+	login := func(t *testing.T, email, password string) (int, string) {
+		t.Helper()
+
+		status, _, body := ts.PostForm(t, "/login", map[string]string{
+			"email":    email,
+			"password": password,
+		})
+		return status, body
+	}
+
+	failUntilLocked := func(t *testing.T, email string) {
+		t.Helper()
+
+		for i := 0; i < ts.Config.Auth.LockoutThreshold; i++ {
+			status, body := login(t, email, "WrongPassword123!")
+			tests.AssertStatus(t, status, http.StatusOK)
+			tests.AssertContains(t, body, "Invalid email or password")
+		}
+	}
+
+	t.Run("successful login resets the count", func(t *testing.T) {
+		for i := 0; i < ts.Config.Auth.LockoutThreshold-1; i++ {
+			login(t, "locked@example.com", "WrongPassword123!")
+		}
+
+		status, _ := login(t, "locked@example.com", "Password123!")
+		tests.AssertStatus(t, status, http.StatusSeeOther)
+
+		status, body := login(t, "locked@example.com", "WrongPassword123!")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Invalid email or password")
+	})
+
+	t.Run("unknown email", func(t *testing.T) {
+		ts.Mailer.Clear()
+
+		failUntilLocked(t, "nobody@example.com")
+
+		_, body := login(t, "nobody@example.com", "WrongPassword123!")
+		tests.AssertContains(t, body, "Too many failed login attempts")
+
+		if ts.Mailer.Count() != 0 {
+			t.Errorf("expected no email for an unknown address; got %d", ts.Mailer.Count())
+		}
+	})
+
+	t.Run("lockout and unlock with a password reset", func(t *testing.T) {
+		ts.Mailer.Clear()
+
+		// the count was reset by the successful login above
+		login(t, "locked@example.com", "Password123!")
+		failUntilLocked(t, "locked@example.com")
+
+		// the right password doesn't help while the account is locked
+		status, body := login(t, "LOCKED@example.com", "Password123!")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Too many failed login attempts")
+
+		email := ts.Mailer.Last()
+		if email == nil {
+			t.Fatal("expected lockout email to be sent")
+		}
+		locked, ok := email.Email.(mailer.AccountLockedEmail)
+		if !ok {
+			t.Fatalf("expected lockout email to be sent; got %T", email.Email)
+		}
+		if ts.Mailer.Count() != 1 {
+			t.Errorf("expected a single lockout email; got %d", ts.Mailer.Count())
+		}
+
+		resetLink := locked.PasswordResetLink
+
+		link, err := url.Parse(resetLink)
+		if err != nil || link.Path != "/reset-password" {
+			t.Fatalf("unexpected password reset link %q", resetLink)
+		}
+
+		status, _, body = ts.PostForm(t, "/reset-password", map[string]string{
+			"token":    link.Query().Get("token"),
+			"password": "NewPassword123!",
+		})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Password reset successfully!")
+
+		status, _ = login(t, "locked@example.com", "NewPassword123!")
+		tests.AssertStatus(t, status, http.StatusSeeOther)
+	})
+}
+
+func TestSocialLoginProviders(t *testing.T) {
+	t.Run("only configured providers are offered", func(t *testing.T) {
+		t.Setenv("GOOGLE_CLIENT_ID", "")
+		t.Setenv("GITHUB_CLIENT_ID", "github-client")
+		t.Setenv("GITHUB_CLIENT_SECRET", "github-secret")
+
+		ts := tests.NewTestServer(t)
+		defer ts.Close()
+
+		status, _, body := ts.Get(t, "/login")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, `action="/auth/github"`)
+		if strings.Contains(body, `action="/auth/google"`) {
+			t.Error("expected no Google button when Google is not configured")
+		}
+
+		status, _, body = ts.Get(t, "/signup")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Create with GitHub")
+
+		status, headers, _ := ts.Get(t, "/auth/github")
+		tests.AssertStatus(t, status, http.StatusTemporaryRedirect)
+		if location := headers.Get("Location"); !strings.HasPrefix(location, "https://github.com/login/oauth/authorize") {
+			t.Errorf("expected a redirect to GitHub; got %q", location)
+		}
+
+		status, _, _ = ts.Get(t, "/auth/google")
+		tests.AssertStatus(t, status, http.StatusBadRequest)
+	})
+
+	t.Run("generic openid connect", func(t *testing.T) {
+		provider := tests.NewFakeOIDCProvider(t)
+
+		t.Setenv("OIDC_NAME", "Test SSO")
+		t.Setenv("OIDC_DISCOVERY_URL", provider.DiscoveryURL())
+		t.Setenv("OIDC_CLIENT_ID", provider.ClientID)
+		t.Setenv("OIDC_CLIENT_SECRET", provider.ClientSecret)
+
+		ts := tests.NewTestServer(t)
+		defer ts.Close()
+
+		status, _, body := ts.Get(t, "/login")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Login with Test SSO")
+
+		login := func(t *testing.T) *http.Client {
+			t.Helper()
+
+			client := ts.NewClientWithCookies(t)
+
+			status, headers, _ := ts.GetWithClient(t, client, "/auth/oidc")
+			tests.AssertStatus(t, status, http.StatusTemporaryRedirect)
+
+			callback := provider.Authorize(t, headers.Get("Location"))
+
+			status, headers, _ = ts.GetWithClient(t, client, callback)
+			tests.AssertRedirect(t, status, headers, "/dashboard")
+
+			return client
+		}
+
+		provider.SetUser(tests.OIDCUser{
+			Subject:       "sso-user-1",
+			Email:         "sso@example.com",
+			EmailVerified: true,
+			Name:          "SSO User",
+		})
+
+		client := login(t)
+
+		status, _, body = ts.GetWithClient(t, client, "/dashboard")
+		tests.AssertStatus(t, status, http.StatusOK)
+
+		user, err := ts.Queries.GetUserByEmail(context.Background(), "sso@example.com")
+		if err != nil {
+			t.Fatalf("expected the user to be created: %v", err)
+		}
+		if user.Name != "SSO User" || !user.EmailVerified {
+			t.Errorf("unexpected user %+v", user)
+		}
+
+		// logging in again uses the same user
+		login(t)
+
+		if _, err := ts.Queries.GetAccountByUserIdAndProvider(context.Background(), queries.GetAccountByUserIdAndProviderParams{
+			UserID:     user.ID,
+			ProviderID: sql.NullString{String: "oidc", Valid: true},
+		}); err != nil {
+			t.Errorf("expected an oidc account for the user: %v", err)
+		}
+
+		// a callback without the state from the begin request is rejected
+		status, headers, _ := ts.GetWithClient(t, ts.NewClientWithCookies(t), "/auth/oidc/callback?code=x&state=y")
+		tests.AssertRedirect(t, status, headers, "/login")
+	})
+}
+
+func TestConnectedAccounts(t *testing.T) {
+	provider := tests.NewFakeOIDCProvider(t)
+
+	t.Setenv("OIDC_NAME", "Test SSO")
+	t.Setenv("OIDC_DISCOVERY_URL", provider.DiscoveryURL())
+	t.Setenv("OIDC_CLIENT_ID", provider.ClientID)
+	t.Setenv("OIDC_CLIENT_SECRET", provider.ClientSecret)
+
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	// socialLogin runs the provider round trip and returns the response of the callback
+	socialLogin := func(t *testing.T, client *http.Client) (int, http.Header) {
+		t.Helper()
+
+		status, headers, _ := ts.GetWithClient(t, client, "/auth/oidc")
+		tests.AssertStatus(t, status, http.StatusTemporaryRedirect)
+
+		status, headers, _ = ts.GetWithClient(t, client, provider.Authorize(t, headers.Get("Location")))
+		return status, headers
+	}
+
+	oidcAccount := func(t *testing.T, userID int32) error {
+		t.Helper()
+
+		_, err := ts.Queries.GetAccountByUserIdAndProvider(context.Background(), queries.GetAccountByUserIdAndProviderParams{
+			UserID:     userID,
+			ProviderID: sql.NullString{String: "oidc", Valid: true},
+		})
+		return err
+	}
+
+	t.Run("same email asks the existing user to confirm", func(t *testing.T) {
+		user := ts.CreateTestUser(t, "Link User", "link@example.com", "Password123!")
+
+		provider.SetUser(tests.OIDCUser{
+			Subject:       "link-user",
+			Email:         "link@example.com",
+			EmailVerified: true,
+			Name:          "Link User",
+		})
+
+		ts.Mailer.Clear()
+
+		status, headers := socialLogin(t, ts.NewClientWithCookies(t))
+		tests.AssertRedirect(t, status, headers, "/login")
+
+		if err := oidcAccount(t, user.ID); err == nil {
+			t.Fatal("expected the account not to be connected before confirming")
+		}
+
+		email := ts.Mailer.Last()
+		if email == nil || email.Recipient() != "link@example.com" {
+			t.Fatalf("expected an account link email; got %+v", email)
+		}
+		accountLink, ok := email.Email.(mailer.AccountLinkEmail)
+		if !ok {
+			t.Fatalf("expected an account link email; got %T", email.Email)
+		}
+
+		confirmLink := accountLink.Link
+		u, err := url.Parse(confirmLink)
+		if err != nil {
+			t.Fatalf("invalid confirm link %q: %v", confirmLink, err)
+		}
+
+		// following the link only asks to confirm, like a mail scanner would
+		status, _, body := ts.Get(t, u.RequestURI())
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, `action="/connections/confirm"`)
+		if err := oidcAccount(t, user.ID); err == nil {
+			t.Fatal("expected the account not to be connected by following the link")
+		}
+
+		confirm := map[string]string{"token": u.Query().Get("token")}
+		status, headers, _ = ts.PostForm(t, "/connections/confirm", confirm)
+		tests.AssertRedirect(t, status, headers, "/login")
+
+		if err := oidcAccount(t, user.ID); err != nil {
+			t.Fatalf("expected the account to be connected: %v", err)
+		}
+
+		// the link only works once
+		status, headers, _ = ts.PostForm(t, "/connections/confirm", confirm)
+		tests.AssertRedirect(t, status, headers, "/login")
+
+		status, headers = socialLogin(t, ts.NewClientWithCookies(t))
+		tests.AssertRedirect(t, status, headers, "/dashboard")
+	})
+
+	t.Run("connect and disconnect from the profile", func(t *testing.T) {
+		ts.CreateTestUser(t, "Connect User", "connect@example.com", "Password123!")
+		client, user := ts.CreateAndLoginUser(t, "Connect User", "connect-2@example.com", "Password123!")
+
+		status, _, body := ts.GetWithClient(t, client, "/profile/connections")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Test SSO")
+		tests.AssertContains(t, body, "Not connected")
+
+		// the provider account doesn't need to use the same email
+		provider.SetUser(tests.OIDCUser{
+			Subject:       "connect-user",
+			Email:         "someone-else@example.com",
+			EmailVerified: true,
+			Name:          "Connect User",
+		})
+
+		status, headers, _ := ts.PostFormWithClient(t, client, "/profile/connections/oidc/connect", nil)
+		tests.AssertStatus(t, status, http.StatusSeeOther)
+
+		status, headers, _ = ts.GetWithClient(t, client, provider.Authorize(t, headers.Get("Location")))
+		tests.AssertRedirect(t, status, headers, "/profile")
+
+		if err := oidcAccount(t, user.ID); err != nil {
+			t.Fatalf("expected the account to be connected: %v", err)
+		}
+
+		status, _, body = ts.GetWithClient(t, client, "/profile")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Your Test SSO account has been connected.")
+
+		// the provider account can't be connected to a second user
+		other, _ := ts.CreateAndLoginUser(t, "Other User", "connect-other@example.com", "Password123!")
+		status, headers, _ = ts.PostFormWithClient(t, other, "/profile/connections/oidc/connect", nil)
+		tests.AssertStatus(t, status, http.StatusSeeOther)
+		status, headers, _ = ts.GetWithClient(t, other, provider.Authorize(t, headers.Get("Location")))
+		tests.AssertRedirect(t, status, headers, "/profile")
+
+		_, _, body = ts.GetWithClient(t, other, "/profile")
+		tests.AssertContains(t, body, "This Test SSO account is already connected to another user.")
+
+		status, _, body = ts.PostFormWithClient(t, client, "/profile/connections/oidc/disconnect", nil)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "The account has been disconnected.")
+
+		if err := oidcAccount(t, user.ID); err == nil {
+			t.Fatal("expected the account to be disconnected")
+		}
+	})
+
+	t.Run("the last login method can't be disconnected", func(t *testing.T) {
+		provider.SetUser(tests.OIDCUser{
+			Subject:       "social-only",
+			Email:         "social-only@example.com",
+			EmailVerified: true,
+			Name:          "Social Only",
+		})
+
+		client := ts.NewClientWithCookies(t)
+		status, headers := socialLogin(t, client)
+		tests.AssertRedirect(t, status, headers, "/dashboard")
+
+		status, _, body := ts.PostFormWithClient(t, client, "/profile/connections/oidc/disconnect", nil)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "This is your only way to log in.")
+
+		user, err := ts.Queries.GetUserByEmail(context.Background(), "social-only@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := oidcAccount(t, user.ID); err != nil {
+			t.Fatalf("expected the account to stay connected: %v", err)
+		}
+	})
+}
+
+func TestEmailChange(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ts.CreateTestUser(t, "Taken User", "taken@example.com", "Password123!")
+
+	// linkFrom returns the path and query of the link of the email change email
+	// sent to recipient, the confirm link to the new address or the cancel link
+	// to the current one
+	linkFrom := func(t *testing.T, recipient string) string {
+		t.Helper()
+
+		for _, email := range ts.Mailer.Messages() {
+			if email.Recipient() != recipient {
+				continue
+			}
+
+			var link string
+			switch e := email.Email.(type) {
+			case mailer.EmailChangeEmail:
+				link = e.Link
+			case mailer.EmailChangeNoticeEmail:
+				link = e.CancelLink
+			default:
+				continue
+			}
+
+			u, err := url.Parse(link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return u.RequestURI()
+		}
+
+		t.Fatalf("expected an email change email to be sent to %s", recipient)
+		return ""
+	}
+
+	// submit posts the confirmation of the page of the link
+	submit := func(t *testing.T, client *http.Client, link string) (int, http.Header) {
+		t.Helper()
+
+		u, err := url.Parse(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, headers, _ := ts.PostFormWithClient(t, client, u.Path, map[string]string{"token": u.Query().Get("token")})
+		return status, headers
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		client, _ := ts.CreateAndLoginUser(t, "Email User", "email-validation@example.com", "Password123!")
+
+		status, _, body := ts.PostFormWithClient(t, client, "/profile/email", map[string]string{"email": "not-an-email"})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "This field must be a valid email address")
+
+		_, _, body = ts.PostFormWithClient(t, client, "/profile/email", map[string]string{"email": "email-validation@example.com"})
+		tests.AssertContains(t, body, "This is already your email address")
+
+		_, _, body = ts.PostFormWithClient(t, client, "/profile/email", map[string]string{"email": "taken@example.com"})
+		tests.AssertContains(t, body, "Email address is already in use")
+	})
+
+	t.Run("confirm the new address", func(t *testing.T) {
+		client, user := ts.CreateAndLoginUser(t, "Email User", "old@example.com", "Password123!")
+		ts.Mailer.Clear()
+
+		status, _, body := ts.PostFormWithClient(t, client, "/profile/email", map[string]string{"email": "new@example.com"})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "We have sent a confirmation link to new@example.com.")
+		tests.AssertContains(t, body, "Waiting for confirmation of")
+
+		// nothing changes before the new address is confirmed
+		current, err := ts.Queries.GetUserById(context.Background(), user.ID)
+		if err != nil || current.Email != "old@example.com" {
+			t.Fatalf("expected the email to stay old@example.com; got %q (%v)", current.Email, err)
+		}
+
+		linkFrom(t, "old@example.com")
+		confirm := linkFrom(t, "new@example.com")
+
+		// following the link only shows the confirmation, mail scanners follow links
+		status, _, body = ts.GetWithClient(t, client, confirm)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, `action="/email/confirm"`)
+
+		current, err = ts.Queries.GetUserById(context.Background(), user.ID)
+		if err != nil || current.Email != "old@example.com" {
+			t.Fatalf("expected the email to stay old@example.com; got %q (%v)", current.Email, err)
+		}
+
+		status, headers := submit(t, client, confirm)
+		tests.AssertRedirect(t, status, headers, "/profile")
+
+		current, err = ts.Queries.GetUserById(context.Background(), user.ID)
+		if err != nil || current.Email != "new@example.com" || !current.EmailVerified {
+			t.Fatalf("expected the verified email new@example.com; got %+v (%v)", current, err)
+		}
+
+		_, _, body = ts.GetWithClient(t, client, "/profile")
+		tests.AssertContains(t, body, "Your email address has been changed to new@example.com.")
+
+		// the link only works once
+		status, headers = submit(t, client, confirm)
+		tests.AssertRedirect(t, status, headers, "/profile")
+		_, _, body = ts.GetWithClient(t, client, "/profile")
+		tests.AssertContains(t, body, "Invalid or expired link.")
+
+		ts.LoginUser(t, "new@example.com", "Password123!")
+	})
+
+	t.Run("cancel from the old address", func(t *testing.T) {
+		client, user := ts.CreateAndLoginUser(t, "Email User", "keep@example.com", "Password123!")
+		ts.Mailer.Clear()
+
+		status, _, _ := ts.PostFormWithClient(t, client, "/profile/email", map[string]string{"email": "stolen@example.com"})
+		tests.AssertStatus(t, status, http.StatusOK)
+
+		cancel := linkFrom(t, "keep@example.com")
+		confirm := linkFrom(t, "stolen@example.com")
+
+		// following the link only shows the confirmation, the change stays pending
+		status, _, body := ts.Get(t, cancel)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, `action="/email/cancel"`)
+
+		_, _, body = ts.GetWithClient(t, client, "/profile/email")
+		tests.AssertContains(t, body, "Waiting for confirmation of")
+
+		anonymous := ts.NewClientWithCookies(t)
+		status, headers := submit(t, anonymous, cancel)
+		tests.AssertRedirect(t, status, headers, "/login")
+
+		// the confirmation link stops working
+		status, headers = submit(t, anonymous, confirm)
+		tests.AssertRedirect(t, status, headers, "/login")
+
+		current, err := ts.Queries.GetUserById(context.Background(), user.ID)
+		if err != nil || current.Email != "keep@example.com" {
+			t.Fatalf("expected the email to stay keep@example.com; got %q (%v)", current.Email, err)
+		}
+
+		_, _, body = ts.GetWithClient(t, client, "/profile/email")
+		tests.AssertNotContains(t, body, "Waiting for confirmation of")
+	})
+}
+
+func TestPersonalAccessTokens(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	client, user := ts.CreateAndLoginUser(t, "Token User", "tokens@example.com", "Password123!")
+
+	tokenRX := regexp.MustCompile(`pat_[a-z2-7]{32}`)
+
+	createToken := func(t *testing.T, name, scope string) string {
+		t.Helper()
+
+		status, _, body := ts.PostFormWithClient(t, client, "/profile/tokens", map[string]string{
+			"name":       name,
+			"scopes":     scope,
+			"expires_in": "30",
+		})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "It won't be shown again.")
+
+		token := tokenRX.FindString(body)
+		if token == "" {
+			t.Fatal("expected the new token in the response")
+		}
+		return token
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		status, _, body := ts.PostFormWithClient(t, client, "/profile/tokens", map[string]string{
+			"name":       "",
+			"expires_in": "7",
+		})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "This field cannot be blank")
+		tests.AssertContains(t, body, "Select at least one scope")
+		tests.AssertContains(t, body, "Select an expiration")
+	})
+
+	t.Run("authenticate with a read token", func(t *testing.T) {
+		token := createToken(t, "Read script", "read")
+
+		tokens, err := ts.Queries.ListPersonalAccessTokens(context.Background(), user.ID)
+		if err != nil || len(tokens) != 1 {
+			t.Fatalf("expected 1 token; got %d (%v)", len(tokens), err)
+		}
+		if strings.Contains(string(tokens[0].Hash), token) {
+			t.Error("expected only the hash of the token to be stored")
+		}
+
+		status, headers, body := ts.DoWithToken(t, http.MethodGet, "/api/v1/profile", token, nil)
+		tests.AssertStatus(t, status, http.StatusOK)
+		if headers.Get("Set-Cookie") != "" {
+			t.Error("expected no cookies for token requests")
+		}
+
+		var me struct {
+			Data struct {
+				ID    int32  `json:"id"`
+				Email string `json:"email"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(body), &me); err != nil {
+			t.Fatalf("invalid JSON %q: %v", body, err)
+		}
+		if me.Data.ID != user.ID || me.Data.Email != "tokens@example.com" {
+			t.Errorf("unexpected user %+v", me)
+		}
+
+		stored, err := ts.Queries.GetPersonalAccessTokenByHash(context.Background(), tokens[0].Hash)
+		if err != nil || !stored.LastUsedAt.Valid {
+			t.Errorf("expected the last used time to be set; got %+v (%v)", stored, err)
+		}
+
+		// changing data needs the write scope
+		status, headers, _ = ts.DoWithToken(t, http.MethodPatch, "/api/v1/profile", token, strings.NewReader(`{"name":"New Name","image":"x.png"}`))
+		tests.AssertStatus(t, status, http.StatusForbidden)
+		tests.AssertContains(t, headers.Get("WWW-Authenticate"), "insufficient_scope")
+
+		_, _, body = ts.GetWithClient(t, client, "/profile/tokens")
+		tests.AssertContains(t, body, "Read script")
+		tests.AssertNotContains(t, body, token)
+	})
+
+	t.Run("missing, invalid and expired tokens", func(t *testing.T) {
+		status, _, _ := ts.DoWithToken(t, http.MethodGet, "/api/v1/profile", "", nil)
+		tests.AssertStatus(t, status, http.StatusUnauthorized)
+
+		status, headers, _ := ts.DoWithToken(t, http.MethodGet, "/api/v1/profile", "pat_invalid", nil)
+		tests.AssertStatus(t, status, http.StatusUnauthorized)
+		tests.AssertContains(t, headers.Get("WWW-Authenticate"), "invalid_token")
+
+		hash := sha256.Sum256([]byte("pat_expired"))
+		_, err := ts.Queries.CreatePersonalAccessToken(context.Background(), queries.CreatePersonalAccessTokenParams{
+			UserID:    user.ID,
+			Name:      "Expired",
+			Hash:      hash[:],
+			Scopes:    "read",
+			ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, _, _ = ts.DoWithToken(t, http.MethodGet, "/api/v1/profile", "pat_expired", nil)
+		tests.AssertStatus(t, status, http.StatusUnauthorized)
+	})
+
+	t.Run("revoked tokens stop working", func(t *testing.T) {
+		token := createToken(t, "Revoked script", "read")
+
+		tokens, err := ts.Queries.ListPersonalAccessTokens(context.Background(), user.ID)
+		if err != nil || len(tokens) == 0 {
+			t.Fatalf("expected tokens; got %v", err)
+		}
+
+		// the newest token is listed first
+		status, _, body := ts.PostFormWithClient(t, client, fmt.Sprintf("/profile/tokens/%d/revoke", tokens[0].ID), nil)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "The token has been revoked.")
+
+		status, _, _ = ts.DoWithToken(t, http.MethodGet, "/api/v1/profile", token, nil)
+		tests.AssertStatus(t, status, http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"go-web-starter/cmd/web/views/auth"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/service"
+	"go-web-starter/internal/types"
+	"net/http"
+
+	"github.com/angelofallars/htmx-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
+)
+
+func (ah *AuthHandler) ConnectionsSectionHandler(w http.ResponseWriter, r *http.Request) {
+	ah.renderConnectionsSection(w, r, forms.Form{})
+}
+
+func (ah *AuthHandler) renderConnectionsSection(w http.ResponseWriter, r *http.Request, form forms.Form) {
+	user := ah.handler.GetUser(r)
+
+	accounts, err := ah.authService.ListConnectedAccounts(r.Context(), user.ID)
+	if err != nil {
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	// every configured provider, connected or not
+	connections := make([]types.ConnectedAccount, 0, len(ah.handler.SocialProviders))
+	for _, provider := range ah.handler.SocialProviders {
+		connection := types.ConnectedAccount{Provider: provider}
+		for _, account := range accounts {
+			if account.ProviderID.String == provider.Name {
+				connection.Connected = true
+				connection.ConnectedAt = account.CreatedAt
+			}
+		}
+		connections = append(connections, connection)
+	}
+
+	// accounts of providers that are no longer configured can still be disconnected
+	for _, account := range accounts {
+		if _, ok := ah.socialProvider(account.ProviderID.String); !ok {
+			connections = append(connections, types.ConnectedAccount{
+				Provider:    types.SocialProvider{Name: account.ProviderID.String, DisplayName: account.ProviderID.String},
+				Connected:   true,
+				ConnectedAt: account.CreatedAt,
+			})
+		}
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	htmx.NewResponse().RenderTempl(r.Context(), w, auth.ConnectionsSection(data, connections, form))
+}
+
+// ConnectSocialAccountHandler sends a logged in user to the provider. They come back
+// to SocialAuthCallbackHandler, which connects the account.
+func (ah *AuthHandler) ConnectSocialAccountHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := ah.socialProvider(chi.URLParam(r, "provider")); !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	authURL, err := gothic.GetAuthURL(w, r)
+	if err != nil {
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusSeeOther)
+}
+
+func (ah *AuthHandler) connectSocialAccount(w http.ResponseWriter, r *http.Request, provider types.SocialProvider, gothUser goth.User) {
+	user := ah.handler.GetUser(r)
+
+	err := ah.authService.ConnectSocialAccount(r.Context(), user.ID, gothUser, provider.Name)
+	switch {
+	case err == nil:
+		ah.handler.Logger.PrintInfo("social account connected", map[string]string{
+			"user_id":  fmt.Sprintf("%d", user.ID),
+			"provider": provider.Name,
+		})
+		ah.handler.SessionManager.Put(r.Context(), "flash", fmt.Sprintf("Your %s account has been connected.", provider.DisplayName))
+	case errors.Is(err, service.ErrSocialAccountInUse):
+		ah.handler.SessionManager.Put(r.Context(), "flash", fmt.Sprintf("This %s account is already connected to another user.", provider.DisplayName))
+	case errors.Is(err, service.ErrProviderAlreadyConnected):
+		ah.handler.SessionManager.Put(r.Context(), "flash", fmt.Sprintf("A %s account is already connected. Disconnect it first to connect another one.", provider.DisplayName))
+	default:
+		ah.handler.Logger.PrintError(err, map[string]string{
+			"user_id":  fmt.Sprintf("%d", user.ID),
+			"provider": provider.Name,
+		})
+		ah.handler.SessionManager.Put(r.Context(), "flash", fmt.Sprintf("Could not connect your %s account. Please try again.", provider.DisplayName))
+	}
+
+	http.Redirect(w, r, "/profile", http.StatusSeeOther)
+}
+
+func (ah *AuthHandler) DisconnectSocialAccountHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.Form
+
+	provider := chi.URLParam(r, "provider")
+	user := ah.handler.GetUser(r)
+
+	err := ah.authService.DisconnectSocialAccount(r.Context(), user.ID, provider)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLastLoginMethod):
+			form.SetMessage("This is your only way to log in. Set a password or add a passkey before disconnecting it.", forms.MessageTypeError)
+		case errors.Is(err, service.ErrSocialAccountNotFound):
+			form.SetMessage("This account is not connected.", forms.MessageTypeError)
+		default:
+			ah.handler.Logger.PrintError(err, map[string]string{
+				"user_id":  fmt.Sprintf("%d", user.ID),
+				"provider": provider,
+			})
+			form.SetMessage("Failed to disconnect the account. Please try again.", forms.MessageTypeError)
+		}
+
+		ah.renderConnectionsSection(w, r, form)
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("social account disconnected", map[string]string{
+		"user_id":  fmt.Sprintf("%d", user.ID),
+		"provider": provider,
+	})
+
+	form.SetMessage("The account has been disconnected.", forms.MessageTypeSuccess)
+	ah.renderConnectionsSection(w, r, form)
+}
+
+// ConfirmAccountLinkView asks to confirm connecting the social account of the
+// link emailed by ProcessSocialAuth. Only the POST of the confirmation connects it,
+// so that mail scanners following the link don't.
+func (ah *AuthHandler) ConfirmAccountLinkView(w http.ResponseWriter, r *http.Request) {
+	form := forms.AccountLinkConfirmForm{
+		Token: r.URL.Query().Get("token"),
+	}
+
+	// validate the token format - should be 26 characters (base32 encoded 16 bytes)
+	if len(form.Token) != 26 {
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Invalid or expired link.")
+		http.Redirect(w, r, ah.emailedLinkRedirectURL(r), http.StatusSeeOther)
+		return
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	data.PageTitle = "Connect account"
+
+	auth.ConfirmAccountLinkView(data, form).Render(r.Context(), w)
+}
+
+// ConfirmAccountLinkHandler connects the social account of the confirmed link. Like
+// the activation link, it works logged in and logged out.
+func (ah *AuthHandler) ConfirmAccountLinkHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.AccountLinkConfirmForm
+
+	redirectURL := ah.emailedLinkRedirectURL(r)
+
+	err := ah.handler.DecodePostForm(r, &form)
+	if err != nil || len(form.Token) != 26 {
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Invalid or expired link.")
+		ah.handler.Redirect(w, r, redirectURL)
+		return
+	}
+
+	user, provider, err := ah.authService.ConfirmAccountLink(r.Context(), form.Token)
+	if err != nil {
+		message := "Invalid or expired link. Please log in with the provider again to get a new one."
+		switch {
+		case errors.Is(err, service.ErrSocialAccountInUse):
+			message = "This account is already connected to another user."
+		case errors.Is(err, service.ErrProviderAlreadyConnected):
+			message = "An account of this provider is already connected to your account."
+		default:
+			ah.handler.Logger.PrintError(err, map[string]string{
+				"request_url": r.URL.Path,
+			})
+		}
+
+		ah.handler.SessionManager.Put(r.Context(), "flash", message)
+		ah.handler.Redirect(w, r, redirectURL)
+		return
+	}
+
+	displayName := provider
+	if p, ok := ah.socialProvider(provider); ok {
+		displayName = p.DisplayName
+	}
+
+	ah.handler.Logger.PrintInfo("social account connected", map[string]string{
+		"user_id":  fmt.Sprintf("%d", user.ID),
+		"provider": provider,
+	})
+
+	ah.handler.SessionManager.Put(r.Context(), "flash", fmt.Sprintf("Your %s account has been connected. You can now log in with it.", displayName))
+	ah.handler.Redirect(w, r, redirectURL)
+}
+
+// emailedLinkRedirectURL is where the confirmation of a link sent by email ends
+func (ah *AuthHandler) emailedLinkRedirectURL(r *http.Request) string {
+	if ah.handler.IsAuthenticated(r) {
+		return "/profile"
+	}
+
+	return "/login"
+}
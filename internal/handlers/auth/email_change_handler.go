@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-web-starter/cmd/web/views/auth"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/service"
+	"net/http"
+
+	"github.com/angelofallars/htmx-go"
+)
+
+func (ah *AuthHandler) EmailSectionHandler(w http.ResponseWriter, r *http.Request) {
+	ah.renderEmailSection(w, r, forms.ChangeEmailForm{})
+}
+
+func (ah *AuthHandler) renderEmailSection(w http.ResponseWriter, r *http.Request, form forms.ChangeEmailForm) {
+	user := ah.handler.GetUser(r)
+
+	pending, err := ah.authService.GetPendingEmailChange(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	var pendingEmail string
+	if pending != nil {
+		pendingEmail = pending.NewEmail
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	htmx.NewResponse().RenderTempl(r.Context(), w, auth.EmailSection(data, form, pendingEmail))
+}
+
+func (ah *AuthHandler) ChangeEmailHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.ChangeEmailForm
+
+	err := ah.handler.DecodePostForm(r, &form)
+	if err != nil {
+		form.SetMessage("Invalid form data", forms.MessageTypeError)
+		ah.renderEmailSection(w, r, form)
+		return
+	}
+
+	// Validation
+	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
+	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
+
+	if !form.Valid() {
+		ah.renderEmailSection(w, r, form)
+		return
+	}
+
+	user := ah.handler.GetUser(r)
+
+	err = ah.authService.RequestEmailChange(r.Context(), user, form.Email, ah.handler.Config.AppURL)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmailUnchanged):
+			form.AddFieldError("email", "This is already your email address")
+		case errors.Is(err, service.ErrEmailTaken):
+			form.AddFieldError("email", "Email address is already in use")
+		default:
+			ah.handler.Logger.PrintError(err, map[string]string{
+				"user_id": fmt.Sprintf("%d", user.ID),
+			})
+			form.SetMessage("Failed to change the email address. Please try again.", forms.MessageTypeError)
+		}
+
+		ah.renderEmailSection(w, r, form)
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("email change requested", map[string]string{
+		"user_id": fmt.Sprintf("%d", user.ID),
+	})
+
+	// start over with an empty form
+	sent := forms.ChangeEmailForm{}
+	sent.SetMessage("We have sent a confirmation link to "+form.Email+".", forms.MessageTypeSuccess)
+	ah.renderEmailSection(w, r, sent)
+}
+
+func (ah *AuthHandler) CancelPendingEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.ChangeEmailForm
+
+	user := ah.handler.GetUser(r)
+
+	err := ah.authService.CancelPendingEmailChange(r.Context(), user.ID)
+	if err != nil {
+		ah.handler.Logger.PrintError(err, map[string]string{
+			"user_id": fmt.Sprintf("%d", user.ID),
+		})
+		form.SetMessage("Failed to cancel the email change. Please try again.", forms.MessageTypeError)
+	} else {
+		form.SetMessage("The email change has been cancelled.", forms.MessageTypeSuccess)
+	}
+
+	ah.renderEmailSection(w, r, form)
+}
+
+// ConfirmEmailChangeView asks to confirm the link sent to the new address. Only
+// the POST of the confirmation changes the address, so that mail scanners following
+// the link don't.
+func (ah *AuthHandler) ConfirmEmailChangeView(w http.ResponseWriter, r *http.Request) {
+	form, ok := ah.emailChangeLinkForm(w, r)
+	if !ok {
+		return
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	data.PageTitle = "Confirm email address"
+
+	auth.ConfirmEmailChangeView(data, form).Render(r.Context(), w)
+}
+
+// ConfirmEmailChangeHandler changes the address of the confirmed link. Like the
+// activation link, it works logged in and logged out.
+func (ah *AuthHandler) ConfirmEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.EmailChangeLinkForm
+
+	redirectURL := ah.emailedLinkRedirectURL(r)
+
+	err := ah.handler.DecodePostForm(r, &form)
+	if err != nil || len(form.Token) != 26 {
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Invalid or expired link.")
+		ah.handler.Redirect(w, r, redirectURL)
+		return
+	}
+
+	user, err := ah.authService.ConfirmEmailChange(r.Context(), form.Token)
+	if err != nil {
+		message := "Invalid or expired link. Please change your email address again."
+		if errors.Is(err, service.ErrEmailTaken) {
+			message = "This email address is already used by another account."
+		} else {
+			ah.handler.Logger.PrintError(err, map[string]string{
+				"request_url": r.URL.Path,
+			})
+		}
+
+		ah.handler.SessionManager.Put(r.Context(), "flash", message)
+		ah.handler.Redirect(w, r, redirectURL)
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("email address changed", map[string]string{
+		"user_id": fmt.Sprintf("%d", user.ID),
+	})
+
+	ah.handler.SessionManager.Put(r.Context(), "flash", "Your email address has been changed to "+user.Email+".")
+	ah.handler.Redirect(w, r, redirectURL)
+}
+
+// CancelEmailChangeView asks to confirm the cancel link sent to the current
+// address, like ConfirmEmailChangeView.
+func (ah *AuthHandler) CancelEmailChangeView(w http.ResponseWriter, r *http.Request) {
+	form, ok := ah.emailChangeLinkForm(w, r)
+	if !ok {
+		return
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	data.PageTitle = "Cancel email change"
+
+	auth.CancelEmailChangeView(data, form).Render(r.Context(), w)
+}
+
+// CancelEmailChangeHandler cancels the email change of the confirmed link.
+func (ah *AuthHandler) CancelEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.EmailChangeLinkForm
+
+	redirectURL := ah.emailedLinkRedirectURL(r)
+
+	err := ah.handler.DecodePostForm(r, &form)
+	if err != nil || len(form.Token) != 26 {
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Invalid or expired link.")
+		ah.handler.Redirect(w, r, redirectURL)
+		return
+	}
+
+	user, err := ah.authService.CancelEmailChange(r.Context(), form.Token)
+	if err != nil {
+		ah.handler.Logger.PrintError(err, map[string]string{
+			"request_url": r.URL.Path,
+		})
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Invalid or expired link. The email change may have been confirmed or cancelled already.")
+		ah.handler.Redirect(w, r, redirectURL)
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("email change cancelled", map[string]string{
+		"user_id": fmt.Sprintf("%d", user.ID),
+	})
+
+	ah.handler.SessionManager.Put(r.Context(), "flash", "The email change has been cancelled. If you didn't ask for it, please change your password.")
+	ah.handler.Redirect(w, r, redirectURL)
+}
+
+// emailChangeLinkForm reads the token of an email change link. Links without a
+// valid token are redirected with a flash message.
+func (ah *AuthHandler) emailChangeLinkForm(w http.ResponseWriter, r *http.Request) (forms.EmailChangeLinkForm, bool) {
+	form := forms.EmailChangeLinkForm{
+		Token: r.URL.Query().Get("token"),
+	}
+
+	// validate the token format - should be 26 characters (base32 encoded 16 bytes)
+	if len(form.Token) != 26 {
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Invalid or expired link.")
+		http.Redirect(w, r, ah.emailedLinkRedirectURL(r), http.StatusSeeOther)
+		return form, false
+	}
+
+	return form, true
+}
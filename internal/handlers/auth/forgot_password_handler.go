@@ -6,6 +6,8 @@ import (
 	"go-web-starter/cmd/web/views/auth"
 	"go-web-starter/internal/forms"
 	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/service"
 	"net/http"
 
 	"github.com/angelofallars/htmx-go"
@@ -59,10 +61,10 @@ func (ah *AuthHandler) ForgotPasswordPostHanlder(w http.ResponseWriter, r *http.
 	}
 
 	// Send the reset email with the token for the user
-	data := map[string]any{
-		"passwordResetLink": passwordResetLink,
-	}
-	err = ah.handler.Mailer.Send(form.Email, "reset_password.tmpl", data)
+	err = ah.handler.Mailer.SendMessage(r.Context(), mailer.NewMessage(mailer.PasswordResetEmail{
+		Link:      passwordResetLink,
+		ExpiresIn: service.PasswordResetTokenTTL,
+	}).To(form.Email))
 	if err != nil {
 		ah.handler.Logger.PrintError(err, nil)
 	}
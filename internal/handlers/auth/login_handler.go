@@ -1,10 +1,11 @@
 package auth
 
 import (
+	"errors"
 	"go-web-starter/cmd/web/components"
 	"go-web-starter/cmd/web/views/auth"
 	"go-web-starter/internal/forms"
-	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/service"
 	"net/http"
 
 	"github.com/angelofallars/htmx-go"
@@ -19,10 +20,7 @@ func (ah *AuthHandler) LoginPostHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Validation
-	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
-	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
-	form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be blank")
+	form.Validate()
 
 	if !form.Valid() {
 		// handle with htmx
@@ -31,35 +29,43 @@ func (ah *AuthHandler) LoginPostHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Authenticate: check the user and account exists
-	user, err := ah.authService.Login(r.Context(), form.Email, form.Password)
+	user, err := ah.authService.Login(r.Context(), form.Email, form.Password, ah.handler.Config.AppURL)
 	if err != nil {
-		htmx.NewResponse().RenderTempl(r.Context(), w, components.FlashMessage("Invalid email or password", components.FlashError))
-		return
-	}
+		if errors.Is(err, service.ErrAccountLocked) {
+			ah.handler.Logger.PrintInfo("login attempt on a locked account", map[string]string{
+				"ip": r.RemoteAddr,
+			})
+			htmx.NewResponse().RenderTempl(r.Context(), w,
+				components.FlashMessage("Too many failed login attempts. Please try again later or reset your password.", components.FlashError),
+			)
+			return
+		}
+
+		if errors.Is(err, service.ErrUserDisabled) {
+			htmx.NewResponse().RenderTempl(r.Context(), w,
+				components.FlashMessage(disabledMessage, components.FlashError),
+			)
+			return
+		}
 
-	// Session manager - renew token AFTER successful authentication to prevent session fixation
-	err = ah.handler.SessionManager.RenewToken(r.Context())
-	if err != nil {
-		htmx.NewResponse().RenderTempl(r.Context(), w, components.FlashMessage("Session error occurred", components.FlashError))
+		htmx.NewResponse().RenderTempl(r.Context(), w, components.FlashMessage("Invalid email or password", components.FlashError))
 		return
 	}
 
-	ah.handler.SessionManager.Put(r.Context(), "authenticatedUserID", user.ID)
-
 	// Get the next=? query string if exists. 1 - redirect to it. 2 - or redirect to home after login
-	redirectURL := "/dashboard"
+	redirectURL := defaultRedirectURL
 	nextPath := r.URL.Query().Get("next")
 
 	if nextPath != "" && IsValidRedirectPath(nextPath) {
 		redirectURL = nextPath
 	}
 
-	if htmx.IsHTMX(r) {
-		htmx.NewResponse().Redirect(redirectURL).Write(w)
+	// Session manager - the token is renewed AFTER successful authentication to prevent session fixation
+	err = ah.completeLogin(w, r, user, loginMethodPassword, redirectURL)
+	if err != nil {
+		htmx.NewResponse().RenderTempl(r.Context(), w, components.FlashMessage("Session error occurred", components.FlashError))
 		return
 	}
-
-	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
 }
 
 func (ah *AuthHandler) LoginViewHandler(w http.ResponseWriter, r *http.Request) {
@@ -67,6 +73,9 @@ func (ah *AuthHandler) LoginViewHandler(w http.ResponseWriter, r *http.Request)
 	data.PageTitle = "Login"
 
 	form := forms.UserLoginForm{}
+	magicLinkForm := forms.MagicLinkForm{
+		Next: r.URL.Query().Get("next"),
+	}
 
-	auth.LoginView(data, form).Render(r.Context(), w)
+	auth.LoginView(data, form, magicLinkForm).Render(r.Context(), w)
 }
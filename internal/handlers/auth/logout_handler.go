@@ -1,11 +1,27 @@
 package auth
 
 import (
+	"go-web-starter/internal/config"
 	"net/http"
 )
 
 func (ah *AuthHandler) LogoutPostHandler(w http.ResponseWriter, r *http.Request) {
-	err := ah.handler.SessionManager.RenewToken(r.Context())
+	err := ah.authService.EndSession(r.Context(), ah.handler.SessionManager.Token(r.Context()))
+	if err != nil {
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	// admins who log out while impersonating a user end the impersonation too
+	if admin := ah.handler.GetImpersonator(r); admin != nil {
+		id := ah.handler.SessionManager.GetInt32(r.Context(), string(config.ImpersonationID))
+		if err := ah.authService.StopImpersonation(r.Context(), admin, ah.handler.GetUser(r).ID, id); err != nil {
+			ah.handler.ServerError(w, err)
+			return
+		}
+	}
+
+	err = ah.handler.SessionManager.RenewToken(r.Context())
 	if err != nil {
 		ah.handler.ServerError(w, err)
 		return
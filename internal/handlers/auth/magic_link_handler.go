@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"go-web-starter/cmd/web/components"
+	"go-web-starter/cmd/web/views/auth"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/service"
+	"net/http"
+
+	"github.com/angelofallars/htmx-go"
+)
+
+func (ah *AuthHandler) MagicLinkPostHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.MagicLinkForm
+
+	err := ah.handler.DecodePostForm(r, &form)
+	if err != nil {
+		htmx.NewResponse().RenderTempl(r.Context(), w, components.FlashMessage("Invalid form data", components.FlashError))
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
+	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
+
+	if !form.Valid() {
+		htmx.NewResponse().RenderTempl(r.Context(), w, components.FlashMessage(form.FieldErrors["email"], components.FlashError))
+		return
+	}
+
+	next := ""
+	if form.Next != "" && IsValidRedirectPath(form.Next) {
+		next = form.Next
+	}
+
+	err = ah.authService.SendMagicLink(r.Context(), form.Email, ah.handler.Config.AppURL, next)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		ah.handler.Logger.PrintError(err, nil)
+		htmx.NewResponse().RenderTempl(r.Context(), w,
+			components.FlashMessage("Can not send you a sign-in link. Please try again later!", components.FlashError),
+		)
+		return
+	}
+
+	// Don't reveal if email exists or not for security
+	htmx.NewResponse().RenderTempl(r.Context(), w,
+		components.FlashMessage("If an account with this email exists, a sign-in link has been sent to it.", components.FlashInfo),
+	)
+}
+
+// MagicLinkView asks the user to confirm the sign-in. The token is only consumed by the
+// POST, so link previews and email scanners that follow the link don't use it up.
+func (ah *AuthHandler) MagicLinkView(w http.ResponseWriter, r *http.Request) {
+	form := forms.MagicLinkLoginForm{
+		Token: r.URL.Query().Get("token"),
+		Next:  r.URL.Query().Get("next"),
+	}
+
+	// validate the token format - should be 26 characters (base32 encoded 16 bytes)
+	if len(form.Token) != 26 {
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Invalid or expired sign-in link.")
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	data.PageTitle = "Sign in"
+
+	auth.MagicLinkView(data, form).Render(r.Context(), w)
+}
+
+func (ah *AuthHandler) MagicLinkLoginHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.MagicLinkLoginForm
+
+	err := ah.handler.DecodePostForm(r, &form)
+	if err != nil || len(form.Token) != 26 {
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Invalid or expired sign-in link.")
+		ah.handler.Redirect(w, r, "/login")
+		return
+	}
+
+	user, err := ah.authService.ConsumeMagicLink(r.Context(), form.Token)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			ah.handler.Logger.PrintError(err, nil)
+		}
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Invalid or expired sign-in link. Please request a new one.")
+		ah.handler.Redirect(w, r, "/login")
+		return
+	}
+
+	redirectURL := defaultRedirectURL
+	if form.Next != "" && IsValidRedirectPath(form.Next) {
+		redirectURL = form.Next
+	}
+
+	if err := ah.completeLogin(w, r, user, loginMethodMagicLink, redirectURL); err != nil {
+		if errors.Is(err, service.ErrUserDisabled) {
+			ah.handler.SessionManager.Put(r.Context(), "flash", disabledMessage)
+			ah.handler.Redirect(w, r, "/login")
+			return
+		}
+		ah.handler.ServerError(w, err)
+		return
+	}
+}
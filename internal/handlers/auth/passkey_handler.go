@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"go-web-starter/cmd/web/views/auth"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/angelofallars/htmx-go"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// session keys holding the state of a running passkey ceremony
+	passkeyRegistrationKey = "passkeyRegistration"
+	passkeyLoginKey        = "passkeyLogin"
+
+	// limit for the JSON body posted by the browser after a ceremony
+	maxPasskeyResponseBytes = 64 * 1024
+)
+
+type passkeyError struct {
+	Error string `json:"error"`
+}
+
+// Login
+
+func (ah *AuthHandler) PasskeyLoginBeginHandler(w http.ResponseWriter, r *http.Request) {
+	assertion, state, err := ah.authService.BeginPasskeyLogin()
+	if err != nil {
+		ah.handler.Logger.PrintError(err, nil)
+		ah.handler.WriteJSON(w, http.StatusServiceUnavailable, passkeyError{"Passkey login is not available."})
+		return
+	}
+
+	ah.handler.SessionManager.Put(r.Context(), passkeyLoginKey, state)
+	ah.handler.WriteJSON(w, http.StatusOK, assertion)
+}
+
+func (ah *AuthHandler) PasskeyLoginFinishHandler(w http.ResponseWriter, r *http.Request) {
+	state := ah.handler.SessionManager.PopBytes(r.Context(), passkeyLoginKey)
+	if state == nil {
+		ah.handler.WriteJSON(w, http.StatusBadRequest, passkeyError{"Your passkey login has expired. Please try again."})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPasskeyResponseBytes)
+
+	user, err := ah.authService.FinishPasskeyLogin(r.Context(), state, r.Body)
+	if err != nil {
+		ah.handler.Logger.PrintInfo("passkey login failed", map[string]string{
+			"error": err.Error(),
+			"ip":    r.RemoteAddr,
+		})
+		ah.recordFailedLogin(r, 0, "invalid_passkey")
+		ah.handler.WriteJSON(w, http.StatusUnauthorized, passkeyError{"This passkey could not be verified."})
+		return
+	}
+
+	// passkey logins require user verification, so a passkey is possession and a
+	// PIN or biometric at once and no second factor is needed
+	if err := ah.createAuthenticatedSession(r, user, loginMethodPasskey); err != nil {
+		if errors.Is(err, service.ErrUserDisabled) {
+			ah.handler.WriteJSON(w, http.StatusForbidden, passkeyError{disabledMessage})
+			return
+		}
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("Successful passkey login", map[string]string{
+		"user_id": fmt.Sprintf("%d", user.ID),
+		"ip":      r.RemoteAddr,
+	})
+
+	redirectURL := defaultRedirectURL
+	if next := r.URL.Query().Get("next"); next != "" && IsValidRedirectPath(next) {
+		redirectURL = next
+	}
+
+	ah.handler.WriteJSON(w, http.StatusOK, map[string]string{"redirect": redirectURL})
+}
+
+// Profile settings
+
+func (ah *AuthHandler) PasskeysSectionHandler(w http.ResponseWriter, r *http.Request) {
+	ah.renderPasskeysSection(w, r, forms.PasskeyForm{})
+}
+
+func (ah *AuthHandler) renderPasskeysSection(w http.ResponseWriter, r *http.Request, form forms.PasskeyForm) {
+	user := ah.handler.GetUser(r)
+
+	passkeys, err := ah.authService.ListPasskeys(r.Context(), user.ID)
+	if err != nil {
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	htmx.NewResponse().RenderTempl(r.Context(), w, auth.PasskeysSection(data, passkeys, form))
+}
+
+func (ah *AuthHandler) PasskeyRegisterBeginHandler(w http.ResponseWriter, r *http.Request) {
+	user := ah.handler.GetUser(r)
+
+	creation, state, err := ah.authService.BeginPasskeyRegistration(r.Context(), user)
+	if err != nil {
+		ah.handler.Logger.PrintError(err, map[string]string{
+			"user_id": fmt.Sprintf("%d", user.ID),
+		})
+		ah.handler.WriteJSON(w, http.StatusServiceUnavailable, passkeyError{"Passkeys are not available right now."})
+		return
+	}
+
+	ah.handler.SessionManager.Put(r.Context(), passkeyRegistrationKey, state)
+	ah.handler.WriteJSON(w, http.StatusOK, creation)
+}
+
+func (ah *AuthHandler) PasskeyRegisterFinishHandler(w http.ResponseWriter, r *http.Request) {
+	user := ah.handler.GetUser(r)
+
+	state := ah.handler.SessionManager.PopBytes(r.Context(), passkeyRegistrationKey)
+	if state == nil {
+		ah.handler.WriteJSON(w, http.StatusBadRequest, passkeyError{"The passkey registration has expired. Please try again."})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPasskeyResponseBytes)
+
+	credential, err := ah.authService.FinishPasskeyRegistration(r.Context(), user, state, r.URL.Query().Get("name"), r.Body)
+	if err != nil {
+		ah.handler.Logger.PrintError(err, map[string]string{
+			"user_id": fmt.Sprintf("%d", user.ID),
+		})
+		ah.handler.WriteJSON(w, http.StatusBadRequest, passkeyError{"The passkey could not be registered."})
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("passkey registered", map[string]string{
+		"user_id":    fmt.Sprintf("%d", user.ID),
+		"passkey_id": fmt.Sprintf("%d", credential.ID),
+	})
+
+	ah.handler.WriteJSON(w, http.StatusCreated, map[string]any{"id": credential.ID, "name": credential.Name})
+}
+
+func (ah *AuthHandler) RenamePasskeyHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.PasskeyForm
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = ah.handler.DecodePostForm(r, &form)
+	if err != nil {
+		form.SetMessage("Invalid form data", forms.MessageTypeError)
+		ah.renderPasskeysSection(w, r, form)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Name, 64), "name", "This field cannot be more than 64 characters long")
+
+	if !form.Valid() {
+		form.SetMessage(form.FieldErrors["name"], forms.MessageTypeError)
+		ah.renderPasskeysSection(w, r, form)
+		return
+	}
+
+	user := ah.handler.GetUser(r)
+
+	err = ah.authService.RenamePasskey(r.Context(), user.ID, int32(id), form.Name)
+	if err != nil {
+		if !errors.Is(err, service.ErrPasskeyNotFound) {
+			ah.handler.Logger.PrintError(err, nil)
+		}
+		form.SetMessage("Failed to rename the passkey. Please try again.", forms.MessageTypeError)
+		ah.renderPasskeysSection(w, r, form)
+		return
+	}
+
+	form = forms.PasskeyForm{}
+	form.SetMessage("Passkey renamed.", forms.MessageTypeSuccess)
+	ah.renderPasskeysSection(w, r, form)
+}
+
+func (ah *AuthHandler) DeletePasskeyHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.PasskeyForm
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	user := ah.handler.GetUser(r)
+
+	err = ah.authService.DeletePasskey(r.Context(), user.ID, int32(id))
+	if err != nil {
+		if !errors.Is(err, service.ErrPasskeyNotFound) {
+			ah.handler.Logger.PrintError(err, nil)
+		}
+		form.SetMessage("Failed to remove the passkey. Please try again.", forms.MessageTypeError)
+		ah.renderPasskeysSection(w, r, form)
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("passkey removed", map[string]string{
+		"user_id":    fmt.Sprintf("%d", user.ID),
+		"passkey_id": fmt.Sprintf("%d", id),
+	})
+
+	form.SetMessage("Passkey removed.", forms.MessageTypeSuccess)
+	ah.renderPasskeysSection(w, r, form)
+}
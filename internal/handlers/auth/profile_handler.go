@@ -1,9 +1,10 @@
 package auth
 
 import (
+	"errors"
 	"go-web-starter/cmd/web/views/auth"
 	"go-web-starter/internal/forms"
-	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/service"
 	"go-web-starter/internal/types"
 	"net/http"
 
@@ -37,9 +38,7 @@ func (ah *AuthHandler) UpdateUserNameAndImageHandler(w http.ResponseWriter, r *h
 		return
 	}
 
-	// Validation
-	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
-	form.CheckField(validator.NotBlank(form.Image), "image", "This field cannot be blank")
+	form.Validate()
 
 	data = ah.handler.NewTemplateData(r)
 	if !form.Valid() {
@@ -74,10 +73,7 @@ func (ah *AuthHandler) UpdateAccountPasswordHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
-	// Validation
-	form.CheckField(validator.NotBlank(form.CurrentPassword), "current_password", "Current password is required")
-	form.CheckField(validator.NotBlank(form.NewPassword), "new_password", "New password is required")
-	form.CheckField(validator.NotBlank(form.ConfirmPassword), "confirm_password", "Confirm password is required")
+	form.Validate()
 
 	data = ah.handler.NewTemplateData(r)
 
@@ -89,9 +85,9 @@ func (ah *AuthHandler) UpdateAccountPasswordHandler(w http.ResponseWriter, r *ht
 	// Get the current user from context
 	user := ah.handler.GetUser(r)
 
-	err = ah.authService.UpdateAccountPassword(r.Context(), user.ID, form.CurrentPassword, form.NewPassword)
+	err = ah.authService.UpdateAccountPassword(r.Context(), user.ID, form.CurrentPassword, form.NewPassword, ah.handler.SessionManager.Token(r.Context()))
 	if err != nil {
-		if err.Error() == "invalid current password" {
+		if errors.Is(err, service.ErrInvalidPassword) {
 			form.SetMessage("Current password is incorrect", forms.MessageTypeError)
 		} else {
 			form.SetMessage("Failed to update password. Please try again.", forms.MessageTypeError)
@@ -115,8 +111,7 @@ func (ah *AuthHandler) DeleteAccountHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Validation
-	form.CheckField(validator.NotBlank(form.Password), "password", "Password is required to delete your account")
+	form.Validate()
 
 	data = ah.handler.NewTemplateData(r)
 	if !form.Valid() {
@@ -6,6 +6,7 @@ import (
 	"go-web-starter/cmd/web/views/auth"
 	"go-web-starter/internal/forms"
 	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/mailer"
 	"net/http"
 
 	"github.com/angelofallars/htmx-go"
@@ -49,7 +50,9 @@ func (ah *AuthHandler) ResetPasswordPostHandler(w http.ResponseWriter, r *http.R
 	}
 
 	// Notify the user by mail
-	err = ah.handler.Mailer.Send(user.Email, "reset_password_confirmation.tmpl", nil)
+	err = ah.handler.Mailer.SendMessage(r.Context(), mailer.NewMessage(mailer.PasswordResetConfirmationEmail{
+		LoginLink: ah.handler.Config.AppURL + "/login",
+	}).To(user.Email))
 	if err != nil {
 		ah.handler.Logger.PrintError(err, nil)
 	}
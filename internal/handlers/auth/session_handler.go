@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"go-web-starter/cmd/web/views/auth"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/angelofallars/htmx-go"
+	"github.com/go-chi/chi/v5"
+)
+
+func (ah *AuthHandler) SessionsSectionHandler(w http.ResponseWriter, r *http.Request) {
+	ah.renderSessionsSection(w, r, forms.Form{})
+}
+
+func (ah *AuthHandler) renderSessionsSection(w http.ResponseWriter, r *http.Request, form forms.Form) {
+	user := ah.handler.GetUser(r)
+
+	sessions, err := ah.authService.ListSessions(r.Context(), user.ID, ah.handler.SessionManager.Token(r.Context()))
+	if err != nil {
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	htmx.NewResponse().RenderTempl(r.Context(), w, auth.SessionsSection(data, sessions, form))
+}
+
+func (ah *AuthHandler) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.Form
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	user := ah.handler.GetUser(r)
+
+	err = ah.authService.RevokeSession(r.Context(), user.ID, int32(id))
+	if err != nil {
+		if !errors.Is(err, service.ErrSessionNotFound) {
+			ah.handler.Logger.PrintError(err, nil)
+		}
+		form.SetMessage("Failed to sign out the session. Please try again.", forms.MessageTypeError)
+		ah.renderSessionsSection(w, r, form)
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("session revoked", map[string]string{
+		"user_id":    fmt.Sprintf("%d", user.ID),
+		"session_id": fmt.Sprintf("%d", id),
+	})
+
+	form.SetMessage("The session has been signed out.", forms.MessageTypeSuccess)
+	ah.renderSessionsSection(w, r, form)
+}
+
+func (ah *AuthHandler) RevokeOtherSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.Form
+
+	user := ah.handler.GetUser(r)
+
+	err := ah.authService.RevokeOtherSessions(r.Context(), user.ID, ah.handler.SessionManager.Token(r.Context()))
+	if err != nil {
+		ah.handler.Logger.PrintError(err, map[string]string{
+			"user_id": fmt.Sprintf("%d", user.ID),
+		})
+		form.SetMessage("Failed to sign out the other sessions. Please try again.", forms.MessageTypeError)
+		ah.renderSessionsSection(w, r, form)
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("other sessions revoked", map[string]string{
+		"user_id": fmt.Sprintf("%d", user.ID),
+	})
+
+	form.SetMessage("All other sessions have been signed out.", forms.MessageTypeSuccess)
+	ah.renderSessionsSection(w, r, form)
+}
@@ -1,9 +1,12 @@
 package auth
 
 import (
+	"errors"
+	"fmt"
 	"go-web-starter/cmd/web/views/auth"
+	"go-web-starter/internal/config"
 	"go-web-starter/internal/forms"
-	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/service"
 	"net/http"
 
 	"github.com/angelofallars/htmx-go"
@@ -20,23 +23,21 @@ func (ah *AuthHandler) SignUpPostHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Validation
-	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
-	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
-	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
-	form.CheckField(validator.NotBlank(form.Password), "password", "This field cannot be blank")
-	form.CheckField(validator.MinChars(form.Password, 8), "password", "This field must be at least 8 characters long")
-	form.CheckField(validator.NotBlank(form.ConfirmPassword), "confirm_password", "This field cannot be blank")
-	form.CheckField(validator.MinChars(form.ConfirmPassword, 8), "confirm_password", "This field must be at least 8 characters long")
-	form.CheckField(validator.Equals(form.Password, form.ConfirmPassword), "confirm_password", "Passwords do not match")
+	form.Validate()
 
 	if !form.Valid() {
 		htmx.NewResponse().RenderTempl(r.Context(), w, auth.SignUpForm(templateData, form))
 		return
 	}
 
-	// Insert into the users table - with DB transaction
-	user, err := ah.authService.SignUp(r.Context(), form.Name, form.Email, form.Password, false)
+	if form.Invitation != "" {
+		ah.signUpWithInvitation(w, r, form)
+		return
+	}
+
+	// Insert into the users table and queue the emails greeting the user and
+	// sending them a link to verify their email address - with DB transaction
+	_, err = ah.authService.SignUp(r.Context(), form.Name, form.Email, form.Password, ah.handler.Config.AppURL)
 	if err != nil {
 		ah.handler.Logger.PrintError(err, map[string]string{
 			"request_method": r.Method,
@@ -50,20 +51,6 @@ func (ah *AuthHandler) SignUpPostHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Send the user a message to verify the user's email address account
-	// TODO: token with a ttl of 6 hour
-	activationLink := ah.handler.Config.AppURL + "/activate?token="
-	data := map[string]any{
-		"activationLink": activationLink,
-		"userID":         user.ID,
-	}
-
-	// TODO: Send this to a background job handler, where it can be retried
-	err = ah.handler.Mailer.Send(user.Email, "user_welcome.tmpl", data)
-	if err != nil {
-		ah.handler.Logger.PrintError(err, nil)
-	}
-
 	// add message to the session manager and display it to the user
 	ah.handler.SessionManager.Put(r.Context(), "flash", "Your account was created successfully!")
 
@@ -76,6 +63,42 @@ func (ah *AuthHandler) SignUpPostHandler(w http.ResponseWriter, r *http.Request)
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
 
+// signUpWithInvitation creates the account of an invited user, who joins the
+// organization of the invitation and is logged in right away.
+func (ah *AuthHandler) signUpWithInvitation(w http.ResponseWriter, r *http.Request, form forms.UserSignUpForm) {
+	templateData := ah.handler.NewTemplateData(r)
+
+	user, membership, err := ah.authService.SignUpWithInvitation(r.Context(), form.Name, form.Password, form.Invitation)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvitationNotFound):
+			form.SetMessage("The invitation is no longer valid. Ask for a new one.", forms.MessageTypeError)
+		case errors.Is(err, service.ErrEmailTaken):
+			form.SetMessage("An account with this email address exists already. Log in to accept the invitation.", forms.MessageTypeError)
+		default:
+			ah.handler.Logger.PrintError(err, map[string]string{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+			})
+			form.SetMessage("Something went wrong with your registration. please try again", forms.MessageTypeError)
+		}
+
+		htmx.NewResponse().RenderTempl(r.Context(), w, auth.SignUpForm(templateData, form))
+		return
+	}
+
+	// the invited address is verified, so the user is logged in right away
+	ah.handler.SessionManager.Put(r.Context(), string(config.CurrentOrganizationID), membership.OrganizationID)
+	ah.handler.SessionManager.Put(r.Context(), "flash", fmt.Sprintf("Welcome to %s.", membership.Name))
+
+	err = ah.completeLogin(w, r, user, loginMethodPassword, "/projects")
+	if err != nil {
+		ah.handler.Logger.PrintError(err, nil)
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Your account was created. Log in to continue.")
+		ah.handler.Redirect(w, r, "/login")
+	}
+}
+
 func (ah *AuthHandler) SignUpViewHandler(w http.ResponseWriter, r *http.Request) {
 	// check user shouldnt be logged in
 	data := ah.handler.NewTemplateData(r)
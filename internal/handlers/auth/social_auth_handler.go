@@ -1,10 +1,13 @@
 package auth
 
 import (
-	"context"
 	"errors"
 	"fmt"
+	"go-web-starter/internal/config"
 	"go-web-starter/internal/queries"
+	"go-web-starter/internal/service"
+	"go-web-starter/internal/types"
+	"net"
 	"net/http"
 	"slices"
 	"time"
@@ -20,13 +23,15 @@ const (
 	// csrfCookieName     = "oauth_csrf"
 	maxEmailLength = 255
 	maxNameLength  = 100
+	// disabledMessage is shown to disabled users whichever way they log in
+	disabledMessage = "This account has been disabled. Please contact support."
 )
 
 func (ah *AuthHandler) SocialAuthHandler(w http.ResponseWriter, r *http.Request) {
 	provider := chi.URLParam(r, "provider")
 
 	// Validate provider
-	if !ah.isValidProvider(provider) {
+	if _, ok := ah.socialProvider(provider); !ok {
 		ah.handler.Logger.PrintInfo("Invalid provider requested", map[string]string{
 			"provider": provider,
 			"ip":       r.RemoteAddr,
@@ -47,7 +52,8 @@ func (ah *AuthHandler) SocialAuthCallbackHandler(w http.ResponseWriter, r *http.
 	provider := chi.URLParam(r, "provider")
 
 	// Validate provider
-	if !ah.isValidProvider(provider) {
+	socialProvider, ok := ah.socialProvider(provider)
+	if !ok {
 		ah.handler.Logger.PrintInfo("Invalid provider in callback", map[string]string{
 			"provider": provider,
 			"ip":       r.RemoteAddr,
@@ -66,6 +72,12 @@ func (ah *AuthHandler) SocialAuthCallbackHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	// Logged in users come back from connecting another account on their profile
+	if ah.handler.IsAuthenticated(r) {
+		ah.connectSocialAccount(w, r, socialProvider, gothUser)
+		return
+	}
+
 	// Validate user data from provider
 	if err := ah.validateSocialUserData(gothUser); err != nil {
 		ah.handler.Logger.PrintError(err, map[string]string{
@@ -77,8 +89,16 @@ func (ah *AuthHandler) SocialAuthCallbackHandler(w http.ResponseWriter, r *http.
 	}
 
 	// Process social authentication
-	user, err := ah.authService.ProcessSocialAuth(r.Context(), gothUser, provider)
+	user, err := ah.authService.ProcessSocialAuth(r.Context(), gothUser, socialProvider, ah.handler.Config.AppURL)
 	if err != nil {
+		if errors.Is(err, service.ErrAccountLinkPending) {
+			ah.handleAuthError(w, r, fmt.Sprintf(
+				"An account with this email address already exists. We have sent you an email to confirm connecting %s to it.",
+				socialProvider.DisplayName,
+			))
+			return
+		}
+
 		ah.handler.Logger.PrintError(err, map[string]string{
 			"provider": provider,
 			"email":    gothUser.Email,
@@ -87,12 +107,6 @@ func (ah *AuthHandler) SocialAuthCallbackHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	// Create new session
-	if err := ah.createAuthenticatedSession(r.Context(), user); err != nil {
-		ah.handler.ServerError(w, err)
-		return
-	}
-
 	// Log successful authentication
 	ah.handler.Logger.PrintInfo("Successful social login", map[string]string{
 		"user_id":  fmt.Sprintf("%d", user.ID),
@@ -100,15 +114,29 @@ func (ah *AuthHandler) SocialAuthCallbackHandler(w http.ResponseWriter, r *http.
 		"ip":       r.RemoteAddr,
 	})
 
-	ah.redirectAfterAuth(w, r)
+	// Create new session, or ask for the second factor first
+	if err := ah.completeLogin(w, r, user, provider, ah.redirectURLAfterAuth(r)); err != nil {
+		if errors.Is(err, service.ErrUserDisabled) {
+			ah.handleAuthError(w, r, disabledMessage)
+			return
+		}
+		ah.handler.ServerError(w, err)
+		return
+	}
 }
 
 // Helper methods
 
-func (ah *AuthHandler) isValidProvider(provider string) bool {
-	// TODO: Make this configurable via environment variables
-	validProviders := []string{"google", "github"}
-	return slices.Contains(validProviders, provider)
+// socialProvider looks up a configured social login provider by name
+func (ah *AuthHandler) socialProvider(name string) (types.SocialProvider, bool) {
+	i := slices.IndexFunc(ah.handler.SocialProviders, func(p types.SocialProvider) bool {
+		return p.Name == name
+	})
+	if i < 0 {
+		return types.SocialProvider{}, false
+	}
+
+	return ah.handler.SocialProviders[i], true
 }
 
 func (ah *AuthHandler) validateSocialUserData(user goth.User) error {
@@ -132,7 +160,9 @@ func (ah *AuthHandler) validateSocialUserData(user goth.User) error {
 	return nil
 }
 
-func (ah *AuthHandler) createAuthenticatedSession(ctx context.Context, user *queries.User) error {
+func (ah *AuthHandler) createAuthenticatedSession(r *http.Request, user *queries.User, method string) error {
+	ctx := r.Context()
+
 	// Renew session token to prevent fixation
 	if err := ah.handler.SessionManager.RenewToken(ctx); err != nil {
 		return err
@@ -142,10 +172,30 @@ func (ah *AuthHandler) createAuthenticatedSession(ctx context.Context, user *que
 	ah.handler.SessionManager.Put(ctx, "authenticatedUserID", user.ID)
 	ah.handler.SessionManager.Put(ctx, "authenticatedAt", time.Now().Unix())
 
-	return nil
+	// Add the session to the index shown on the profile page
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return ah.authService.RecordSession(ctx, user.ID, ah.handler.SessionManager.Token(ctx), r.UserAgent(), ip, method, ah.handler.SessionManager.Lifetime)
+}
+
+// recordFailedLogin writes a login that failed after the user was known, e.g. with
+// a wrong second factor, to the audit log. Failures are logged, the visitor sees
+// the failed login either way.
+func (ah *AuthHandler) recordFailedLogin(r *http.Request, userID int32, reason string) {
+	err := ah.authService.RecordAuditEvent(r.Context(), service.AuditEvent{
+		Action:   config.AuditLoginFailed,
+		TargetID: userID,
+		Metadata: map[string]any{"reason": reason},
+	})
+	if err != nil {
+		ah.handler.Logger.PrintError(err, nil)
+	}
 }
 
-func (ah *AuthHandler) redirectAfterAuth(w http.ResponseWriter, r *http.Request) {
+func (ah *AuthHandler) redirectURLAfterAuth(r *http.Request) string {
 	redirectURL := defaultRedirectURL
 
 	// Get intended destination from session (set before OAuth flow)
@@ -156,7 +206,7 @@ func (ah *AuthHandler) redirectAfterAuth(w http.ResponseWriter, r *http.Request)
 		ah.handler.SessionManager.Remove(r.Context(), "next")
 	}
 
-	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+	return redirectURL
 }
 
 func (ah *AuthHandler) handleAuthError(w http.ResponseWriter, r *http.Request, userMessage string) {
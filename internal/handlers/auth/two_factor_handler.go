@@ -0,0 +1,310 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"go-web-starter/cmd/web/views/auth"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/service"
+	"net/http"
+
+	"github.com/angelofallars/htmx-go"
+)
+
+const (
+	// session keys of a login waiting for the second factor
+	twoFactorUserIDKey   = "twoFactorUserID"
+	twoFactorNextKey     = "twoFactorNext"
+	twoFactorAttemptsKey = "twoFactorAttempts"
+	twoFactorMethodKey   = "twoFactorMethod"
+
+	maxTwoFactorAttempts = 5
+)
+
+// login methods recorded in the audit log, social logins record the provider
+const (
+	loginMethodPassword  = "password"
+	loginMethodMagicLink = "magic_link"
+	loginMethodPasskey   = "passkey"
+)
+
+// completeLogin finishes a login once the password or social provider has been
+// checked. Users with two-factor authentication are sent to the code challenge
+// first, everyone else gets a session and is redirected to redirectURL.
+func (ah *AuthHandler) completeLogin(w http.ResponseWriter, r *http.Request, user *queries.User, method, redirectURL string) error {
+	enabled, err := ah.authService.IsTwoFactorEnabled(r.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		if err := ah.handler.SessionManager.RenewToken(r.Context()); err != nil {
+			return err
+		}
+
+		ah.handler.SessionManager.Put(r.Context(), twoFactorUserIDKey, user.ID)
+		ah.handler.SessionManager.Put(r.Context(), twoFactorNextKey, redirectURL)
+		ah.handler.SessionManager.Put(r.Context(), twoFactorMethodKey, method)
+		ah.handler.SessionManager.Remove(r.Context(), twoFactorAttemptsKey)
+
+		ah.handler.Redirect(w, r, "/login/2fa")
+		return nil
+	}
+
+	if err := ah.createAuthenticatedSession(r, user, method); err != nil {
+		return err
+	}
+
+	ah.handler.Redirect(w, r, redirectURL)
+	return nil
+}
+
+func (ah *AuthHandler) clearTwoFactorChallenge(r *http.Request) {
+	ah.handler.SessionManager.Remove(r.Context(), twoFactorUserIDKey)
+	ah.handler.SessionManager.Remove(r.Context(), twoFactorNextKey)
+	ah.handler.SessionManager.Remove(r.Context(), twoFactorAttemptsKey)
+	ah.handler.SessionManager.Remove(r.Context(), twoFactorMethodKey)
+}
+
+func (ah *AuthHandler) TwoFactorChallengeView(w http.ResponseWriter, r *http.Request) {
+	if ah.handler.SessionManager.GetInt32(r.Context(), twoFactorUserIDKey) == 0 {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	data.PageTitle = "Two-factor authentication"
+
+	auth.TwoFactorChallengeView(data, forms.TwoFactorCodeForm{}).Render(r.Context(), w)
+}
+
+func (ah *AuthHandler) TwoFactorChallengePostHandler(w http.ResponseWriter, r *http.Request) {
+	userID := ah.handler.SessionManager.GetInt32(r.Context(), twoFactorUserIDKey)
+	if userID == 0 {
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Your login has expired. Please log in again.")
+		ah.handler.Redirect(w, r, "/login")
+		return
+	}
+
+	var form forms.TwoFactorCodeForm
+
+	data := ah.handler.NewTemplateData(r)
+
+	err := ah.handler.DecodePostForm(r, &form)
+	if err != nil {
+		form.SetMessage("Invalid form data", forms.MessageTypeError)
+		htmx.NewResponse().RenderTempl(r.Context(), w, auth.TwoFactorChallengeForm(data, form))
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Code), "code", "This field cannot be blank")
+
+	if !form.Valid() {
+		htmx.NewResponse().RenderTempl(r.Context(), w, auth.TwoFactorChallengeForm(data, form))
+		return
+	}
+
+	// invalid codes count towards the lockout of the account, the attempts of the
+	// challenge only limit the guesses per password login
+	err = ah.authService.VerifyLoginTwoFactor(r.Context(), userID, form.Code, ah.handler.Config.AppURL)
+	if errors.Is(err, service.ErrAccountLocked) {
+		ah.handler.Logger.PrintInfo("two-factor attempt on a locked account", map[string]string{
+			"user_id": fmt.Sprintf("%d", userID),
+			"ip":      r.RemoteAddr,
+		})
+
+		ah.clearTwoFactorChallenge(r)
+		ah.handler.SessionManager.Put(r.Context(), "flash", "Too many failed login attempts. Please try again later or reset your password.")
+		ah.handler.Redirect(w, r, "/login")
+		return
+	}
+	if err != nil {
+		if !errors.Is(err, service.ErrInvalidTwoFactorCode) {
+			ah.handler.Logger.PrintError(err, map[string]string{
+				"user_id": fmt.Sprintf("%d", userID),
+			})
+		}
+
+		attempts := ah.handler.SessionManager.GetInt(r.Context(), twoFactorAttemptsKey) + 1
+		if attempts >= maxTwoFactorAttempts {
+			ah.handler.Logger.PrintInfo("too many two-factor attempts", map[string]string{
+				"user_id": fmt.Sprintf("%d", userID),
+				"ip":      r.RemoteAddr,
+			})
+
+			ah.clearTwoFactorChallenge(r)
+			ah.handler.SessionManager.Put(r.Context(), "flash", "Too many invalid codes. Please log in again.")
+			ah.handler.Redirect(w, r, "/login")
+			return
+		}
+		ah.handler.SessionManager.Put(r.Context(), twoFactorAttemptsKey, attempts)
+
+		form.Code = ""
+		form.SetMessage("Invalid authentication code", forms.MessageTypeError)
+		htmx.NewResponse().RenderTempl(r.Context(), w, auth.TwoFactorChallengeForm(data, form))
+		return
+	}
+
+	redirectURL := ah.handler.SessionManager.GetString(r.Context(), twoFactorNextKey)
+	if redirectURL == "" || !IsValidRedirectPath(redirectURL) {
+		redirectURL = defaultRedirectURL
+	}
+
+	method := ah.handler.SessionManager.GetString(r.Context(), twoFactorMethodKey)
+	ah.clearTwoFactorChallenge(r)
+
+	if err := ah.createAuthenticatedSession(r, &queries.User{ID: userID}, method); err != nil {
+		if errors.Is(err, service.ErrUserDisabled) {
+			ah.handler.SessionManager.Put(r.Context(), "flash", disabledMessage)
+			ah.handler.Redirect(w, r, "/login")
+			return
+		}
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	ah.handler.Redirect(w, r, redirectURL)
+}
+
+// Profile settings
+
+func (ah *AuthHandler) TwoFactorSectionHandler(w http.ResponseWriter, r *http.Request) {
+	ah.renderTwoFactorSection(w, r, forms.DisableTwoFactorForm{})
+}
+
+func (ah *AuthHandler) renderTwoFactorSection(w http.ResponseWriter, r *http.Request, form forms.DisableTwoFactorForm) {
+	user := ah.handler.GetUser(r)
+
+	status, err := ah.authService.GetTwoFactorStatus(r.Context(), user.ID)
+	if err != nil {
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	htmx.NewResponse().RenderTempl(r.Context(), w, auth.TwoFactorSection(data, status, form))
+}
+
+func (ah *AuthHandler) TwoFactorSetupHandler(w http.ResponseWriter, r *http.Request) {
+	user := ah.handler.GetUser(r)
+
+	setup, err := ah.authService.BeginTwoFactorSetup(r.Context(), user)
+	if err != nil {
+		ah.handler.Logger.PrintError(err, map[string]string{
+			"user_id": fmt.Sprintf("%d", user.ID),
+		})
+
+		var form forms.DisableTwoFactorForm
+		form.SetMessage("Could not start the two-factor setup. Please try again.", forms.MessageTypeError)
+		ah.renderTwoFactorSection(w, r, form)
+		return
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	htmx.NewResponse().RenderTempl(r.Context(), w, auth.TwoFactorSetupForm(data, setup, forms.TwoFactorCodeForm{}))
+}
+
+func (ah *AuthHandler) TwoFactorConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.TwoFactorCodeForm
+
+	user := ah.handler.GetUser(r)
+	data := ah.handler.NewTemplateData(r)
+
+	setup, err := ah.authService.GetPendingTwoFactorSetup(r.Context(), user)
+	if err != nil {
+		ah.handler.ServerError(w, err)
+		return
+	}
+
+	err = ah.handler.DecodePostForm(r, &form)
+	if err != nil {
+		form.SetMessage("Invalid form data", forms.MessageTypeError)
+		htmx.NewResponse().RenderTempl(r.Context(), w, auth.TwoFactorSetupForm(data, setup, form))
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Code), "code", "This field cannot be blank")
+
+	if !form.Valid() {
+		htmx.NewResponse().RenderTempl(r.Context(), w, auth.TwoFactorSetupForm(data, setup, form))
+		return
+	}
+
+	recoveryCodes, err := ah.authService.ConfirmTwoFactor(r.Context(), user.ID, form.Code)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTwoFactorCode) {
+			form.AddFieldError("code", "The code is not valid. Check the time on your device and try again.")
+		} else {
+			ah.handler.Logger.PrintError(err, map[string]string{
+				"user_id": fmt.Sprintf("%d", user.ID),
+			})
+			form.SetMessage("Could not enable two-factor authentication. Please try again.", forms.MessageTypeError)
+		}
+
+		form.Code = ""
+		htmx.NewResponse().RenderTempl(r.Context(), w, auth.TwoFactorSetupForm(data, setup, form))
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("two-factor authentication enabled", map[string]string{
+		"user_id": fmt.Sprintf("%d", user.ID),
+	})
+
+	htmx.NewResponse().RenderTempl(r.Context(), w, auth.RecoveryCodes(data, recoveryCodes))
+}
+
+func (ah *AuthHandler) RegenerateRecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	user := ah.handler.GetUser(r)
+
+	recoveryCodes, err := ah.authService.RegenerateRecoveryCodes(r.Context(), user.ID)
+	if err != nil {
+		ah.handler.Logger.PrintError(err, map[string]string{
+			"user_id": fmt.Sprintf("%d", user.ID),
+		})
+
+		var form forms.DisableTwoFactorForm
+		form.SetMessage("Could not generate new recovery codes. Please try again.", forms.MessageTypeError)
+		ah.renderTwoFactorSection(w, r, form)
+		return
+	}
+
+	data := ah.handler.NewTemplateData(r)
+	htmx.NewResponse().RenderTempl(r.Context(), w, auth.RecoveryCodes(data, recoveryCodes))
+}
+
+func (ah *AuthHandler) DisableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.DisableTwoFactorForm
+
+	err := ah.handler.DecodePostForm(r, &form)
+	if err != nil {
+		form.SetMessage("Invalid form data", forms.MessageTypeError)
+		ah.renderTwoFactorSection(w, r, form)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Password), "password", "Password is required to disable two-factor authentication")
+
+	if !form.Valid() {
+		ah.renderTwoFactorSection(w, r, form)
+		return
+	}
+
+	user := ah.handler.GetUser(r)
+
+	err = ah.authService.DisableTwoFactor(r.Context(), user.ID, form.Password)
+	if err != nil {
+		form.SetMessage("Failed to disable two-factor authentication. Check your password and try again.", forms.MessageTypeError)
+		ah.renderTwoFactorSection(w, r, form)
+		return
+	}
+
+	ah.handler.Logger.PrintInfo("two-factor authentication disabled", map[string]string{
+		"user_id": fmt.Sprintf("%d", user.ID),
+	})
+
+	form = forms.DisableTwoFactorForm{}
+	form.SetMessage("Two-factor authentication has been disabled.", forms.MessageTypeSuccess)
+	ah.renderTwoFactorSection(w, r, form)
+}
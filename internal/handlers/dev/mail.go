@@ -0,0 +1,83 @@
+package dev
+
+import (
+	"go-web-starter/cmd/web/views"
+	"go-web-starter/internal/handlers"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/types"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// DevHandler serves the development tools. The routes are only registered when
+// config.Config.DevToolsEnabled allows it.
+type DevHandler struct {
+	handler *handlers.Handlers
+}
+
+func NewDevHandler(h *handlers.Handlers) *DevHandler {
+	return &DevHandler{
+		handler: h,
+	}
+}
+
+// MailViewHandler previews every email with sample data, and the inbox of the
+// emails captured by the mail driver. The sample or message query parameter
+// picks the email to show, tab the part of it.
+func (dh *DevHandler) MailViewHandler(w http.ResponseWriter, r *http.Request) {
+	data := dh.handler.NewTemplateData(r)
+	data.PageTitle = "Mail preview"
+
+	samples := mailer.Samples(dh.handler.Config.AppURL)
+
+	query := r.URL.Query()
+	preview := types.MailPreview{
+		Samples: slices.Sorted(maps.Keys(samples)),
+		Tab:     query.Get("tab"),
+	}
+	if !slices.Contains([]string{types.MailTabSubject, types.MailTabPlain, types.MailTabHTML}, preview.Tab) {
+		preview.Tab = types.MailTabHTML
+	}
+
+	if inbox, ok := dh.handler.Mailer.(mailer.Inbox); ok {
+		var err error
+		preview.Inbox, err = inbox.Inbox()
+		if err != nil {
+			dh.handler.ServerError(w, err)
+			return
+		}
+		// the inbox is shown even when it is empty
+		if preview.Inbox == nil {
+			preview.Inbox = []mailer.Message{}
+		}
+	}
+
+	if name := query.Get("sample"); name != "" {
+		sample, ok := samples[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		preview.Sample = name
+		email, err := mailer.Render(r.Context(), dh.handler.Config.Mailer.Sender, mailer.NewBrand(dh.handler.Config), mailer.NewMessage(sample).To("jane@example.com"))
+		if err != nil {
+			preview.Error = err.Error()
+		} else {
+			preview.Email = &email
+		}
+	} else if message := query.Get("message"); message != "" {
+		n, err := strconv.Atoi(message)
+		if err != nil || n < 1 || n > len(preview.Inbox) {
+			http.NotFound(w, r)
+			return
+		}
+
+		preview.Message = n
+		preview.Email = &preview.Inbox[n-1]
+	}
+
+	views.DevMailView(data, preview).Render(r.Context(), w)
+}
@@ -0,0 +1,66 @@
+package dev_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/tests"
+)
+
+func TestMailPreview(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	t.Run("lists the emails", func(t *testing.T) {
+		status, _, body := ts.Get(t, "/_dev/mail")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "PasswordResetEmail")
+		tests.AssertContains(t, body, "ActivationEmail")
+		tests.AssertContains(t, body, "No emails sent yet.")
+	})
+
+	t.Run("renders an email with sample data", func(t *testing.T) {
+		status, _, body := ts.Get(t, "/_dev/mail?sample=ActivationEmail&tab=subject")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Verify your email address")
+
+		status, _, body = ts.Get(t, "/_dev/mail?sample=ActivationEmail&tab=plain")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Hi Jane Doe,")
+		tests.AssertContains(t, body, "/activate?token=")
+
+		status, _, body = ts.Get(t, "/_dev/mail?sample=ActivationEmail")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, `id="mail-html"`)
+
+		status, _, _ = ts.Get(t, "/_dev/mail?sample=MissingEmail")
+		tests.AssertStatus(t, status, http.StatusNotFound)
+	})
+
+	t.Run("shows the captured emails", func(t *testing.T) {
+		ts.Mailer.Clear()
+		msg := mailer.NewMessage(mailer.WelcomeEmail{Name: "Captured Jane"}).
+			To("jane@example.com").
+			CC("john@example.com").
+			Attach("notes.txt", "text/plain", []byte("notes"))
+		err := ts.Mailer.SendMessage(context.Background(), msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, _, body := ts.Get(t, "/_dev/mail")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "/_dev/mail?message=1")
+
+		status, _, body = ts.Get(t, "/_dev/mail?message=1&tab=plain")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Hi Captured Jane,")
+		tests.AssertContains(t, body, "john@example.com")
+		tests.AssertContains(t, body, "notes.txt")
+
+		status, _, _ = ts.Get(t, "/_dev/mail?message=2")
+		tests.AssertStatus(t, status, http.StatusNotFound)
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"go-web-starter/internal/config"
@@ -11,8 +12,10 @@ import (
 	"go-web-starter/internal/types"
 	"net/http"
 	"runtime/debug"
+	"slices"
 
 	"github.com/alexedwards/scs/v2"
+	"github.com/angelofallars/htmx-go"
 	"github.com/go-playground/form/v4"
 	"github.com/justinas/nosurf"
 	"golang.org/x/crypto/bcrypt"
@@ -25,6 +28,8 @@ type Handlers struct {
 	Mailer         mailer.Mailer
 	SessionManager *scs.SessionManager
 	Config         config.Config
+	// SocialProviders are the social logins offered on the login and signup pages
+	SocialProviders []types.SocialProvider
 }
 
 func NewHandlers(
@@ -34,20 +39,22 @@ func NewHandlers(
 	mailer mailer.Mailer,
 	sessionManager *scs.SessionManager,
 	config config.Config,
+	socialProviders []types.SocialProvider,
 ) *Handlers {
 	return &Handlers{
-		DbQueries:      q,
-		DbService:      dbService,
-		Logger:         logger,
-		Mailer:         mailer,
-		SessionManager: sessionManager,
-		Config:         config,
+		DbQueries:       q,
+		DbService:       dbService,
+		Logger:          logger,
+		Mailer:          mailer,
+		SessionManager:  sessionManager,
+		Config:          config,
+		SocialProviders: socialProviders,
 	}
 }
 
 // helpers
 
-func (h *Handlers) isAuthenticated(r *http.Request) bool {
+func (h *Handlers) IsAuthenticated(r *http.Request) bool {
 	isAuthenticated, ok := r.Context().Value(config.IsAuthenticatedContextKey).(bool)
 	if !ok {
 		return false
@@ -65,15 +72,52 @@ func (h *Handlers) GetUser(r *http.Request) *queries.User {
 	return &user
 }
 
+// GetImpersonator returns the admin logged in as the user, nil when the user is
+// logged in themselves
+func (h *Handlers) GetImpersonator(r *http.Request) *queries.User {
+	impersonator, ok := r.Context().Value(config.ImpersonatorContextKey).(queries.User)
+	if !ok {
+		return nil
+	}
+
+	return &impersonator
+}
+
+// GetOrganization returns the current organization of the user, nil when they have none
+func (h *Handlers) GetOrganization(r *http.Request) *types.Membership {
+	membership, ok := r.Context().Value(config.OrganizationContextKey).(types.Membership)
+	if !ok {
+		return nil
+	}
+
+	return &membership
+}
+
+// GetOrganizations returns all organizations the user is a member of
+func (h *Handlers) GetOrganizations(r *http.Request) []types.Membership {
+	memberships, _ := r.Context().Value(config.OrganizationsContextKey).([]types.Membership)
+	return memberships
+}
+
+// HasPermission reports whether the roles of the logged in user grant the permission
+func (h *Handlers) HasPermission(r *http.Request, permission string) bool {
+	permissions, _ := r.Context().Value(config.PermissionsContextKey).([]string)
+	return slices.Contains(permissions, permission)
+}
+
 func (h *Handlers) NewTemplateData(r *http.Request) types.TemplateData {
 	return types.TemplateData{
 		AppName:         h.Config.AppName,
 		AppEnv:          h.Config.AppEnv,
-		IsAuthenticated: h.isAuthenticated(r),
+		IsAuthenticated: h.IsAuthenticated(r),
 		User:            h.GetUser(r),
 		CSRFToken:       nosurf.Token(r),
 		Flash:           h.SessionManager.PopString(r.Context(), "flash"),
 		CurrentPath:     r.URL.Path,
+		SocialProviders: h.SocialProviders,
+		Organization:    h.GetOrganization(r),
+		Organizations:   h.GetOrganizations(r),
+		Impersonator:    h.GetImpersonator(r),
 	}
 }
 
@@ -110,6 +154,30 @@ func (h *Handlers) DecodePostForm(r *http.Request, dst any) error {
 	return nil
 }
 
+// Redirect sends the client to url, using the HX-Redirect header for htmx requests
+// so that the whole page is replaced instead of the swap target.
+func (h *Handlers) Redirect(w http.ResponseWriter, r *http.Request, url string) {
+	if htmx.IsHTMX(r) {
+		htmx.NewResponse().Redirect(url).Write(w)
+		return
+	}
+
+	http.Redirect(w, r, url, http.StatusSeeOther)
+}
+
+// WriteJSON encodes data as the JSON response body with the given status code
+func (h *Handlers) WriteJSON(w http.ResponseWriter, status int, data any) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		h.ServerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(js)
+}
+
 // The serverError helper writes an error message and stack trace to the errorLog,
 // then sends a generic 500 Internal Server Error response to the user.
 func (h *Handlers) ServerError(w http.ResponseWriter, err error) {
@@ -0,0 +1,109 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"go-web-starter/cmd/web/views"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/service"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// InvitationViewHandler shows the invitation of the emailed link. Invitees with an
+// account log in to accept it, everyone else signs up with the invited address.
+func (oh *OrganizationHandler) InvitationViewHandler(w http.ResponseWriter, r *http.Request) {
+	data := oh.handler.NewTemplateData(r)
+	data.PageTitle = "Invitation"
+
+	token := r.URL.Query().Get("token")
+
+	invitation, err := oh.orgService.GetInvitation(r.Context(), token)
+	if errors.Is(err, service.ErrInvitationNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		views.InvitationView(data, views.InvitationPage{}).Render(r.Context(), w)
+		return
+	}
+	if err != nil {
+		oh.handler.ServerError(w, err)
+		return
+	}
+
+	page := views.InvitationPage{
+		Invitation: invitation,
+		Token:      token,
+		LoginURL:   "/login?next=" + url.QueryEscape(r.URL.RequestURI()),
+	}
+
+	if data.IsAuthenticated {
+		page.OtherUser = !strings.EqualFold(data.User.Email, invitation.Email)
+	} else {
+		page.HasAccount, err = oh.orgService.HasAccount(r.Context(), invitation.Email)
+		if err != nil {
+			oh.handler.ServerError(w, err)
+			return
+		}
+
+		page.SignUpForm = forms.UserSignUpForm{
+			Email:      invitation.Email,
+			Invitation: token,
+		}
+	}
+
+	views.InvitationView(data, page).Render(r.Context(), w)
+}
+
+// AcceptInvitationHandler adds the logged in user to the organization of the
+// invitation and makes it the current one
+func (oh *OrganizationHandler) AcceptInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.InvitationTokenForm
+
+	err := oh.handler.DecodePostForm(r, &form)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	user := oh.handler.GetUser(r)
+
+	membership, err := oh.orgService.AcceptInvitation(r.Context(), form.Token, user)
+	switch {
+	case errors.Is(err, service.ErrInvitationNotFound):
+		oh.handler.SessionManager.Put(r.Context(), "flash", "The invitation is no longer valid. Ask for a new one.")
+		oh.handler.Redirect(w, r, "/dashboard")
+		return
+	case errors.Is(err, service.ErrInvitationEmail):
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	case err != nil:
+		oh.handler.ServerError(w, err)
+		return
+	}
+
+	oh.handler.SessionManager.Put(r.Context(), string(config.CurrentOrganizationID), membership.OrganizationID)
+	oh.handler.SessionManager.Put(r.Context(), "flash", fmt.Sprintf("Welcome to %s.", membership.Name))
+
+	oh.handler.Redirect(w, r, "/projects")
+}
+
+// DeclineInvitationHandler turns the invitation down, logged in or not
+func (oh *OrganizationHandler) DeclineInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.InvitationTokenForm
+
+	err := oh.handler.DecodePostForm(r, &form)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	err = oh.orgService.DeclineInvitation(r.Context(), form.Token)
+	if err != nil && !errors.Is(err, service.ErrInvitationNotFound) {
+		oh.handler.ServerError(w, err)
+		return
+	}
+
+	oh.handler.SessionManager.Put(r.Context(), "flash", "The invitation was declined.")
+	oh.handler.Redirect(w, r, "/")
+}
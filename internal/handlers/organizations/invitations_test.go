@@ -0,0 +1,198 @@
+package organizations_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/tests"
+)
+
+// invitationToken delivers the outbox and returns the token of the last
+// invitation mailed to email
+func invitationToken(t *testing.T, ts *tests.TestServer, email string) string {
+	t.Helper()
+
+	ts.RunJobs(t)
+
+	messages := ts.Mailer.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		invitation, ok := messages[i].Email.(mailer.InvitationEmail)
+		if !ok || messages[i].Recipient() != email {
+			continue
+		}
+
+		link, err := url.Parse(invitation.Link)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return link.Query().Get("token")
+	}
+
+	t.Fatalf("expected an invitation to %s; got %+v", email, ts.Mailer.Last())
+	return ""
+}
+
+func TestInvitations(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	owner, _ := ts.CreateAndLoginUser(t, "Owner", "owner@example.com", "Password123!")
+	existing, existingUser := ts.CreateAndLoginUser(t, "Existing", "existing@example.com", "Password123!")
+
+	status, headers, _ := ts.PostFormWithClient(t, owner, "/organizations", map[string]string{"name": "Acme"})
+	tests.AssertRedirect(t, status, headers, "/projects")
+
+	invite := func(t *testing.T, email, role string) (int, string) {
+		t.Helper()
+
+		status, _, body := ts.PostFormWithClient(t, owner, "/members/invitations", map[string]string{
+			"email": email,
+			"role":  role,
+		})
+		return status, body
+	}
+
+	t.Run("invite", func(t *testing.T) {
+		status, body := invite(t, "new@example.com", "owner")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "This field must be one of the listed roles")
+
+		status, body = invite(t, "new@example.com", "member")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Invitation sent to new@example.com.")
+
+		status, body = invite(t, "NEW@example.com", "member")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "pending invitation")
+
+		status, body = invite(t, "owner@example.com", "member")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "member already")
+	})
+
+	t.Run("new users sign up with the invitation", func(t *testing.T) {
+		token := invitationToken(t, ts, "new@example.com")
+
+		status, _, body := ts.Get(t, "/invitations/accept?token="+url.QueryEscape(token))
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Join Acme")
+		tests.AssertContains(t, body, `name="invitation"`)
+
+		// the invited address is used, whatever was posted
+		status, headers, _ := ts.PostForm(t, "/signup", map[string]string{
+			"name":             "New",
+			"email":            "other@example.com",
+			"password":         "Password123!",
+			"confirm_password": "Password123!",
+			"invitation":       token,
+		})
+		tests.AssertRedirect(t, status, headers, "/projects")
+
+		user, err := ts.Queries.GetUserByEmail(context.Background(), "new@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !user.EmailVerified {
+			t.Error("expected the invited address to be verified")
+		}
+
+		memberships, err := ts.Queries.ListMembershipsForUser(context.Background(), user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(memberships) != 2 {
+			t.Errorf("expected the personal organization and Acme; got %+v", memberships)
+		}
+
+		status, _, _ = ts.Get(t, "/invitations/accept?token="+url.QueryEscape(token))
+		tests.AssertStatus(t, status, http.StatusNotFound)
+	})
+
+	t.Run("existing users accept", func(t *testing.T) {
+		invite(t, "existing@example.com", "admin")
+		token := invitationToken(t, ts, "existing@example.com")
+
+		_, _, body := ts.Get(t, "/invitations/accept?token="+url.QueryEscape(token))
+		tests.AssertContains(t, body, "Log in to accept")
+
+		// only the invited user can accept
+		status, _, _ := ts.PostFormWithClient(t, owner, "/invitations/accept", map[string]string{"token": token})
+		tests.AssertStatus(t, status, http.StatusForbidden)
+
+		status, headers, _ := ts.PostFormWithClient(t, existing, "/invitations/accept", map[string]string{"token": token})
+		tests.AssertRedirect(t, status, headers, "/projects")
+
+		memberships, err := ts.Queries.ListMembershipsForUser(context.Background(), existingUser.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(memberships) != 1 || memberships[0].Role != "admin" {
+			t.Errorf("expected an admin membership of Acme; got %+v", memberships)
+		}
+	})
+
+	t.Run("declined, revoked and expired invitations stay visible", func(t *testing.T) {
+		invite(t, "declined@example.com", "member")
+		token := invitationToken(t, ts, "declined@example.com")
+
+		status, headers, _ := ts.PostForm(t, "/invitations/decline", map[string]string{"token": token})
+		tests.AssertRedirect(t, status, headers, "/")
+
+		invite(t, "revoked@example.com", "member")
+		_, err := ts.DB.Exec(`UPDATE invitations SET expires_at = NOW() - INTERVAL '1 day' WHERE email = 'revoked@example.com'`)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, body := ts.GetWithClient(t, owner, "/members")
+		tests.AssertContains(t, body, "declined@example.com")
+		tests.AssertContains(t, body, "declined")
+		tests.AssertContains(t, body, "expired")
+	})
+
+	t.Run("resend revives expired invitations", func(t *testing.T) {
+		var id int32
+		err := ts.DB.QueryRow(`SELECT id FROM invitations WHERE email = 'revoked@example.com'`).Scan(&id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, _, body := ts.PostFormWithClient(t, owner, fmt.Sprintf("/members/invitations/%d/resend", id), nil)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Invitation sent again.")
+		token := invitationToken(t, ts, "revoked@example.com")
+
+		status, _, body = ts.PostFormWithClient(t, owner, fmt.Sprintf("/members/invitations/%d/revoke", id), nil)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "revoked")
+
+		status, _, _ = ts.Get(t, "/invitations/accept?token="+url.QueryEscape(token))
+		tests.AssertStatus(t, status, http.StatusNotFound)
+
+		// answered invitations can't be revoked or resent
+		status, _, _ = ts.PostFormWithClient(t, owner, fmt.Sprintf("/members/invitations/%d/resend", id), nil)
+		tests.AssertStatus(t, status, http.StatusNotFound)
+	})
+
+	t.Run("members can't invite", func(t *testing.T) {
+		member, _ := ts.CreateAndLoginUser(t, "Member", "member@example.com", "Password123!")
+
+		invite(t, "member@example.com", "member")
+		token := invitationToken(t, ts, "member@example.com")
+		ts.PostFormWithClient(t, member, "/invitations/accept", map[string]string{"token": token})
+
+		status, _, _ := ts.PostFormWithClient(t, member, "/members/invitations", map[string]string{
+			"email": "someone@example.com",
+			"role":  "member",
+		})
+		tests.AssertStatus(t, status, http.StatusForbidden)
+
+		_, _, body := ts.GetWithClient(t, member, "/members")
+		tests.AssertNotContains(t, body, "declined@example.com")
+	})
+}
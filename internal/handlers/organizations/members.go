@@ -0,0 +1,155 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"go-web-starter/cmd/web/views"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/angelofallars/htmx-go"
+	"github.com/go-chi/chi/v5"
+)
+
+// MembersViewHandler lists the members of the current organization. Admins also
+// see the invitations and can invite people.
+func (oh *OrganizationHandler) MembersViewHandler(w http.ResponseWriter, r *http.Request) {
+	data := oh.handler.NewTemplateData(r)
+	data.PageTitle = "Members"
+
+	tenant := oh.tenant(r)
+
+	members, err := tenant.ListMembers(r.Context())
+	if err != nil {
+		oh.handler.ServerError(w, err)
+		return
+	}
+
+	var invitations []queries.ListInvitationsRow
+	if tenant.Membership.HasRole(config.OrgRoleAdmin) {
+		invitations, err = tenant.ListInvitations(r.Context())
+		if err != nil {
+			oh.handler.ServerError(w, err)
+			return
+		}
+	}
+
+	views.MembersView(data, members, invitations, forms.InvitationForm{Role: config.OrgRoleMember}).Render(r.Context(), w)
+}
+
+func (oh *OrganizationHandler) InviteHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.InvitationForm
+
+	tenant := oh.tenant(r)
+	if !tenant.Membership.HasRole(config.OrgRoleAdmin) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	err := oh.handler.DecodePostForm(r, &form)
+	if err != nil {
+		form.SetMessage("Invalid form data", forms.MessageTypeError)
+		oh.renderInvitations(w, r, tenant, form)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Email), "email", "This field cannot be blank")
+	form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", "This field must be a valid email address")
+	form.CheckField(validator.PermittedValue(form.Role, config.InvitationRoles...), "role", "This field must be one of the listed roles")
+
+	if !form.Valid() {
+		oh.renderInvitations(w, r, tenant, form)
+		return
+	}
+
+	user := oh.handler.GetUser(r)
+
+	err = oh.orgService.Invite(r.Context(), user, tenant.Membership, form.Email, form.Role, oh.handler.Config.AppURL)
+	switch {
+	case errors.Is(err, service.ErrAlreadyMember):
+		form.AddFieldError("email", "This person is a member already")
+		oh.renderInvitations(w, r, tenant, form)
+		return
+	case errors.Is(err, service.ErrAlreadyInvited):
+		form.AddFieldError("email", "This address has a pending invitation, resend it from the list")
+		oh.renderInvitations(w, r, tenant, form)
+		return
+	case err != nil:
+		oh.handler.Logger.PrintError(err, map[string]string{
+			"organization_id": fmt.Sprintf("%d", tenant.Membership.OrganizationID),
+		})
+		form.SetMessage("Failed to send the invitation. Please try again.", forms.MessageTypeError)
+		oh.renderInvitations(w, r, tenant, form)
+		return
+	}
+
+	sent := forms.InvitationForm{Role: config.OrgRoleMember}
+	sent.SetMessage(fmt.Sprintf("Invitation sent to %s.", form.Email), forms.MessageTypeSuccess)
+	oh.renderInvitations(w, r, tenant, sent)
+}
+
+// ResendInvitationHandler mails a pending invitation again with a new link
+func (oh *OrganizationHandler) ResendInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	oh.updateInvitation(w, r, "Invitation sent again.", func(tenant *service.Tenant, id int32) error {
+		return oh.orgService.ResendInvitation(r.Context(), oh.handler.GetUser(r), tenant.Membership, id, oh.handler.Config.AppURL)
+	})
+}
+
+func (oh *OrganizationHandler) RevokeInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	oh.updateInvitation(w, r, "Invitation revoked.", func(tenant *service.Tenant, id int32) error {
+		return oh.orgService.RevokeInvitation(r.Context(), tenant.Membership, id)
+	})
+}
+
+// updateInvitation runs update on the invitation of the id URL parameter and
+// re-renders the invitations with message
+func (oh *OrganizationHandler) updateInvitation(w http.ResponseWriter, r *http.Request, message string, update func(*service.Tenant, int32) error) {
+	tenant := oh.tenant(r)
+	if !tenant.Membership.HasRole(config.OrgRoleAdmin) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	form := forms.InvitationForm{Role: config.OrgRoleMember}
+
+	err = update(tenant, int32(id))
+	switch {
+	case errors.Is(err, service.ErrInvitationNotFound):
+		http.NotFound(w, r)
+		return
+	case err != nil:
+		oh.handler.Logger.PrintError(err, map[string]string{
+			"organization_id": fmt.Sprintf("%d", tenant.Membership.OrganizationID),
+			"invitation_id":   fmt.Sprintf("%d", id),
+		})
+		form.SetMessage("Something went wrong. Please try again.", forms.MessageTypeError)
+	default:
+		form.SetMessage(message, forms.MessageTypeSuccess)
+	}
+
+	oh.renderInvitations(w, r, tenant, form)
+}
+
+// renderInvitations re-renders the invitations of the tenant into #invitations
+func (oh *OrganizationHandler) renderInvitations(w http.ResponseWriter, r *http.Request, tenant *service.Tenant, form forms.InvitationForm) {
+	data := oh.handler.NewTemplateData(r)
+
+	invitations, err := tenant.ListInvitations(r.Context())
+	if err != nil {
+		oh.handler.ServerError(w, err)
+		return
+	}
+
+	htmx.NewResponse().RenderTempl(r.Context(), w, views.InvitationsSection(data, invitations, form))
+}
@@ -0,0 +1,109 @@
+package organizations
+
+import (
+	"fmt"
+	"go-web-starter/cmd/web/views"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/handlers"
+	"go-web-starter/internal/handlers/auth"
+	"go-web-starter/internal/service"
+	"net/http"
+
+	"github.com/angelofallars/htmx-go"
+)
+
+type OrganizationHandler struct {
+	handler    *handlers.Handlers
+	orgService *service.OrganizationService
+}
+
+func NewOrganizationHandler(h *handlers.Handlers, orgService *service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		handler:    h,
+		orgService: orgService,
+	}
+}
+
+// tenant returns the data of the current organization. The routes calling it are
+// behind requireOrganization.
+func (oh *OrganizationHandler) tenant(r *http.Request) *service.Tenant {
+	return oh.orgService.Tenant(*oh.handler.GetOrganization(r))
+}
+
+func (oh *OrganizationHandler) NewOrganizationView(w http.ResponseWriter, r *http.Request) {
+	data := oh.handler.NewTemplateData(r)
+	data.PageTitle = "New organization"
+
+	views.NewOrganizationView(data, forms.OrganizationForm{}).Render(r.Context(), w)
+}
+
+// CreateOrganizationHandler creates an organization owned by the user and switches to it
+func (oh *OrganizationHandler) CreateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.OrganizationForm
+
+	data := oh.handler.NewTemplateData(r)
+
+	err := oh.handler.DecodePostForm(r, &form)
+	if err != nil {
+		form.SetMessage("Invalid form data", forms.MessageTypeError)
+		htmx.NewResponse().RenderTempl(r.Context(), w, views.OrganizationForm(data, form))
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Name, 100), "name", "This field cannot be more than 100 characters long")
+
+	if !form.Valid() {
+		htmx.NewResponse().RenderTempl(r.Context(), w, views.OrganizationForm(data, form))
+		return
+	}
+
+	user := oh.handler.GetUser(r)
+
+	membership, err := oh.orgService.CreateOrganization(r.Context(), user.ID, form.Name)
+	if err != nil {
+		oh.handler.Logger.PrintError(err, map[string]string{
+			"user_id": fmt.Sprintf("%d", user.ID),
+		})
+		form.SetMessage("Failed to create the organization. Please try again.", forms.MessageTypeError)
+		htmx.NewResponse().RenderTempl(r.Context(), w, views.OrganizationForm(data, form))
+		return
+	}
+
+	oh.handler.SessionManager.Put(r.Context(), string(config.CurrentOrganizationID), membership.OrganizationID)
+	oh.handler.SessionManager.Put(r.Context(), "flash", fmt.Sprintf("Welcome to %s.", membership.Name))
+
+	oh.handler.Redirect(w, r, "/projects")
+}
+
+// SwitchOrganizationHandler makes another organization of the user the current one
+// and goes back to the page the switcher was used on.
+func (oh *OrganizationHandler) SwitchOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.SwitchOrganizationForm
+
+	err := oh.handler.DecodePostForm(r, &form)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	for _, membership := range oh.handler.GetOrganizations(r) {
+		if membership.OrganizationID != form.OrganizationID {
+			continue
+		}
+
+		oh.handler.SessionManager.Put(r.Context(), string(config.CurrentOrganizationID), membership.OrganizationID)
+
+		redirectURL := "/dashboard"
+		if form.Next != "" && auth.IsValidRedirectPath(form.Next) {
+			redirectURL = form.Next
+		}
+
+		oh.handler.Redirect(w, r, redirectURL)
+		return
+	}
+
+	http.NotFound(w, r)
+}
@@ -0,0 +1,130 @@
+package organizations_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/tests"
+)
+
+// organizationID returns the only organization of the user
+func organizationID(t *testing.T, ts *tests.TestServer, userID int32) int32 {
+	t.Helper()
+
+	memberships, err := ts.Queries.ListMembershipsForUser(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(memberships) != 1 {
+		t.Fatalf("expected one organization; got %d", len(memberships))
+	}
+
+	return memberships[0].ID
+}
+
+func TestOrganizations(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ctx := context.Background()
+
+	owner, ownerUser := ts.CreateAndLoginUser(t, "Owner", "owner@example.com", "Password123!")
+	other, otherUser := ts.CreateAndLoginUser(t, "Other", "other@example.com", "Password123!")
+
+	t.Run("projects require an organization", func(t *testing.T) {
+		status, headers, _ := ts.GetWithClient(t, owner, "/projects")
+		tests.AssertRedirect(t, status, headers, "/organizations/new")
+	})
+
+	t.Run("create an organization", func(t *testing.T) {
+		status, _, body := ts.PostFormWithClient(t, owner, "/organizations", map[string]string{"name": ""})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "This field cannot be blank")
+
+		status, headers, _ := ts.PostFormWithClient(t, owner, "/organizations", map[string]string{"name": "Acme"})
+		tests.AssertRedirect(t, status, headers, "/projects")
+
+		status, _, body = ts.GetWithClient(t, owner, "/projects")
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Acme")
+
+		status, headers, _ = ts.PostFormWithClient(t, other, "/organizations", map[string]string{"name": "Globex"})
+		tests.AssertRedirect(t, status, headers, "/projects")
+	})
+
+	acmeID := organizationID(t, ts, ownerUser.ID)
+	globexID := organizationID(t, ts, otherUser.ID)
+
+	t.Run("projects are scoped to the organization", func(t *testing.T) {
+		status, _, body := ts.PostFormWithClient(t, owner, "/projects", map[string]string{
+			"name":        "Rocket",
+			"description": "Road runner",
+		})
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Rocket")
+
+		_, _, body = ts.GetWithClient(t, other, "/projects")
+		tests.AssertNotContains(t, body, "Rocket")
+	})
+
+	projects, err := ts.Queries.ListProjects(ctx, acmeID)
+	if err != nil || len(projects) != 1 {
+		t.Fatalf("expected the project of Acme; got %v, %v", projects, err)
+	}
+	deleteURL := fmt.Sprintf("/projects/%d/delete", projects[0].ID)
+
+	t.Run("projects of another organization are not found", func(t *testing.T) {
+		status, _, _ := ts.PostFormWithClient(t, other, deleteURL, nil)
+		tests.AssertStatus(t, status, http.StatusNotFound)
+	})
+
+	t.Run("switching to an organization of someone else fails", func(t *testing.T) {
+		status, _, _ := ts.PostFormWithClient(t, other, "/organizations/switch", map[string]string{
+			"organization_id": fmt.Sprintf("%d", acmeID),
+		})
+		tests.AssertStatus(t, status, http.StatusNotFound)
+	})
+
+	err = ts.Queries.CreateMembership(ctx, queries.CreateMembershipParams{
+		OrganizationID: acmeID,
+		UserID:         otherUser.ID,
+		Role:           config.OrgRoleMember,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("members switch organizations", func(t *testing.T) {
+		status, headers, _ := ts.PostFormWithClient(t, other, "/organizations/switch", map[string]string{
+			"organization_id": fmt.Sprintf("%d", acmeID),
+			"next":            "/projects",
+		})
+		tests.AssertRedirect(t, status, headers, "/projects")
+
+		_, _, body := ts.GetWithClient(t, other, "/projects")
+		tests.AssertContains(t, body, "Rocket")
+	})
+
+	t.Run("members can't delete projects", func(t *testing.T) {
+		status, _, _ := ts.PostFormWithClient(t, other, deleteURL, nil)
+		tests.AssertStatus(t, status, http.StatusForbidden)
+	})
+
+	t.Run("owners delete projects", func(t *testing.T) {
+		status, _, body := ts.PostFormWithClient(t, owner, deleteURL, nil)
+		tests.AssertStatus(t, status, http.StatusOK)
+		tests.AssertContains(t, body, "Project deleted.")
+	})
+
+	t.Run("switching back", func(t *testing.T) {
+		status, headers, _ := ts.PostFormWithClient(t, other, "/organizations/switch", map[string]string{
+			"organization_id": fmt.Sprintf("%d", globexID),
+			"next":            "https://evil.example.com",
+		})
+		tests.AssertRedirect(t, status, headers, "/dashboard")
+	})
+}
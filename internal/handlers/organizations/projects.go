@@ -0,0 +1,110 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"go-web-starter/cmd/web/views"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/forms/validator"
+	"go-web-starter/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/angelofallars/htmx-go"
+	"github.com/go-chi/chi/v5"
+)
+
+func (oh *OrganizationHandler) ProjectsViewHandler(w http.ResponseWriter, r *http.Request) {
+	data := oh.handler.NewTemplateData(r)
+	data.PageTitle = "Projects"
+
+	projects, err := oh.tenant(r).ListProjects(r.Context())
+	if err != nil {
+		oh.handler.ServerError(w, err)
+		return
+	}
+
+	views.ProjectView(data, projects, forms.ProjectForm{}).Render(r.Context(), w)
+}
+
+func (oh *OrganizationHandler) CreateProjectHandler(w http.ResponseWriter, r *http.Request) {
+	var form forms.ProjectForm
+
+	data := oh.handler.NewTemplateData(r)
+	tenant := oh.tenant(r)
+
+	err := oh.handler.DecodePostForm(r, &form)
+	if err != nil {
+		form.SetMessage("Invalid form data", forms.MessageTypeError)
+		oh.renderProjects(w, r, tenant, form)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Name, 100), "name", "This field cannot be more than 100 characters long")
+	form.CheckField(validator.MaxChars(form.Description, 500), "description", "This field cannot be more than 500 characters long")
+
+	if !form.Valid() {
+		oh.renderProjects(w, r, tenant, form)
+		return
+	}
+
+	_, err = tenant.CreateProject(r.Context(), data.User.ID, form.Name, form.Description)
+	if err != nil {
+		oh.handler.Logger.PrintError(err, map[string]string{
+			"organization_id": fmt.Sprintf("%d", tenant.Membership.OrganizationID),
+		})
+		form.SetMessage("Failed to create the project. Please try again.", forms.MessageTypeError)
+		oh.renderProjects(w, r, tenant, form)
+		return
+	}
+
+	form = forms.ProjectForm{}
+	form.SetMessage("Project created.", forms.MessageTypeSuccess)
+	oh.renderProjects(w, r, tenant, form)
+}
+
+// DeleteProjectHandler deletes a project of the current organization. Members
+// can create projects, deleting them takes an admin.
+func (oh *OrganizationHandler) DeleteProjectHandler(w http.ResponseWriter, r *http.Request) {
+	tenant := oh.tenant(r)
+
+	if !tenant.Membership.HasRole(config.OrgRoleAdmin) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = tenant.DeleteProject(r.Context(), int32(id))
+	if errors.Is(err, service.ErrProjectNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		oh.handler.ServerError(w, err)
+		return
+	}
+
+	var form forms.ProjectForm
+	form.SetMessage("Project deleted.", forms.MessageTypeSuccess)
+	oh.renderProjects(w, r, tenant, form)
+}
+
+// renderProjects re-renders the project list of the tenant into #projects
+func (oh *OrganizationHandler) renderProjects(w http.ResponseWriter, r *http.Request, tenant *service.Tenant, form forms.ProjectForm) {
+	data := oh.handler.NewTemplateData(r)
+
+	projects, err := tenant.ListProjects(r.Context())
+	if err != nil {
+		oh.handler.ServerError(w, err)
+		return
+	}
+
+	htmx.NewResponse().RenderTempl(r.Context(), w, views.ProjectsSection(data, projects, form))
+}
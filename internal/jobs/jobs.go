@@ -0,0 +1,95 @@
+// Package jobs runs background work from the jobs table. Jobs are enqueued with
+// Enqueue, within the transaction of the work that caused them when needed, and
+// are picked by the workers with FOR UPDATE SKIP LOCKED so that any number of
+// them can share the table. Failed jobs are retried with exponential backoff
+// until they run out of attempts and are moved to the dead letters.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"go-web-starter/internal/queries"
+	"time"
+)
+
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	// StatusDead is the dead letter state of jobs that ran out of attempts
+	StatusDead = "dead"
+)
+
+// DefaultMaxAttempts is how often a job runs before it is dead, unless enqueued
+// with MaxAttempts
+const DefaultMaxAttempts = 5
+
+const (
+	// backoffBase is the delay before the first retry, every further retry waits twice as long
+	backoffBase = 30 * time.Second
+	backoffMax  = time.Hour
+)
+
+// Job is the payload of a job, it is stored as JSON. Kind names the handler and
+// must be unique.
+type Job interface {
+	Kind() string
+}
+
+type options struct {
+	delay       time.Duration
+	maxAttempts int32
+}
+
+type Option func(*options)
+
+// Delay runs the job d from now instead of right away
+func Delay(d time.Duration) Option {
+	return func(o *options) {
+		o.delay = d
+	}
+}
+
+// MaxAttempts overrides DefaultMaxAttempts
+func MaxAttempts(n int) Option {
+	return func(o *options) {
+		o.maxAttempts = int32(max(n, 1))
+	}
+}
+
+// Enqueue stores the job for the workers. To only run the job when a transaction
+// commits, pass the queries of the transaction:
+//
+//	dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+//		qtx := dbQueries.WithTx(tx)
+//		...
+//		return jobs.Enqueue(ctx, qtx, SomeJob{...})
+//	})
+func Enqueue(ctx context.Context, q *queries.Queries, job Job, opts ...Option) (queries.Job, error) {
+	o := options{maxAttempts: DefaultMaxAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return queries.Job{}, err
+	}
+
+	return q.EnqueueJob(ctx, queries.EnqueueJobParams{
+		Kind:        job.Kind(),
+		Payload:     payload,
+		MaxAttempts: o.maxAttempts,
+		RunAt:       time.Now().Add(o.delay),
+	})
+}
+
+// backoff is the delay before the retry following the given attempt
+func backoff(attempt int32) time.Duration {
+	d := backoffBase
+	for i := int32(1); i < attempt && d < backoffMax; i++ {
+		d *= 2
+	}
+
+	return min(d, backoffMax)
+}
@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	testCases := []struct {
+		attempt int32
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tc := range testCases {
+		if got := backoff(tc.attempt); got != tc.want {
+			t.Errorf("backoff(%d) = %v; want %v", tc.attempt, got, tc.want)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	err := errors.New("unavailable")
+
+	testCases := []struct {
+		name    string
+		err     error
+		attempt int32
+		want    bool
+	}{
+		{"attempts left", err, 1, true},
+		{"last attempt", err, 3, false},
+		{"permanent", Permanent(err), 1, false},
+		{"wrapped permanent", fmt.Errorf("send: %w", Permanent(err)), 1, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			at, ok := Retry(tc.err, tc.attempt, 3)
+			if ok != tc.want {
+				t.Fatalf("Retry() = %v; want %v", ok, tc.want)
+			}
+			if ok && time.Until(at) < backoff(tc.attempt)-time.Second {
+				t.Errorf("expected the retry after the backoff; got %v", at)
+			}
+		})
+	}
+}
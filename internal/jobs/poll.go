@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/jsonlog"
+	"sync"
+	"time"
+)
+
+// statusTimeout is how long recording the outcome of an attempt may take
+const statusTimeout = 10 * time.Second
+
+// NextFunc processes the next item of a queue that is due, if there is one. The
+// error is about the queue, failing items are retried or given up on.
+type NextFunc func(ctx context.Context) (bool, error)
+
+// Poll starts config.Workers goroutines that call next and blocks until ctx is
+// done and the items they are processing are finished. A goroutine waits for
+// config.PollInterval when there is nothing to do or next fails, errors are
+// logged for the component.
+func Poll(ctx context.Context, cfg config.Jobs, logger *jsonlog.Logger, component string, next NextFunc) {
+	var wg sync.WaitGroup
+
+	for range max(cfg.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			poll(ctx, cfg, logger, component, next)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func poll(ctx context.Context, cfg config.Jobs, logger *jsonlog.Logger, component string, next NextFunc) {
+	for ctx.Err() == nil {
+		found, err := next(ctx)
+		if err != nil {
+			logger.PrintError(err, map[string]string{"component": component})
+		}
+		if found && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(cfg.PollInterval):
+		}
+	}
+}
+
+// Drain calls next until there is nothing left to do and returns how many items
+// were processed
+func Drain(ctx context.Context, next NextFunc) (int, error) {
+	count := 0
+	for {
+		found, err := next(ctx)
+		if err != nil || !found {
+			return count, err
+		}
+		count++
+	}
+}
+
+// StaleBefore is the time before which a claimed item is considered abandoned
+// by a worker that died. It leaves the item time to finish past its timeout.
+func StaleBefore(cfg config.Jobs) time.Time {
+	return time.Now().Add(-2 * cfg.Timeout)
+}
+
+// AttemptContext is the context of an attempt. An attempt that is running when
+// ctx is done is finished, unless it runs past its timeout.
+func AttemptContext(ctx context.Context, cfg config.Jobs) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), cfg.Timeout)
+}
+
+// StatusContext is the context of the writes that record the outcome of an
+// attempt. It isn't cancelled with ctx and has a timeout of its own, so that an
+// attempt that ran into its timeout or was stopped is still recorded.
+func StatusContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), statusTimeout)
+}
+
+// Retry returns when to retry an item after the given attempt failed with err.
+// It returns false when the item must be given up on because err is permanent
+// or it was the last attempt.
+func Retry(err error, attempt, maxAttempts int32) (time.Time, bool) {
+	var permanent permanentError
+	if errors.As(err, &permanent) || attempt >= maxAttempts {
+		return time.Time{}, false
+	}
+
+	return time.Now().Add(backoff(attempt)), true
+}
+
+// Permanent marks err so that the attempt that failed with it isn't retried
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/jsonlog"
+	"go-web-starter/internal/queries"
+	"strconv"
+)
+
+type handlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// Worker runs the jobs of the kinds registered with Handle
+type Worker struct {
+	queries  *queries.Queries
+	logger   *jsonlog.Logger
+	config   config.Jobs
+	handlers map[string]handlerFunc
+}
+
+func NewWorker(q *queries.Queries, logger *jsonlog.Logger, cfg config.Jobs) *Worker {
+	return &Worker{
+		queries:  q,
+		logger:   logger,
+		config:   cfg,
+		handlers: map[string]handlerFunc{},
+	}
+}
+
+// Handle registers the handler of the jobs of type T. A job is retried when the
+// handler returns an error or panics.
+func Handle[T Job](w *Worker, handler func(ctx context.Context, job T) error) {
+	var zero T
+
+	w.handlers[zero.Kind()] = func(ctx context.Context, payload json.RawMessage) error {
+		var job T
+		if err := json.Unmarshal(payload, &job); err != nil {
+			return Permanent(fmt.Errorf("decode payload: %w", err))
+		}
+
+		return handler(ctx, job)
+	}
+}
+
+// Run starts config.Workers workers and blocks until ctx is done and the jobs
+// they are running are finished.
+func (w *Worker) Run(ctx context.Context) {
+	Poll(ctx, w.config, w.logger, "jobs", w.RunNext)
+}
+
+// RunPending runs the jobs that are due until there are none left and returns
+// how many ran
+func (w *Worker) RunPending(ctx context.Context) (int, error) {
+	return Drain(ctx, w.RunNext)
+}
+
+// RunNext runs the next job that is due, if there is one. The error is about
+// the queue, failing jobs are retried or moved to the dead letters.
+func (w *Worker) RunNext(ctx context.Context) (bool, error) {
+	job, err := w.queries.ClaimJob(ctx, StaleBefore(w.config))
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// a job that was running when its worker stopped may have no attempts left
+	if job.Attempts > job.MaxAttempts {
+		return true, w.kill(ctx, job, errors.New("the worker stopped during the last attempt"))
+	}
+
+	// a running job is finished when the worker shuts down
+	runCtx, cancel := AttemptContext(ctx, w.config)
+	defer cancel()
+
+	jobErr := w.run(runCtx, job)
+
+	statusCtx, cancelStatus := StatusContext(ctx)
+	defer cancelStatus()
+
+	if jobErr == nil {
+		return true, w.queries.CompleteJob(statusCtx, job.ID)
+	}
+
+	runAt, ok := Retry(jobErr, job.Attempts, job.MaxAttempts)
+	if !ok {
+		return true, w.kill(statusCtx, job, jobErr)
+	}
+
+	return true, w.queries.RetryJob(statusCtx, queries.RetryJobParams{
+		ID:        job.ID,
+		RunAt:     runAt,
+		LastError: jobErr.Error(),
+	})
+}
+
+func (w *Worker) run(ctx context.Context, job queries.Job) (err error) {
+	handler, ok := w.handlers[job.Kind]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for jobs of kind %q", job.Kind))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler(ctx, job.Payload)
+}
+
+// kill moves the job to the dead letters
+func (w *Worker) kill(ctx context.Context, job queries.Job, jobErr error) error {
+	w.logger.PrintError(jobErr, map[string]string{
+		"component": "jobs",
+		"job_id":    strconv.FormatInt(job.ID, 10),
+		"kind":      job.Kind,
+		"attempts":  strconv.Itoa(int(job.Attempts)),
+	})
+
+	return w.queries.KillJob(ctx, queries.KillJobParams{
+		ID:        job.ID,
+		LastError: jobErr.Error(),
+	})
+}
@@ -0,0 +1,153 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"go-web-starter/internal/jobs"
+	"go-web-starter/internal/jsonlog"
+	"go-web-starter/internal/tests"
+	"io"
+	"testing"
+	"time"
+)
+
+type testJob struct {
+	Value string `json:"value"`
+}
+
+func (testJob) Kind() string {
+	return "test"
+}
+
+func TestWorker(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ctx := context.Background()
+	cfg := ts.Config.Jobs
+	cfg.Timeout = time.Minute
+
+	var got []string
+	fail := errors.New("unavailable")
+	worker := jobs.NewWorker(ts.Queries, jsonlog.New(io.Discard, jsonlog.LevelInfo), cfg)
+	jobs.Handle(worker, func(ctx context.Context, job testJob) error {
+		got = append(got, job.Value)
+		if job.Value == "failing" {
+			return fail
+		}
+		return nil
+	})
+
+	t.Run("runs due jobs", func(t *testing.T) {
+		job, err := jobs.Enqueue(ctx, ts.Queries, testJob{Value: "ok"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = jobs.Enqueue(ctx, ts.Queries, testJob{Value: "later"}, jobs.Delay(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		count, err := worker.RunPending(ctx)
+		if err != nil || count != 1 {
+			t.Fatalf("expected 1 job to run; got %d, %v", count, err)
+		}
+		if len(got) != 1 || got[0] != "ok" {
+			t.Errorf("unexpected payloads %v", got)
+		}
+
+		job, err = ts.Queries.GetJob(ctx, job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != jobs.StatusDone || job.Attempts != 1 || !job.FinishedAt.Valid {
+			t.Errorf("expected the job to be done; got %+v", job)
+		}
+	})
+
+	t.Run("retries failed jobs until they are dead", func(t *testing.T) {
+		job, err := jobs.Enqueue(ctx, ts.Queries, testJob{Value: "failing"}, jobs.MaxAttempts(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := worker.RunPending(ctx); err != nil {
+			t.Fatal(err)
+		}
+		job, err = ts.Queries.GetJob(ctx, job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != jobs.StatusPending || job.LastError != fail.Error() || job.RunAt.Before(time.Now()) {
+			t.Fatalf("expected the job to be retried later; got %+v", job)
+		}
+
+		// make the retry due
+		_, err = ts.DB.ExecContext(ctx, "UPDATE jobs SET run_at = now() WHERE id = $1", job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := worker.RunPending(ctx); err != nil {
+			t.Fatal(err)
+		}
+		job, err = ts.Queries.GetJob(ctx, job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != jobs.StatusDead || job.Attempts != 2 {
+			t.Errorf("expected the job to be dead; got %+v", job)
+		}
+	})
+
+	t.Run("jobs that time out are retried", func(t *testing.T) {
+		slowCfg := cfg
+		slowCfg.Timeout = 50 * time.Millisecond
+
+		slow := jobs.NewWorker(ts.Queries, jsonlog.New(io.Discard, jsonlog.LevelInfo), slowCfg)
+		jobs.Handle(slow, func(ctx context.Context, job testJob) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		job, err := jobs.Enqueue(ctx, ts.Queries, testJob{Value: "slow"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := slow.RunNext(ctx); err != nil {
+			t.Fatal(err)
+		}
+		job, err = ts.Queries.GetJob(ctx, job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != jobs.StatusPending || job.LastError != context.DeadlineExceeded.Error() {
+			t.Errorf("expected the job to be retried after its timeout; got %+v", job)
+		}
+	})
+
+	t.Run("jobs without handler are dead", func(t *testing.T) {
+		job, err := jobs.Enqueue(ctx, ts.Queries, unknownJob{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := worker.RunPending(ctx); err != nil {
+			t.Fatal(err)
+		}
+		job, err = ts.Queries.GetJob(ctx, job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != jobs.StatusDead || job.Attempts != 1 {
+			t.Errorf("expected the job to be dead; got %+v", job)
+		}
+	})
+}
+
+type unknownJob struct{}
+
+func (unknownJob) Kind() string {
+	return "unknown"
+}
@@ -0,0 +1,215 @@
+package mailer
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-web-starter/internal/config"
+	"reflect"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+// Email is the typed content of an email. The content of the Body component is
+// put in the layout of the app, and the plain text alternative is generated from
+// the HTML.
+type Email interface {
+	Subject() string
+	Body() templ.Component
+}
+
+// Brand is the app the emails are sent for, shown in the layout of every email
+type Brand struct {
+	Name string
+	// URL is the base URL of the app, e.g. https://example.com
+	URL string
+}
+
+// NewBrand returns the brand of the configured app
+func NewBrand(cfg config.Config) Brand {
+	return Brand{
+		Name: cfg.AppName,
+		URL:  cfg.AppURL,
+	}
+}
+
+// Name returns the name of the email type, e.g. "PasswordResetEmail"
+func Name(email Email) string {
+	t := reflect.TypeOf(email)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t.Name()
+}
+
+// emails are all emails of the package, to decode them by name
+var emails = []Email{
+	WelcomeEmail{},
+	ActivationEmail{},
+	LoginLinkEmail{},
+	PasswordResetEmail{},
+	PasswordResetConfirmationEmail{},
+	PasswordResetRequiredEmail{},
+	AccountLockedEmail{},
+	AccountLinkEmail{},
+	EmailChangeEmail{},
+	EmailChangeNoticeEmail{},
+	InvitationEmail{},
+}
+
+// decodeEmail decodes the JSON of the email with the name
+func decodeEmail(name string, data []byte) (Email, error) {
+	for _, email := range emails {
+		if Name(email) != name {
+			continue
+		}
+
+		decoded := reflect.New(reflect.TypeOf(email))
+		if err := json.Unmarshal(data, decoded.Interface()); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", name, err)
+		}
+		return decoded.Elem().Interface().(Email), nil
+	}
+
+	return nil, fmt.Errorf("unknown email %q", name)
+}
+
+// WelcomeEmail greets a user after signing up
+type WelcomeEmail struct {
+	Name string
+}
+
+func (e WelcomeEmail) Subject() string       { return "Welcome on board!" }
+func (e WelcomeEmail) Body() templ.Component { return welcomeBody(e) }
+
+// ActivationEmail asks a user to verify their email address
+type ActivationEmail struct {
+	Name      string
+	Link      string
+	ExpiresIn time.Duration
+}
+
+func (e ActivationEmail) Subject() string       { return "Verify your email address" }
+func (e ActivationEmail) Body() templ.Component { return activationBody(e) }
+
+// LoginLinkEmail holds a magic link to sign in without a password
+type LoginLinkEmail struct {
+	Name      string
+	Link      string
+	ExpiresIn time.Duration
+}
+
+func (e LoginLinkEmail) Subject() string       { return "Your sign-in link" }
+func (e LoginLinkEmail) Body() templ.Component { return loginLinkBody(e) }
+
+// PasswordResetEmail holds the link to choose a new password after "Forgot password"
+type PasswordResetEmail struct {
+	Link      string
+	ExpiresIn time.Duration
+}
+
+func (e PasswordResetEmail) Subject() string       { return "Reset your password" }
+func (e PasswordResetEmail) Body() templ.Component { return passwordResetBody(e) }
+
+// PasswordResetConfirmationEmail tells a user that their password was reset
+type PasswordResetConfirmationEmail struct {
+	LoginLink string
+}
+
+func (e PasswordResetConfirmationEmail) Subject() string { return "Password reset confirmation" }
+func (e PasswordResetConfirmationEmail) Body() templ.Component {
+	return passwordResetConfirmationBody(e)
+}
+
+// PasswordResetRequiredEmail asks a user to choose a new password after an admin
+// reset it
+type PasswordResetRequiredEmail struct {
+	Name      string
+	Link      string
+	ExpiresIn time.Duration
+}
+
+func (e PasswordResetRequiredEmail) Subject() string       { return "Please choose a new password" }
+func (e PasswordResetRequiredEmail) Body() templ.Component { return passwordResetRequiredBody(e) }
+
+// AccountLockedEmail tells a user that their account was locked after too many
+// failed logins. The password reset link unlocks it.
+type AccountLockedEmail struct {
+	Name              string
+	LockedFor         time.Duration
+	PasswordResetLink string
+}
+
+func (e AccountLockedEmail) Subject() string       { return "Your account has been locked" }
+func (e AccountLockedEmail) Body() templ.Component { return accountLockedBody(e) }
+
+// AccountLinkEmail asks a user to confirm connecting a social account that uses
+// their email address
+type AccountLinkEmail struct {
+	Name      string
+	Provider  string
+	Link      string
+	ExpiresIn time.Duration
+}
+
+func (e AccountLinkEmail) Subject() string {
+	return fmt.Sprintf("Confirm connecting your %s account", e.Provider)
+}
+func (e AccountLinkEmail) Body() templ.Component { return accountLinkBody(e) }
+
+// EmailChangeEmail is sent to the new address to confirm an email change
+type EmailChangeEmail struct {
+	Name      string
+	NewEmail  string
+	Link      string
+	ExpiresIn time.Duration
+}
+
+func (e EmailChangeEmail) Subject() string       { return "Confirm your new email address" }
+func (e EmailChangeEmail) Body() templ.Component { return emailChangeBody(e) }
+
+// EmailChangeNoticeEmail is sent to the current address of an email change, with
+// a link to cancel it
+type EmailChangeNoticeEmail struct {
+	Name       string
+	NewEmail   string
+	CancelLink string
+	ExpiresIn  time.Duration
+}
+
+func (e EmailChangeNoticeEmail) Subject() string       { return "Your email address is about to change" }
+func (e EmailChangeNoticeEmail) Body() templ.Component { return emailChangeNoticeBody(e) }
+
+// InvitationEmail invites someone to join an organization
+type InvitationEmail struct {
+	InviterName      string
+	OrganizationName string
+	Role             string
+	Link             string
+	ExpiresIn        time.Duration
+}
+
+func (e InvitationEmail) Subject() string {
+	return fmt.Sprintf("%s invited you to join %s", e.InviterName, e.OrganizationName)
+}
+func (e InvitationEmail) Body() templ.Component { return invitationBody(e) }
+
+// humanizeDuration formats a token ttl for use in emails, e.g. "3 days" or "45 minutes".
+func humanizeDuration(d time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return plural(int(d/(24*time.Hour)), "day")
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int(d/time.Hour), "hour")
+	default:
+		return plural(int(d.Round(time.Minute)/time.Minute), "minute")
+	}
+}
@@ -0,0 +1,83 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer writes emails as .eml files to a maildir, where mail clients and
+// editors can open them
+type FileMailer struct {
+	dir    string
+	sender string
+	brand  Brand
+}
+
+func NewFile(dir, sender string, brand Brand) FileMailer {
+	return FileMailer{
+		dir:    dir,
+		sender: sender,
+		brand:  brand,
+	}
+}
+
+func (m FileMailer) Send(recipient string, email Email) error {
+	return m.SendMessage(context.Background(), NewMessage(email).To(recipient))
+}
+
+func (m FileMailer) SendMessage(ctx context.Context, builder *MessageBuilder) error {
+	msg, err := Render(ctx, m.sender, m.brand, builder)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(m.dir, sub), 0o755); err != nil {
+			return err
+		}
+	}
+
+	name, err := m.uniqueName()
+	if err != nil {
+		return err
+	}
+
+	// like maildir delivery, the file only shows up in new once it is complete
+	tmpPath := filepath.Join(m.dir, "tmp", name)
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	_, err = msg.mailMessage().WriteTo(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, filepath.Join(m.dir, "new", name))
+}
+
+// uniqueName returns a maildir file name, which sorts by the time of sending
+func (m FileMailer) uniqueName() (string, error) {
+	random := make([]byte, 6)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+
+	now := time.Now()
+	return fmt.Sprintf("%d.M%06dP%d_%s.%s.eml", now.Unix(), now.Nanosecond()/1000, os.Getpid(), hex.EncodeToString(random), host), nil
+}
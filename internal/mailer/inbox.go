@@ -0,0 +1,176 @@
+package mailer
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Inbox is implemented by the drivers that keep the emails they send, so that
+// they can be looked at during development
+type Inbox interface {
+	// Inbox returns the sent emails, oldest first
+	Inbox() ([]Message, error)
+}
+
+func (m *MemoryMailer) Inbox() ([]Message, error) {
+	return m.Messages(), nil
+}
+
+// Inbox reads the emails of the maildir, new and read ones. Emails written by
+// others are included, as far as they can be read.
+func (m FileMailer) Inbox() ([]Message, error) {
+	var paths []string
+	for _, sub := range []string{"new", "cur"} {
+		matches, err := filepath.Glob(filepath.Join(m.dir, sub, "*"))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+
+	// the names start with the time of sending
+	slices.SortFunc(paths, func(a, b string) int {
+		return strings.Compare(filepath.Base(a), filepath.Base(b))
+	})
+
+	messages := make([]Message, 0, len(paths))
+	for _, path := range paths {
+		msg, err := readMessage(path)
+		if err != nil {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+// readMessage parses an email written by mailMessage
+func readMessage(path string) (Message, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Message{}, err
+	}
+	defer file.Close()
+
+	parsed, err := mail.ReadMessage(file)
+	if err != nil {
+		return Message{}, err
+	}
+
+	decoder := new(mime.WordDecoder)
+	header := func(key string) string {
+		value := parsed.Header.Get(key)
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			return decoded
+		}
+		return value
+	}
+
+	addresses := func(key string) []string {
+		list, err := parsed.Header.AddressList(key)
+		if err != nil {
+			return nil
+		}
+
+		var addresses []string
+		for _, address := range list {
+			if address.Name == "" {
+				addresses = append(addresses, address.Address)
+			} else {
+				addresses = append(addresses, address.String())
+			}
+		}
+		return addresses
+	}
+
+	msg := Message{
+		Sender:  header("From"),
+		To:      addresses("To"),
+		CC:      addresses("Cc"),
+		ReplyTo: header("Reply-To"),
+		Headers: make(map[string]string),
+		Subject: header("Subject"),
+	}
+	msg.SentAt, _ = parsed.Header.Date()
+
+	for key := range parsed.Header {
+		if !slices.Contains(reservedHeaders, key) {
+			msg.Headers[key] = header(key)
+		}
+	}
+
+	err = readBody(&msg, parsed.Header, parsed.Body)
+
+	return msg, err
+}
+
+// readBody sets the plain and HTML body or adds an attachment to the message
+// from a part of the email with header
+func readBody(msg *Message, header interface{ Get(key string) string }, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			err = readBody(msg, part.Header, part)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	if disposition == "attachment" {
+		msg.Attachments = append(msg.Attachments, Attachment{
+			Filename:    dispositionParams["filename"],
+			ContentType: mediaType,
+			Content:     content,
+		})
+		return nil
+	}
+
+	// the lines of emails end with CRLF
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+
+	switch mediaType {
+	case "text/plain":
+		msg.PlainBody = text
+	case "text/html":
+		msg.HTMLBody = text
+	}
+
+	return nil
+}
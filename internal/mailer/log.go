@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"context"
+	"go-web-starter/internal/jsonlog"
+	"strings"
+)
+
+// LogMailer prints emails to the log instead of sending them, e.g. for local
+// development without an SMTP server
+type LogMailer struct {
+	logger *jsonlog.Logger
+	sender string
+	brand  Brand
+}
+
+func NewLog(logger *jsonlog.Logger, sender string, brand Brand) LogMailer {
+	return LogMailer{
+		logger: logger,
+		sender: sender,
+		brand:  brand,
+	}
+}
+
+func (m LogMailer) Send(recipient string, email Email) error {
+	return m.SendMessage(context.Background(), NewMessage(email).To(recipient))
+}
+
+func (m LogMailer) SendMessage(ctx context.Context, builder *MessageBuilder) error {
+	msg, err := Render(ctx, m.sender, m.brand, builder)
+	if err != nil {
+		return err
+	}
+
+	properties := map[string]string{
+		"to":      msg.Recipient(),
+		"from":    msg.Sender,
+		"subject": msg.Subject,
+		"email":   Name(msg.Email),
+		"body":    msg.PlainBody,
+	}
+	if len(msg.CC) > 0 {
+		properties["cc"] = strings.Join(msg.CC, ", ")
+	}
+	if len(msg.BCC) > 0 {
+		properties["bcc"] = strings.Join(msg.BCC, ", ")
+	}
+	if msg.ReplyTo != "" {
+		properties["reply_to"] = msg.ReplyTo
+	}
+	if len(msg.Attachments) > 0 {
+		var names []string
+		for _, attachment := range msg.Attachments {
+			names = append(names, attachment.Filename)
+		}
+		properties["attachments"] = strings.Join(names, ", ")
+	}
+
+	m.logger.PrintInfo("email", properties)
+
+	return nil
+}
@@ -2,77 +2,144 @@ package mailer
 
 import (
 	"bytes"
-	"embed"
+	"context"
+	"fmt"
 	"go-web-starter/internal/config"
-	"html/template"
+	"go-web-starter/internal/jsonlog"
+	"io"
+	"maps"
+	"strings"
 	"time"
 
+	"github.com/a-h/templ"
 	"github.com/go-mail/mail/v2"
 )
 
-//go:embed "templates"
-var templateFS embed.FS
-
 type Mailer interface {
-	Send(recipient, templateFile string, data interface{}) error
+	// SendMessage renders and sends the message. Sending is abandoned when ctx
+	// is done.
+	SendMessage(ctx context.Context, msg *MessageBuilder) error
+	// Send sends the email to a single recipient, without a context. It is kept
+	// for the existing callers of the Mailer, new code uses SendMessage.
+	Send(recipient string, email Email) error
 }
 
-type AppMailer struct {
-	dialer *mail.Dialer
-	sender string
+// Message is a rendered email
+type Message struct {
+	Sender  string
+	To      []string
+	CC      []string
+	BCC     []string
+	ReplyTo string
+	// Headers are the custom headers, e.g. List-Unsubscribe
+	Headers     map[string]string
+	Attachments []Attachment
+	Subject     string
+	PlainBody   string
+	HTMLBody    string
+	SentAt      time.Time
+	// Email is what the message was rendered from, nil when it was read back
+	// from a maildir
+	Email Email
 }
 
-func New(smtp config.SMTP) AppMailer {
-	dialer := mail.NewDialer(smtp.Host, smtp.Port, smtp.Username, smtp.Password)
-	dialer.Timeout = 5 * time.Second
+// Recipient returns the To addresses, as in the To header
+func (m Message) Recipient() string {
+	return strings.Join(m.To, ", ")
+}
 
-	return AppMailer{
-		dialer: dialer,
-		sender: smtp.Sender,
+// New returns the mailer of the configured driver. The emails are sent for brand.
+func New(smtp config.SMTP, brand Brand, logger *jsonlog.Logger) (Mailer, error) {
+	switch smtp.Driver {
+	case config.MailDriverSMTP, "":
+		return NewSMTP(smtp, brand), nil
+	case config.MailDriverFile:
+		return NewFile(smtp.Dir, smtp.Sender, brand), nil
+	case config.MailDriverLog:
+		return NewLog(logger, smtp.Sender, brand), nil
+	case config.MailDriverMemory:
+		return NewMemory(smtp.Sender, brand), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", smtp.Driver)
 	}
-
 }
 
-func (m AppMailer) Send(recipient, templateFile string, data interface{}) error {
-	tmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
-	if err != nil {
-		return err
+// Render renders the email of the message in the layout of brand, and its plain
+// text alternative. The message is sent by sender unless it sets another one.
+func Render(ctx context.Context, sender string, brand Brand, msg *MessageBuilder) (Message, error) {
+	if err := ctx.Err(); err != nil {
+		return Message{}, err
 	}
+	if err := msg.err(); err != nil {
+		return Message{}, err
+	}
+
+	subject := msg.email.Subject()
 
-	subject := new(bytes.Buffer)
-	err = tmpl.ExecuteTemplate(subject, "subject", data)
+	htmlBody := new(bytes.Buffer)
+	err := layout(brand, subject).Render(templ.WithChildren(ctx, msg.email.Body()), htmlBody)
 	if err != nil {
-		return err
+		return Message{}, err
 	}
 
-	plainBody := new(bytes.Buffer)
-	err = tmpl.ExecuteTemplate(plainBody, "plainBody", data)
+	plainBody, err := PlainText(htmlBody.String())
 	if err != nil {
-		return err
+		return Message{}, err
 	}
 
-	htmlBody := new(bytes.Buffer)
-	err = tmpl.ExecuteTemplate(htmlBody, "htmlBody", data)
-	if err != nil {
-		return err
+	if msg.sender != "" {
+		sender = msg.sender
 	}
 
-	// Mail
-	msg := mail.NewMessage()
-	msg.SetHeader("To", recipient)
-	msg.SetHeader("From", m.sender)
-	msg.SetHeader("Subject", subject.String())
-	msg.SetBody("text/plain", plainBody.String())
-	msg.AddAlternative("text/html", htmlBody.String())
+	return Message{
+		Sender:      sender,
+		To:          msg.to,
+		CC:          msg.cc,
+		BCC:         msg.bcc,
+		ReplyTo:     msg.replyTo,
+		Headers:     maps.Clone(msg.headers),
+		Attachments: msg.attachments,
+		Subject:     subject,
+		PlainBody:   plainBody,
+		HTMLBody:    htmlBody.String(),
+		SentAt:      time.Now(),
+		Email:       msg.email,
+	}, nil
+}
 
-	// Call the DialAndSend() method on the dialer, passing in the message to send. This
-	// opens a connection to the SMTP server, sends the message, then closes the
-	// connection. If there is a timeout, it will return a "dial tcp: i/o timeout" error.
+// mailMessage builds the MIME message, with the HTML body as alternative to the plain one
+func (m Message) mailMessage() *mail.Message {
+	msg := mail.NewMessage()
+	msg.SetHeader("From", m.Sender)
+	for field, addresses := range map[string][]string{"To": m.To, "Cc": m.CC, "Bcc": m.BCC} {
+		if len(addresses) > 0 {
+			msg.SetHeader(field, addresses...)
+		}
+	}
+	if m.ReplyTo != "" {
+		msg.SetHeader("Reply-To", m.ReplyTo)
+	}
+	msg.SetHeader("Subject", m.Subject)
+	msg.SetDateHeader("Date", m.SentAt)
+	for name, value := range m.Headers {
+		msg.SetHeader(name, value)
+	}
 
-	err = m.dialer.DialAndSend(msg)
-	if err != nil {
-		return err
+	msg.SetBody("text/plain", m.PlainBody)
+	msg.AddAlternative("text/html", m.HTMLBody)
+
+	for _, attachment := range m.Attachments {
+		settings := []mail.FileSetting{
+			mail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(attachment.Content)
+				return err
+			}),
+		}
+		if attachment.ContentType != "" {
+			settings = append(settings, mail.SetHeader(map[string][]string{"Content-Type": {attachment.ContentType}}))
+		}
+		msg.Attach(attachment.Filename, settings...)
 	}
 
-	return nil
+	return msg
 }
@@ -0,0 +1,440 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/jsonlog"
+	"io"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a-h/templ"
+)
+
+var (
+	brand   = Brand{Name: "Acme App", URL: "https://app.example.com"}
+	welcome = WelcomeEmail{Name: "Jane"}
+)
+
+// brokenEmail fails to render
+type brokenEmail struct{}
+
+func (brokenEmail) Subject() string { return "Broken" }
+func (brokenEmail) Body() templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		return errors.New("broken")
+	})
+}
+
+func TestRender(t *testing.T) {
+	msg, err := Render(context.Background(), "app@example.com", brand, NewMessage(welcome).To("jane@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.Recipient() != "jane@example.com" || msg.Sender != "app@example.com" || msg.Email != welcome {
+		t.Errorf("unexpected message %+v", msg)
+	}
+	if msg.Subject != "Welcome on board!" || !strings.Contains(msg.PlainBody, "Hi Jane,") || !strings.Contains(msg.HTMLBody, "Hi Jane,") {
+		t.Errorf("expected the rendered subject and bodies; got %+v", msg)
+	}
+	// the layout shares the branding
+	for _, body := range []string{msg.PlainBody, msg.HTMLBody} {
+		if !strings.Contains(body, "Acme App") || !strings.Contains(body, "https://app.example.com") {
+			t.Errorf("expected the brand in the body:\n%s", body)
+		}
+	}
+
+	if _, err := Render(context.Background(), "app@example.com", brand, NewMessage(brokenEmail{}).To("jane@example.com")); err == nil {
+		t.Error("expected the error of the body")
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	email := ActivationEmail{Name: "Jane <Doe>", Link: "https://app.example.com/activate?token=abc&x=1", ExpiresIn: 3 * 24 * time.Hour}
+	msg, err := Render(context.Background(), "app@example.com", brand, NewMessage(email).To("jane@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `Acme App
+
+Hi Jane <Doe>,
+
+Please click on the below link to verify your email address and activate your account:
+
+https://app.example.com/activate?token=abc&x=1
+
+Please note that this is a one-time use link and it will expire in 3 days. If you did not create an account, you can safely ignore this email.
+
+Thanks
+
+Sent by Acme App
+https://app.example.com
+`
+	if msg.PlainBody != want {
+		t.Errorf("expected the plain text\n%s\ngot\n%s", want, msg.PlainBody)
+	}
+	if strings.Contains(msg.HTMLBody, "Jane <Doe>") {
+		t.Error("expected the HTML to be escaped")
+	}
+}
+
+func TestHumanizeDuration(t *testing.T) {
+	testCases := []struct {
+		d    time.Duration
+		want string
+	}{
+		{3 * 24 * time.Hour, "3 days"},
+		{24 * time.Hour, "1 day"},
+		{36 * time.Hour, "36 hours"},
+		{time.Hour, "1 hour"},
+		{45 * time.Minute, "45 minutes"},
+		{90 * time.Second, "2 minutes"},
+	}
+
+	for _, tc := range testCases {
+		if got := humanizeDuration(tc.d); got != tc.want {
+			t.Errorf("%s: expected %q; got %q", tc.d, tc.want, got)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	logger := jsonlog.New(io.Discard, jsonlog.LevelInfo)
+
+	testCases := []struct {
+		driver string
+		want   string
+	}{
+		{"", "mailer.SMTPMailer"},
+		{config.MailDriverSMTP, "mailer.SMTPMailer"},
+		{config.MailDriverFile, "mailer.FileMailer"},
+		{config.MailDriverLog, "mailer.LogMailer"},
+		{config.MailDriverMemory, "*mailer.MemoryMailer"},
+	}
+
+	for _, tc := range testCases {
+		m, err := New(config.SMTP{Driver: tc.driver}, brand, logger)
+		if err != nil {
+			t.Fatalf("driver %q: %v", tc.driver, err)
+		}
+		if got := fmt.Sprintf("%T", m); got != tc.want {
+			t.Errorf("driver %q: expected %s; got %s", tc.driver, tc.want, got)
+		}
+	}
+
+	if _, err := New(config.SMTP{Driver: "carrier-pigeon"}, brand, logger); err == nil {
+		t.Error("expected an error for an unknown driver")
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m := NewFile(dir, "app@example.com", brand)
+
+	for range 2 {
+		if err := m.Send("jane@example.com", welcome); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "new", "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 emails in the maildir; got %v", files)
+	}
+
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"To: jane@example.com", "From: app@example.com", "text/plain", "text/html"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected the email to contain %q:\n%s", want, content)
+		}
+	}
+
+	if tmp, _ := os.ReadDir(filepath.Join(dir, "tmp")); len(tmp) != 0 {
+		t.Errorf("expected no leftovers in tmp; got %v", tmp)
+	}
+
+	inbox, err := m.Inbox()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inbox) != 2 {
+		t.Fatalf("expected the 2 emails in the inbox; got %d", len(inbox))
+	}
+
+	want, err := Render(context.Background(), "app@example.com", brand, NewMessage(welcome).To("jane@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := inbox[0]
+	if got.Recipient() != want.Recipient() || got.Sender != want.Sender || got.Subject != want.Subject {
+		t.Errorf("expected the headers of %+v; got %+v", want, got)
+	}
+	if got.PlainBody != want.PlainBody || got.HTMLBody != want.HTMLBody {
+		t.Errorf("expected the bodies to be read back; got %q and %q", got.PlainBody, got.HTMLBody)
+	}
+	if got.SentAt.IsZero() {
+		t.Error("expected the date of the email")
+	}
+}
+
+func TestFileMailerMessage(t *testing.T) {
+	dir := t.TempDir()
+	m := NewFile(dir, "app@example.com", brand)
+
+	msg := NewMessage(welcome).
+		From("Acme <team@example.com>").
+		To("jane@example.com", "John <john@example.com>").
+		CC("cc@example.com").
+		BCC("bcc@example.com").
+		ReplyTo("support@example.com").
+		ListUnsubscribe("https://app.example.com/unsubscribe?token=abc").
+		Header("x-entity-ref-id", "42").
+		Attach("report.csv", "text/csv", []byte("a,b\n1,2\n"))
+	if err := m.SendMessage(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+
+	inbox, err := m.Inbox()
+	if err != nil || len(inbox) != 1 {
+		t.Fatalf("expected the email in the inbox; got %d (%v)", len(inbox), err)
+	}
+	got := inbox[0]
+
+	if got.Sender != "Acme <team@example.com>" || got.Recipient() != `jane@example.com, "John" <john@example.com>` {
+		t.Errorf("unexpected sender and recipients %q and %q", got.Sender, got.Recipient())
+	}
+	if !slices.Equal(got.CC, []string{"cc@example.com"}) || got.ReplyTo != "support@example.com" {
+		t.Errorf("expected the copy and reply address; got %v and %q", got.CC, got.ReplyTo)
+	}
+	// the blind copies are left out of the email
+	if len(got.BCC) != 0 || got.Headers["Bcc"] != "" {
+		t.Errorf("expected no BCC header; got %v", got.BCC)
+	}
+
+	wantHeaders := map[string]string{
+		"List-Unsubscribe":      "<https://app.example.com/unsubscribe?token=abc>",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		"X-Entity-Ref-Id":       "42",
+	}
+	if !maps.Equal(got.Headers, wantHeaders) {
+		t.Errorf("expected the headers %v; got %v", wantHeaders, got.Headers)
+	}
+
+	if len(got.Attachments) != 1 {
+		t.Fatalf("expected the attachment; got %+v", got.Attachments)
+	}
+	if a := got.Attachments[0]; a.Filename != "report.csv" || a.ContentType != "text/csv" || string(a.Content) != "a,b\n1,2\n" {
+		t.Errorf("unexpected attachment %+v", a)
+	}
+	if !strings.Contains(got.PlainBody, "Hi Jane,") {
+		t.Errorf("expected the attachment not to replace the body; got %q", got.PlainBody)
+	}
+}
+
+func TestMessageBuilder(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name string
+		msg  *MessageBuilder
+		want string
+	}{
+		{"no recipients", NewMessage(welcome), ErrNoRecipients.Error()},
+		{"invalid recipient", NewMessage(welcome).To("jane"), `invalid address "jane"`},
+		{"invalid reply address", NewMessage(welcome).To("jane@example.com").ReplyTo("support"), `invalid address "support"`},
+		{"reserved header", NewMessage(welcome).To("jane@example.com").Header("subject", "Hi"), "header Subject is set from the message"},
+		{"header injection", NewMessage(welcome).To("jane@example.com").Header("X-Tag", "a\r\nBcc: x@example.com"), "header X-Tag has a line break"},
+	}
+
+	for _, tc := range testCases {
+		_, err := Render(ctx, "app@example.com", brand, tc.msg)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected the error %q; got %v", tc.name, tc.want, err)
+		}
+	}
+
+	// only blind copies is fine
+	if _, err := Render(ctx, "app@example.com", brand, NewMessage(welcome).BCC("jane@example.com")); err != nil {
+		t.Errorf("expected a message with only blind copies to render; got %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	m := NewMemory("app@example.com", brand)
+	if err := m.SendMessage(canceled, NewMessage(welcome).To("jane@example.com")); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the send to be canceled; got %v", err)
+	}
+	if m.Count() != 0 {
+		t.Error("expected canceled emails not to be recorded")
+	}
+}
+
+func TestMessageJSON(t *testing.T) {
+	samples := Samples("http://localhost:8080")
+	if len(samples) != len(emails) {
+		t.Errorf("expected every email to be decodable; got %d emails and %d samples", len(emails), len(samples))
+	}
+
+	for name, email := range samples {
+		msg := NewMessage(email).
+			From("Acme <app@example.com>").
+			To("jane@example.com").
+			CC("john@example.com").
+			ReplyTo("support@example.com").
+			Header("X-Entity-Ref-ID", "42").
+			Attach("notes.txt", "text/plain", []byte("notes"))
+
+		data, err := json.Marshal(msg)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		var decoded MessageBuilder
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(&decoded, msg) {
+			t.Errorf("%s: expected the same message after decoding; got %+v", name, decoded)
+		}
+	}
+
+	if _, err := json.Marshal(NewMessage(welcome)); !errors.Is(err, ErrNoRecipients) {
+		t.Errorf("expected a message that can't be sent not to be encoded; got %v", err)
+	}
+
+	var decoded MessageBuilder
+	err := json.Unmarshal([]byte(`{"email_type":"MissingEmail","email":{},"to":["jane@example.com"]}`), &decoded)
+	if err == nil || !strings.Contains(err.Error(), `unknown email "MissingEmail"`) {
+		t.Errorf("expected unknown emails not to be decoded; got %v", err)
+	}
+}
+
+func TestSMTPMailerCanceled(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// the server greets, then hangs once the sending starts
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		fmt.Fprint(conn, "220 localhost ready\r\n")
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if strings.HasPrefix(line, "EHLO") || strings.HasPrefix(line, "HELO") {
+				fmt.Fprint(conn, "250 localhost\r\n")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	m := NewSMTP(config.SMTP{Host: "127.0.0.1", Port: addr.Port, Sender: "app@example.com"}, brand)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = m.SendMessage(ctx, NewMessage(welcome).To("jane@example.com"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the send to be abandoned; got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the send to stop with the context; took %s", elapsed)
+	}
+}
+
+func TestSamples(t *testing.T) {
+	samples := Samples("http://localhost:8080")
+	if len(samples) == 0 {
+		t.Fatal("expected samples")
+	}
+
+	for name, email := range samples {
+		if Name(email) != name {
+			t.Errorf("expected %s to be listed by its name; got %s", Name(email), name)
+		}
+
+		msg, err := Render(context.Background(), "app@example.com", brand, NewMessage(email).To("jane@example.com"))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if msg.Subject == "" {
+			t.Errorf("%s: expected a subject", name)
+		}
+		// the buttons are followed from the plain text
+		if strings.Contains(msg.HTMLBody, `class="button"`) && !strings.Contains(msg.PlainBody, "\nhttp://localhost:8080/") {
+			t.Errorf("%s: expected the link in the plain text:\n%s", name, msg.PlainBody)
+		}
+		if strings.Contains(msg.HTMLBody, `href=""`) {
+			t.Errorf("%s: expected every link to be filled in", name)
+		}
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	m := NewMemory("app@example.com", brand)
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.Send(fmt.Sprintf("user%d@example.com", i), welcome); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if m.Count() != 10 || len(m.Messages()) != 10 {
+		t.Fatalf("expected 10 emails; got %d", m.Count())
+	}
+	if last := m.Last(); last == nil || last.Subject == "" || last.PlainBody == "" {
+		t.Errorf("expected the rendered email; got %+v", last)
+	}
+
+	if err := m.Send("jane@example.com", brokenEmail{}); err == nil {
+		t.Error("expected the error of rendering the email")
+	}
+	if m.Count() != 10 {
+		t.Error("expected failed emails not to be recorded")
+	}
+
+	m.Clear()
+	if m.Count() != 0 || m.Last() != nil {
+		t.Error("expected no emails after clearing")
+	}
+}
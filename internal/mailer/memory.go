@@ -0,0 +1,74 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryMailer keeps the rendered emails in memory, e.g. for tests. It is safe
+// for concurrent use.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	sender   string
+	brand    Brand
+	messages []Message
+}
+
+func NewMemory(sender string, brand Brand) *MemoryMailer {
+	return &MemoryMailer{sender: sender, brand: brand}
+}
+
+func (m *MemoryMailer) Send(recipient string, email Email) error {
+	return m.SendMessage(context.Background(), NewMessage(email).To(recipient))
+}
+
+func (m *MemoryMailer) SendMessage(ctx context.Context, builder *MessageBuilder) error {
+	msg, err := Render(ctx, m.sender, m.brand, builder)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+
+	return nil
+}
+
+// Messages returns the sent emails, oldest first
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+// Last returns the last sent email, nil when none was sent
+func (m *MemoryMailer) Last() *Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.messages) == 0 {
+		return nil
+	}
+
+	msg := m.messages[len(m.messages)-1]
+	return &msg
+}
+
+// Count returns the number of sent emails
+func (m *MemoryMailer) Count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.messages)
+}
+
+// Clear forgets the sent emails
+func (m *MemoryMailer) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
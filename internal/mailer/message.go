@@ -0,0 +1,227 @@
+package mailer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/textproto"
+	"slices"
+	"strings"
+)
+
+var ErrNoRecipients = errors.New("message has no recipients")
+
+// reservedHeaders are set from the message itself and can't be set with Header
+var reservedHeaders = []string{
+	"Bcc", "Cc", "Content-Transfer-Encoding", "Content-Type", "Date", "From",
+	"Mime-Version", "Reply-To", "Subject", "To",
+}
+
+// Attachment is a file attached to a message
+type Attachment struct {
+	Filename string
+	// ContentType is detected from the extension of the filename when empty
+	ContentType string
+	Content     []byte
+}
+
+// MessageBuilder puts together the message of an email, e.g.
+//
+//	mailer.NewMessage(mailer.InvitationEmail{...}).
+//		To("jane@example.com").
+//		ReplyTo(inviter.Email)
+//
+// The builder methods can be chained. Invalid addresses and headers are
+// reported when the message is sent.
+type MessageBuilder struct {
+	email       Email
+	sender      string
+	to          []string
+	cc          []string
+	bcc         []string
+	replyTo     string
+	headers     map[string]string
+	attachments []Attachment
+	errs        []error
+}
+
+// NewMessage starts the message of the email
+func NewMessage(email Email) *MessageBuilder {
+	return &MessageBuilder{
+		email:   email,
+		headers: make(map[string]string),
+	}
+}
+
+// From sets the sender, instead of the configured one
+func (b *MessageBuilder) From(address string) *MessageBuilder {
+	b.sender = b.address(address)
+	return b
+}
+
+// To adds recipients
+func (b *MessageBuilder) To(addresses ...string) *MessageBuilder {
+	b.to = b.addresses(b.to, addresses)
+	return b
+}
+
+// CC adds recipients who get a copy
+func (b *MessageBuilder) CC(addresses ...string) *MessageBuilder {
+	b.cc = b.addresses(b.cc, addresses)
+	return b
+}
+
+// BCC adds recipients who get a copy without the others knowing
+func (b *MessageBuilder) BCC(addresses ...string) *MessageBuilder {
+	b.bcc = b.addresses(b.bcc, addresses)
+	return b
+}
+
+// ReplyTo sets where replies go, instead of the sender
+func (b *MessageBuilder) ReplyTo(address string) *MessageBuilder {
+	b.replyTo = b.address(address)
+	return b
+}
+
+// ListUnsubscribe adds the List-Unsubscribe headers, with which mail clients
+// offer to unsubscribe. The link must accept a POST request to unsubscribe in
+// one click, see RFC 8058.
+func (b *MessageBuilder) ListUnsubscribe(link string) *MessageBuilder {
+	b.headers["List-Unsubscribe"] = "<" + link + ">"
+	b.headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+	return b
+}
+
+// Header sets a custom header, e.g. X-Entity-Ref-ID. The headers of the message
+// itself, such as To or Subject, can't be set.
+func (b *MessageBuilder) Header(name, value string) *MessageBuilder {
+	name = textproto.CanonicalMIMEHeaderKey(name)
+	if slices.Contains(reservedHeaders, name) {
+		b.errs = append(b.errs, fmt.Errorf("header %s is set from the message", name))
+		return b
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		b.errs = append(b.errs, fmt.Errorf("header %s has a line break", name))
+		return b
+	}
+
+	b.headers[name] = value
+	return b
+}
+
+// Attach attaches a file. The content type is detected from the extension of the
+// filename when it is empty.
+func (b *MessageBuilder) Attach(filename, contentType string, content []byte) *MessageBuilder {
+	b.attachments = append(b.attachments, Attachment{
+		Filename:    filename,
+		ContentType: contentType,
+		Content:     content,
+	})
+	return b
+}
+
+// address records an error when the address is invalid, e.g. not like
+// "jane@example.com" or "Jane <jane@example.com>"
+func (b *MessageBuilder) address(address string) string {
+	if _, err := mail.ParseAddress(address); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("invalid address %q: %w", address, err))
+	}
+
+	return address
+}
+
+func (b *MessageBuilder) addresses(list, addresses []string) []string {
+	for _, address := range addresses {
+		list = append(list, b.address(address))
+	}
+
+	return list
+}
+
+// err returns why the message can't be sent, nil when it can
+func (b *MessageBuilder) err() error {
+	if len(b.to)+len(b.cc)+len(b.bcc) == 0 {
+		return ErrNoRecipients
+	}
+
+	return errors.Join(b.errs...)
+}
+
+// Email returns the email of the message
+func (b *MessageBuilder) Email() Email {
+	return b.email
+}
+
+// Recipients returns all addresses the message goes to, including the copies
+func (b *MessageBuilder) Recipients() []string {
+	return slices.Concat(b.to, b.cc, b.bcc)
+}
+
+// messageJSON is a MessageBuilder as JSON, e.g. to queue it in the outbox
+type messageJSON struct {
+	EmailType   string            `json:"email_type"`
+	Email       json.RawMessage   `json:"email"`
+	Sender      string            `json:"sender,omitempty"`
+	To          []string          `json:"to,omitempty"`
+	CC          []string          `json:"cc,omitempty"`
+	BCC         []string          `json:"bcc,omitempty"`
+	ReplyTo     string            `json:"reply_to,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Attachments []Attachment      `json:"attachments,omitempty"`
+}
+
+// MarshalJSON encodes the message with its email. A message that can't be sent
+// isn't encoded, so that it fails right away instead of when it is sent.
+func (b *MessageBuilder) MarshalJSON() ([]byte, error) {
+	if err := b.err(); err != nil {
+		return nil, err
+	}
+
+	email, err := json.Marshal(b.email)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(messageJSON{
+		EmailType:   Name(b.email),
+		Email:       email,
+		Sender:      b.sender,
+		To:          b.to,
+		CC:          b.cc,
+		BCC:         b.bcc,
+		ReplyTo:     b.replyTo,
+		Headers:     b.headers,
+		Attachments: b.attachments,
+	})
+}
+
+// UnmarshalJSON decodes a message encoded by MarshalJSON. The email must be one
+// of the emails of the package.
+func (b *MessageBuilder) UnmarshalJSON(data []byte) error {
+	var msg messageJSON
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+
+	email, err := decodeEmail(msg.EmailType, msg.Email)
+	if err != nil {
+		return err
+	}
+
+	*b = MessageBuilder{
+		email:       email,
+		sender:      msg.Sender,
+		to:          msg.To,
+		cc:          msg.CC,
+		bcc:         msg.BCC,
+		replyTo:     msg.ReplyTo,
+		headers:     msg.Headers,
+		attachments: msg.Attachments,
+	}
+	if b.headers == nil {
+		b.headers = make(map[string]string)
+	}
+
+	return nil
+}
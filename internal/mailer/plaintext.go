@@ -0,0 +1,83 @@
+package mailer
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// blocks are the elements that start a new paragraph in plain text
+var blocks = []atom.Atom{atom.P, atom.Div, atom.Table, atom.Tr, atom.Td, atom.H1, atom.H2, atom.H3, atom.Ul, atom.Ol, atom.Li}
+
+// PlainText converts the HTML of an email to its plain text alternative. Blocks
+// become paragraphs, and buttons (links with the button class) their link, so
+// that the link can be followed from the plain text.
+func PlainText(htmlBody string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(htmlBody))
+	if err != nil {
+		return "", err
+	}
+
+	text := new(strings.Builder)
+	writePlainText(text, doc)
+
+	// trim the lines and keep a single empty line between paragraphs
+	var lines []string
+	for line := range strings.SplitSeq(text.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n")) + "\n", nil
+}
+
+func writePlainText(text *strings.Builder, n *html.Node) {
+	switch {
+	case n.Type == html.TextNode:
+		text.WriteString(whitespace.ReplaceAllString(n.Data, " "))
+		return
+	case n.Type != html.ElementNode && n.Type != html.DocumentNode:
+		return
+	}
+
+	switch {
+	case slices.Contains([]atom.Atom{atom.Head, atom.Style, atom.Script}, n.DataAtom):
+		return
+	case n.DataAtom == atom.Br:
+		text.WriteString("\n")
+		return
+	case n.DataAtom == atom.A && slices.Contains(strings.Fields(attr(n, "class")), "button"):
+		text.WriteString("\n\n" + attr(n, "href") + "\n\n")
+		return
+	}
+
+	block := slices.Contains(blocks, n.DataAtom)
+	if block {
+		text.WriteString("\n\n")
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		writePlainText(text, child)
+	}
+	if block {
+		text.WriteString("\n\n")
+	}
+}
+
+// attr returns the value of the attribute key of the element, empty when it has none
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,75 @@
+package mailer
+
+import "time"
+
+// sampleToken stands in for the tokens of the links in the samples
+const sampleToken = "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU"
+
+// Samples returns an email of every type filled with sample data, by the name of
+// the type, to preview the emails with. The links point to baseURL.
+func Samples(baseURL string) map[string]Email {
+	emails := []Email{
+		WelcomeEmail{
+			Name: "Jane Doe",
+		},
+		ActivationEmail{
+			Name:      "Jane Doe",
+			Link:      baseURL + "/activate?token=" + sampleToken,
+			ExpiresIn: 3 * 24 * time.Hour,
+		},
+		LoginLinkEmail{
+			Name:      "Jane Doe",
+			Link:      baseURL + "/login/magic?token=" + sampleToken,
+			ExpiresIn: 15 * time.Minute,
+		},
+		PasswordResetEmail{
+			Link:      baseURL + "/reset-password?token=" + sampleToken,
+			ExpiresIn: 45 * time.Minute,
+		},
+		PasswordResetConfirmationEmail{
+			LoginLink: baseURL + "/login",
+		},
+		PasswordResetRequiredEmail{
+			Name:      "Jane Doe",
+			Link:      baseURL + "/reset-password?token=" + sampleToken,
+			ExpiresIn: 24 * time.Hour,
+		},
+		AccountLockedEmail{
+			Name:              "Jane Doe",
+			LockedFor:         15 * time.Minute,
+			PasswordResetLink: baseURL + "/reset-password?token=" + sampleToken,
+		},
+		AccountLinkEmail{
+			Name:      "Jane Doe",
+			Provider:  "GitHub",
+			Link:      baseURL + "/connections/confirm?token=" + sampleToken,
+			ExpiresIn: time.Hour,
+		},
+		EmailChangeEmail{
+			Name:      "Jane Doe",
+			NewEmail:  "jane.doe@example.com",
+			Link:      baseURL + "/email/confirm?token=" + sampleToken,
+			ExpiresIn: 24 * time.Hour,
+		},
+		EmailChangeNoticeEmail{
+			Name:       "Jane Doe",
+			NewEmail:   "jane.doe@example.com",
+			CancelLink: baseURL + "/email/cancel?token=" + sampleToken,
+			ExpiresIn:  24 * time.Hour,
+		},
+		InvitationEmail{
+			InviterName:      "John Smith",
+			OrganizationName: "Acme",
+			Role:             "member",
+			Link:             baseURL + "/invitations/accept?token=" + sampleToken,
+			ExpiresIn:        7 * 24 * time.Hour,
+		},
+	}
+
+	samples := make(map[string]Email, len(emails))
+	for _, email := range emails {
+		samples[Name(email)] = email
+	}
+
+	return samples
+}
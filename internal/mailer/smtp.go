@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"go-web-starter/internal/config"
+	"time"
+
+	"github.com/go-mail/mail/v2"
+)
+
+// SMTPMailer sends emails through an SMTP server
+type SMTPMailer struct {
+	dialer *mail.Dialer
+	sender string
+	brand  Brand
+}
+
+func NewSMTP(smtp config.SMTP, brand Brand) SMTPMailer {
+	dialer := mail.NewDialer(smtp.Host, smtp.Port, smtp.Username, smtp.Password)
+	dialer.Timeout = 5 * time.Second
+
+	return SMTPMailer{
+		dialer: dialer,
+		sender: smtp.Sender,
+		brand:  brand,
+	}
+}
+
+func (m SMTPMailer) Send(recipient string, email Email) error {
+	return m.SendMessage(context.Background(), NewMessage(email).To(recipient))
+}
+
+// SendMessage opens a connection to the SMTP server, sends the message, then
+// closes the connection. If there is a timeout, it will return a "dial tcp: i/o
+// timeout" error. When ctx is done first, SendMessage returns right away and
+// leaves the connection to finish or time out in the background, so the email
+// may still be delivered.
+func (m SMTPMailer) SendMessage(ctx context.Context, msg *MessageBuilder) error {
+	rendered, err := Render(ctx, m.sender, m.brand, msg)
+	if err != nil {
+		return err
+	}
+
+	sent := make(chan error, 1)
+	go func() {
+		sent <- m.dialer.DialAndSend(rendered.mailMessage())
+	}()
+
+	select {
+	case err := <-sent:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
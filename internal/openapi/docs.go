@@ -0,0 +1,28 @@
+package openapi
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+)
+
+//go:embed docs.html
+var docsHTML string
+
+var docsTemplate = template.Must(template.New("docs").Parse(docsHTML))
+
+// DocsHandler serves a page rendering the document at specURL with Scalar, loaded
+// from a CDN.
+func DocsHandler(title, specURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+		err := docsTemplate.Execute(w, map[string]string{
+			"Title":   title,
+			"SpecURL": specURL,
+		})
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Package openapi generates the OpenAPI 3.1 document of the app from the chi router
+// and the Go types the handlers decode and encode, so the document can't drift
+// from the code.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const Version = "3.1.0"
+
+// BearerAuth is the name of the security scheme of routes with Auth set
+const BearerAuth = "bearerAuth"
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem maps the lowercase HTTP methods of a path to their operation
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                     `json:"operationId,omitempty"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []Parameter                `json:"parameters,omitempty"`
+	RequestBody *RequestBody               `json:"requestBody,omitempty"`
+	Responses   map[string]*ResponseObject `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// ResponseObject is a response in the document, see Response for documenting one
+type ResponseObject struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme"`
+	Description string `json:"description,omitempty"`
+}
+
+// Route documents a route registered on the router. The path parameters are taken
+// from the chi pattern, the bodies from the Go types.
+type Route struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Description string
+	Tags        []string
+	// Auth marks routes that require a personal access token
+	Auth bool
+	// JSON is a value of the type of the JSON request body
+	JSON any
+	// Form is a value of the form struct decoded from an urlencoded request body
+	Form      any
+	Responses []Response
+}
+
+// Response documents a response of a Route
+type Response struct {
+	Status      int
+	Description string
+	// JSON is a value of the type of the JSON response body
+	JSON any
+	// HTML marks responses rendering a page or an HTMX fragment
+	HTML bool
+	// Redirect marks responses with a Location header
+	Redirect bool
+}
+
+// Build generates the document of the documented routes. Every route must be
+// registered on routes, so the document never promises endpoints that don't exist.
+func Build(info Info, routes chi.Routes, documented []Route) (*Document, error) {
+	registered := make(map[string]bool)
+
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		registered[method+" "+route] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+		},
+	}
+	g := newGenerator(doc.Components.Schemas)
+
+	for _, route := range documented {
+		if !registered[route.Method+" "+route.Path] {
+			return nil, fmt.Errorf("openapi: %s %s is not registered on the router", route.Method, route.Path)
+		}
+
+		path := documentPath(route.Path)
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+
+		method := strings.ToLower(route.Method)
+		if _, ok := (*item)[method]; ok {
+			return nil, fmt.Errorf("openapi: %s %s is documented twice", route.Method, route.Path)
+		}
+
+		(*item)[method] = g.operation(route)
+
+		if route.Auth {
+			doc.Components.SecuritySchemes = map[string]SecurityScheme{
+				BearerAuth: {
+					Type:        "http",
+					Scheme:      "bearer",
+					Description: "A personal access token, created on the profile page or by logging in through the API.",
+				},
+			}
+		}
+	}
+
+	return doc, nil
+}
+
+// Operation returns the operation documented for the route pattern, nil when there
+// is none.
+func (d *Document) Operation(method, pattern string) *Operation {
+	item, ok := d.Paths[documentPath(pattern)]
+	if !ok {
+		return nil
+	}
+
+	return (*item)[strings.ToLower(method)]
+}
+
+func (g *generator) operation(route Route) *Operation {
+	op := &Operation{
+		OperationID: route.OperationID,
+		Summary:     route.Summary,
+		Description: route.Description,
+		Tags:        route.Tags,
+		Parameters:  pathParameters(route.Path),
+		Responses:   make(map[string]*ResponseObject),
+	}
+
+	if route.Auth {
+		op.Security = []map[string][]string{{BearerAuth: {}}}
+	}
+
+	switch {
+	case route.JSON != nil:
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: g.schema(route.JSON, tagJSON)}},
+		}
+	case route.Form != nil:
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/x-www-form-urlencoded": {Schema: g.schema(route.Form, tagForm)}},
+		}
+	}
+
+	for _, resp := range route.Responses {
+		description := resp.Description
+		if description == "" {
+			description = http.StatusText(resp.Status)
+		}
+
+		response := &ResponseObject{Description: description}
+
+		switch {
+		case resp.JSON != nil:
+			response.Content = map[string]MediaType{"application/json": {Schema: g.schema(resp.JSON, tagJSON)}}
+		case resp.HTML:
+			response.Content = map[string]MediaType{"text/html": {Schema: &Schema{Type: "string"}}}
+		}
+
+		if resp.Redirect {
+			response.Headers = map[string]Header{"Location": {Schema: &Schema{Type: "string"}}}
+		}
+
+		op.Responses[fmt.Sprint(resp.Status)] = response
+	}
+
+	return op
+}
+
+var pathParamRX = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// documentPath drops the regular expressions of the chi pattern, /{id:[0-9]+}
+// becomes /{id}
+func documentPath(pattern string) string {
+	return pathParamRX.ReplaceAllString(pattern, "{$1}")
+}
+
+// pathParameters returns the parameters of a chi pattern like /tokens/{id}
+func pathParameters(path string) []Parameter {
+	var params []Parameter
+	for _, match := range pathParamRX.FindAllStringSubmatch(path, -1) {
+		params = append(params, Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	return params
+}
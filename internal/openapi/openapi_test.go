@@ -0,0 +1,224 @@
+package openapi_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go-web-starter/internal/openapi"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type base struct {
+	Hidden string `json:"-" form:"-"`
+}
+
+type item struct {
+	base
+	ID        int64             `json:"id"`
+	Name      string            `json:"name" form:"name"`
+	Note      string            `json:"note,omitempty" form:"note,omitempty"`
+	Tags      []string          `json:"tags" form:"tags"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type itemList struct {
+	Items []item `json:"items"`
+}
+
+func noop(w http.ResponseWriter, r *http.Request) {}
+
+func newRouter() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/items", noop)
+	r.Post("/items", noop)
+	r.Route("/api", func(r chi.Router) {
+		r.Delete("/items/{id}", noop)
+	})
+	return r
+}
+
+var routes = []openapi.Route{
+	{
+		Method: http.MethodGet,
+		Path:   "/items",
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, JSON: itemList{}},
+		},
+	},
+	{
+		Method: http.MethodPost,
+		Path:   "/items",
+		Form:   item{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, HTML: true},
+			{Status: http.StatusSeeOther, Redirect: true},
+		},
+	},
+	{
+		Method: http.MethodDelete,
+		Path:   "/api/items/{id}",
+		Auth:   true,
+		Responses: []openapi.Response{
+			{Status: http.StatusNoContent},
+		},
+	},
+}
+
+func build(t *testing.T) *openapi.Document {
+	t.Helper()
+
+	doc, err := openapi.Build(openapi.Info{Title: "Test", Version: "1"}, newRouter(), routes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return doc
+}
+
+func TestBuild(t *testing.T) {
+	doc := build(t)
+
+	if doc.OpenAPI != openapi.Version {
+		t.Errorf("expected version %s; got %s", openapi.Version, doc.OpenAPI)
+	}
+
+	t.Run("named structs become components", func(t *testing.T) {
+		list := doc.Operation(http.MethodGet, "/items").Responses["200"].Content["application/json"].Schema
+		if list.Ref != "#/components/schemas/itemList" {
+			t.Fatalf("expected a reference to itemList; got %+v", list)
+		}
+
+		schema := doc.Components.Schemas["item"]
+		if schema == nil {
+			t.Fatal("expected the item component")
+		}
+
+		if _, ok := schema.Properties["Hidden"]; ok {
+			t.Error("fields tagged - must be left out")
+		}
+		if got := schema.Properties["created_at"]; got.Type != "string" || got.Format != "date-time" {
+			t.Errorf("expected time.Time as a date-time string; got %+v", got)
+		}
+		if got := schema.Properties["tags"]; got.Type != "array" || got.Items.Type != "string" {
+			t.Errorf("expected an array of strings; got %+v", got)
+		}
+		if got := strings.Join(schema.Required, ","); got != "id,name,tags,created_at" {
+			t.Errorf("expected the fields without omitempty to be required; got %s", got)
+		}
+	})
+
+	t.Run("form bodies use the form tags", func(t *testing.T) {
+		body := doc.Operation(http.MethodPost, "/items").RequestBody
+		schema := body.Content["application/x-www-form-urlencoded"].Schema
+		if schema == nil {
+			t.Fatal("expected an urlencoded body")
+		}
+
+		// fields without a form tag keep their Go name, like the form decoder does
+		for _, name := range []string{"name", "note", "tags", "ID"} {
+			if _, ok := schema.Properties[name]; !ok {
+				t.Errorf("expected the property %q; got %v", name, schema.Properties)
+			}
+		}
+	})
+
+	t.Run("path parameters and security", func(t *testing.T) {
+		op := doc.Operation(http.MethodDelete, "/api/items/{id}")
+		if op == nil {
+			t.Fatal("expected the delete operation")
+		}
+		if len(op.Parameters) != 1 || op.Parameters[0].Name != "id" || op.Parameters[0].In != "path" {
+			t.Errorf("expected the id path parameter; got %+v", op.Parameters)
+		}
+		if len(op.Security) != 1 {
+			t.Errorf("expected the bearer security requirement; got %+v", op.Security)
+		}
+		if _, ok := doc.Components.SecuritySchemes[openapi.BearerAuth]; !ok {
+			t.Error("expected the bearer security scheme")
+		}
+	})
+
+	t.Run("unregistered routes fail", func(t *testing.T) {
+		_, err := openapi.Build(openapi.Info{}, newRouter(), []openapi.Route{
+			{Method: http.MethodPut, Path: "/items"},
+		})
+		if err == nil {
+			t.Error("expected an error for a route missing from the router")
+		}
+	})
+}
+
+func TestValidateResponse(t *testing.T) {
+	doc := build(t)
+
+	tests := []struct {
+		name        string
+		method      string
+		pattern     string
+		status      int
+		contentType string
+		body        string
+		valid       bool
+	}{
+		{
+			name: "valid", method: http.MethodGet, pattern: "/items", status: http.StatusOK, contentType: "application/json",
+			body:  `{"items":[{"id":1,"name":"a","tags":[],"labels":{"k":"v"},"created_at":"2025-01-02T03:04:05Z"}]}`,
+			valid: true,
+		},
+		{
+			name: "missing required property", method: http.MethodGet, pattern: "/items", status: http.StatusOK, contentType: "application/json",
+			body: `{"items":[{"id":1,"tags":[],"created_at":"2025-01-02T03:04:05Z"}]}`,
+		},
+		{
+			name: "undocumented property", method: http.MethodGet, pattern: "/items", status: http.StatusOK, contentType: "application/json",
+			body: `{"items":[],"total":0}`,
+		},
+		{
+			name: "wrong type", method: http.MethodGet, pattern: "/items", status: http.StatusOK, contentType: "application/json",
+			body: `{"items":[{"id":"1","name":"a","tags":[],"created_at":"2025-01-02T03:04:05Z"}]}`,
+		},
+		{
+			name: "invalid date-time", method: http.MethodGet, pattern: "/items", status: http.StatusOK, contentType: "application/json",
+			body: `{"items":[{"id":1,"name":"a","tags":null,"created_at":"yesterday"}]}`,
+		},
+		{
+			name: "undocumented status", method: http.MethodGet, pattern: "/items", status: http.StatusNotFound, contentType: "application/json",
+			body: `{}`,
+		},
+		{
+			name: "undocumented content type", method: http.MethodPost, pattern: "/items", status: http.StatusOK, contentType: "application/json",
+			body: `{}`,
+		},
+		{
+			name: "html", method: http.MethodPost, pattern: "/items", status: http.StatusOK, contentType: "text/html; charset=utf-8",
+			body:  `<p>ok</p>`,
+			valid: true,
+		},
+		{
+			name: "no content", method: http.MethodDelete, pattern: "/api/items/{id}", status: http.StatusNoContent,
+			valid: true,
+		},
+		{
+			name: "body on no content", method: http.MethodDelete, pattern: "/api/items/{id}", status: http.StatusNoContent,
+			body: `{}`,
+		},
+		{
+			name: "undocumented route", method: http.MethodPut, pattern: "/items", status: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := doc.ValidateResponse(tt.method, tt.pattern, tt.status, tt.contentType, []byte(tt.body))
+			if tt.valid && err != nil {
+				t.Errorf("expected a valid response; got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of JSON Schema the generator produces
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	// AdditionalProperties is a *Schema for maps and false for structs
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+}
+
+const (
+	tagJSON = "json"
+	tagForm = "form"
+)
+
+const refPrefix = "#/components/schemas/"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// generator builds schemas from Go types. Named structs of JSON bodies become
+// components and are referenced, everything else is inlined.
+type generator struct {
+	components map[string]*Schema
+	types      map[string]reflect.Type
+}
+
+func newGenerator(components map[string]*Schema) *generator {
+	return &generator{
+		components: components,
+		types:      make(map[string]reflect.Type),
+	}
+}
+
+func (g *generator) schema(v any, tag string) *Schema {
+	return g.typeSchema(reflect.TypeOf(v), tag)
+}
+
+func (g *generator) typeSchema(t reflect.Type, tag string) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int32, reflect.Uint32, reflect.Int16, reflect.Uint16, reflect.Int8, reflect.Uint8:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		// encoding/json sends bytes as base64
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.typeSchema(t.Elem(), tag)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem(), tag)}
+	case reflect.Struct:
+		if tag != tagJSON || t.Name() == "" || strings.Contains(t.Name(), "[") {
+			return g.structSchema(t, tag)
+		}
+		return g.component(t)
+	default:
+		// interfaces accept any value
+		return &Schema{}
+	}
+}
+
+// component registers the schema of the named struct t and returns a reference to it
+func (g *generator) component(t reflect.Type) *Schema {
+	name := t.Name()
+
+	if existing, ok := g.types[name]; ok {
+		if existing != t {
+			panic(fmt.Sprintf("openapi: schema name %s is used by %s and %s", name, existing, t))
+		}
+	} else {
+		g.types[name] = t
+		// registered before the fields so recursive types end in a reference
+		g.components[name] = &Schema{}
+		*g.components[name] = *g.structSchema(t, tagJSON)
+	}
+
+	return &Schema{Ref: refPrefix + name}
+}
+
+// structSchema lists the fields with their tag names. Fields without omitempty are
+// required. JSON objects with unknown fields are rejected by the API, form posts
+// carry the CSRF token next to the fields.
+func (g *generator) structSchema(t reflect.Type, tag string) *Schema {
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	if tag == tagJSON {
+		s.AdditionalProperties = false
+	}
+
+	g.addFields(s, t, tag)
+
+	return s
+}
+
+func (g *generator) addFields(s *Schema, t reflect.Type, tag string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		name, omitempty := parseTag(field.Tag.Get(tag))
+		if name == "-" {
+			continue
+		}
+
+		// embedded structs without a name are flattened, like encoding/json does
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft, tag)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		s.Properties[name] = g.typeSchema(field.Type, tag)
+		if !omitempty {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func parseTag(tag string) (name string, omitempty bool) {
+	name, opts, _ := strings.Cut(tag, ",")
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitempty = true
+		}
+	}
+
+	return name, omitempty
+}
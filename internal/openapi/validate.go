@@ -0,0 +1,177 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// ValidateResponse checks a response of the route pattern against the document:
+// the status code and content type must be documented and a JSON body must match
+// its schema. It backs the contract tests of the handlers.
+func (d *Document) ValidateResponse(method, pattern string, status int, contentType string, body []byte) error {
+	op := d.Operation(method, pattern)
+	if op == nil {
+		return fmt.Errorf("%s %s is not documented", method, pattern)
+	}
+
+	resp, ok := op.Responses[fmt.Sprint(status)]
+	if !ok {
+		return fmt.Errorf("%s %s: status %d is not documented", method, pattern, status)
+	}
+
+	if len(resp.Content) == 0 {
+		if len(bytes.TrimSpace(body)) > 0 {
+			return fmt.Errorf("%s %s: status %d must not have a body", method, pattern, status)
+		}
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	media, ok := resp.Content[mediaType]
+	if !ok {
+		return fmt.Errorf("%s %s: content type %q is not documented for status %d", method, pattern, contentType, status)
+	}
+
+	if mediaType != "application/json" || media.Schema == nil {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("%s %s: invalid JSON: %w", method, pattern, err)
+	}
+
+	if err := d.Validate(media.Schema, value); err != nil {
+		return fmt.Errorf("%s %s: status %d: %w", method, pattern, status, err)
+	}
+
+	return nil
+}
+
+// Validate checks a value decoded with json.Decoder.UseNumber against the schema,
+// resolving references to the components of the document.
+func (d *Document) Validate(s *Schema, value any) error {
+	var errs []error
+	d.validate(s, value, "body", &errs)
+
+	return errors.Join(errs...)
+}
+
+func (d *Document) validate(s *Schema, value any, path string, errs *[]error) {
+	if s.Ref != "" {
+		ref, ok := d.Components.Schemas[strings.TrimPrefix(s.Ref, refPrefix)]
+		if !ok {
+			*errs = append(*errs, fmt.Errorf("%s: unknown schema %s", path, s.Ref))
+			return
+		}
+		s = ref
+	}
+
+	if s.Type == "" {
+		return
+	}
+
+	fail := func() {
+		*errs = append(*errs, fmt.Errorf("%s: expected %s; got %s", path, s.Type, describe(value)))
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			fail()
+			return
+		}
+
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				*errs = append(*errs, fmt.Errorf("%s: missing required property %q", path, name))
+			}
+		}
+
+		for name, v := range object {
+			if prop, ok := s.Properties[name]; ok {
+				d.validate(prop, v, path+"."+name, errs)
+				continue
+			}
+
+			switch additional := s.AdditionalProperties.(type) {
+			case *Schema:
+				d.validate(additional, v, path+"."+name, errs)
+			case bool:
+				if !additional {
+					*errs = append(*errs, fmt.Errorf("%s: undocumented property %q", path, name))
+				}
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			fail()
+			return
+		}
+
+		if s.Items != nil {
+			for i, v := range array {
+				d.validate(s.Items, v, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail()
+			return
+		}
+
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				*errs = append(*errs, fmt.Errorf("%s: expected a date-time; got %q", path, str))
+			}
+		}
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			fail()
+			return
+		}
+
+		if _, err := n.Int64(); err != nil {
+			fail()
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			fail()
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail()
+		}
+	}
+}
+
+func describe(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case json.Number:
+		return "number " + v.String()
+	case bool:
+		return fmt.Sprintf("boolean %t", v)
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
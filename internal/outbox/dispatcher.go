@@ -0,0 +1,153 @@
+package outbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/jobs"
+	"go-web-starter/internal/jsonlog"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/queries"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Dispatcher sends the messages of the outbox through the mailer
+type Dispatcher struct {
+	queries *queries.Queries
+	mailer  mailer.Mailer
+	logger  *jsonlog.Logger
+	config  config.Jobs
+	// domain is the right part of the Message-Id of the messages, e.g. example.com
+	domain string
+}
+
+// NewDispatcher returns a dispatcher that uses the job configuration for its
+// concurrency, polling and timeouts. The Message-Id of the messages are in the
+// domain of appURL.
+func NewDispatcher(q *queries.Queries, m mailer.Mailer, logger *jsonlog.Logger, cfg config.Jobs, appURL string) *Dispatcher {
+	domain := "localhost"
+	if u, err := url.Parse(appURL); err == nil && u.Hostname() != "" {
+		domain = u.Hostname()
+	}
+
+	return &Dispatcher{
+		queries: q,
+		mailer:  m,
+		logger:  logger,
+		config:  cfg,
+		domain:  domain,
+	}
+}
+
+// Run starts config.Workers dispatchers and blocks until ctx is done and the
+// messages they are sending are finished. Messages are polled and retried like
+// jobs.
+func (d *Dispatcher) Run(ctx context.Context) {
+	jobs.Poll(ctx, d.config, d.logger, "outbox", d.DispatchNext)
+}
+
+// DispatchPending sends the messages that are due until there are none left and
+// returns how many were attempted
+func (d *Dispatcher) DispatchPending(ctx context.Context) (int, error) {
+	return jobs.Drain(ctx, d.DispatchNext)
+}
+
+// DispatchNext sends the next message that is due, if there is one. The error is
+// about the outbox, failed deliveries are retried or the message is dead.
+func (d *Dispatcher) DispatchNext(ctx context.Context) (bool, error) {
+	message, err := d.queries.ClaimOutboxMessage(ctx, jobs.StaleBefore(d.config))
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// a message that was sending when its dispatcher stopped may have no attempts left
+	if message.Attempts > message.MaxAttempts {
+		return true, d.kill(ctx, message, errors.New("the dispatcher stopped during the last attempt"))
+	}
+
+	// a message being sent is finished when the dispatcher shuts down
+	sendCtx, cancel := jobs.AttemptContext(ctx, d.config)
+	defer cancel()
+
+	startedAt := time.Now()
+	sendErr := d.send(sendCtx, message)
+
+	// a send that ran into its timeout is recorded all the same
+	statusCtx, cancelStatus := jobs.StatusContext(ctx)
+	defer cancelStatus()
+
+	attempt := queries.AddOutboxAttemptParams{
+		MessageID: message.ID,
+		Attempt:   message.Attempts,
+		StartedAt: startedAt,
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err := d.queries.AddOutboxAttempt(statusCtx, attempt); err != nil {
+		return true, err
+	}
+
+	if sendErr == nil {
+		return true, d.queries.MarkOutboxMessageSent(statusCtx, message.ID)
+	}
+
+	nextAttemptAt, ok := jobs.Retry(sendErr, message.Attempts, message.MaxAttempts)
+	if !ok {
+		return true, d.kill(statusCtx, message, sendErr)
+	}
+
+	return true, d.queries.RetryOutboxMessage(statusCtx, queries.RetryOutboxMessageParams{
+		ID:            message.ID,
+		NextAttemptAt: nextAttemptAt,
+		LastError:     sendErr.Error(),
+	})
+}
+
+// send sends the message with a Message-Id derived from its idempotency key, so
+// that mail servers and clients can tell a retry of a message that was delivered
+// before apart from a new message
+func (d *Dispatcher) send(ctx context.Context, message queries.OutboxMessage) error {
+	var msg mailer.MessageBuilder
+	if err := json.Unmarshal(message.Message, &msg); err != nil {
+		return jobs.Permanent(fmt.Errorf("decode message: %w", err))
+	}
+
+	err := d.mailer.SendMessage(ctx, msg.Header("Message-Id", d.messageID(message.IdempotencyKey)))
+	if errors.Is(err, mailer.ErrNoRecipients) {
+		return jobs.Permanent(err)
+	}
+
+	return err
+}
+
+// messageID is the Message-Id of the message with the idempotency key
+func (d *Dispatcher) messageID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "<" + hex.EncodeToString(sum[:16]) + "@" + d.domain + ">"
+}
+
+// kill gives up on the message
+func (d *Dispatcher) kill(ctx context.Context, message queries.OutboxMessage, sendErr error) error {
+	d.logger.PrintError(sendErr, map[string]string{
+		"component":  "outbox",
+		"message_id": strconv.FormatInt(message.ID, 10),
+		"email_type": message.EmailType,
+		"attempts":   strconv.Itoa(int(message.Attempts)),
+	})
+
+	return d.queries.KillOutboxMessage(ctx, queries.KillOutboxMessageParams{
+		ID:        message.ID,
+		LastError: sendErr.Error(),
+	})
+}
@@ -0,0 +1,195 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"go-web-starter/internal/jsonlog"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/outbox"
+	"go-web-starter/internal/tests"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingMailer fails to send while fail is set, and hangs until the send times
+// out while hang is set
+type failingMailer struct {
+	*mailer.MemoryMailer
+	fail error
+	hang bool
+}
+
+func (m *failingMailer) SendMessage(ctx context.Context, msg *mailer.MessageBuilder) error {
+	if m.fail != nil {
+		return m.fail
+	}
+	if m.hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return m.MemoryMailer.SendMessage(ctx, msg)
+}
+
+func TestDispatcher(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ctx := context.Background()
+	cfg := ts.Config.Jobs
+	cfg.Timeout = time.Minute
+
+	m := &failingMailer{MemoryMailer: ts.Mailer}
+	dispatcher := outbox.NewDispatcher(ts.Queries, m, jsonlog.New(io.Discard, jsonlog.LevelInfo), cfg, "https://app.example.com")
+
+	welcome := func(to string) *mailer.MessageBuilder {
+		return mailer.NewMessage(mailer.WelcomeEmail{Name: "Jane"}).To(to)
+	}
+
+	t.Run("only sends committed messages", func(t *testing.T) {
+		tx, err := ts.DB.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := outbox.Add(ctx, ts.Queries.WithTx(tx), "rolled-back", welcome("jane@example.com")); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+
+		count, err := dispatcher.DispatchPending(ctx)
+		if err != nil || count != 0 {
+			t.Fatalf("expected no message; got %d, %v", count, err)
+		}
+	})
+
+	t.Run("sends a message once per key", func(t *testing.T) {
+		ts.Mailer.Clear()
+
+		first, err := outbox.Add(ctx, ts.Queries, "welcome:1", welcome("jane@example.com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := outbox.Add(ctx, ts.Queries, "welcome:1", welcome("jane@example.com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first.ID != second.ID || first.EmailType != "WelcomeEmail" || first.Recipients != "jane@example.com" {
+			t.Fatalf("expected the message to be added once; got %+v and %+v", first, second)
+		}
+
+		count, err := dispatcher.DispatchPending(ctx)
+		if err != nil || count != 1 {
+			t.Fatalf("expected 1 message to be sent; got %d, %v", count, err)
+		}
+
+		email := ts.Mailer.Last()
+		if email == nil || email.Recipient() != "jane@example.com" {
+			t.Fatalf("expected the welcome email; got %+v", email)
+		}
+		if id := email.Headers["Message-Id"]; !strings.HasPrefix(id, "<") || !strings.HasSuffix(id, "@app.example.com>") {
+			t.Errorf("expected a Message-Id in the domain of the app; got %q", id)
+		}
+
+		message, err := ts.Queries.GetOutboxMessage(ctx, first.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if message.Status != outbox.StatusSent || !message.SentAt.Valid || string(message.Message) != "{}" {
+			t.Errorf("expected the message to be sent and cleared; got %+v", message)
+		}
+
+		attempts, err := ts.Queries.ListOutboxAttempts(ctx, first.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(attempts) != 1 || attempts[0].Error != "" {
+			t.Errorf("expected 1 successful attempt; got %+v", attempts)
+		}
+	})
+
+	t.Run("retries failed deliveries until the message is dead", func(t *testing.T) {
+		m.fail = errors.New("mail server unavailable")
+		defer func() { m.fail = nil }()
+
+		message, err := outbox.Add(ctx, ts.Queries, "welcome:2", welcome("john@example.com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ts.DB.ExecContext(ctx, "UPDATE outbox_messages SET max_attempts = 2 WHERE id = $1", message.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := dispatcher.DispatchPending(ctx); err != nil {
+			t.Fatal(err)
+		}
+		message, err = ts.Queries.GetOutboxMessage(ctx, message.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if message.Status != outbox.StatusPending || message.LastError != m.fail.Error() || message.NextAttemptAt.Before(time.Now()) {
+			t.Fatalf("expected the message to be retried later; got %+v", message)
+		}
+
+		// make the retry due
+		_, err = ts.DB.ExecContext(ctx, "UPDATE outbox_messages SET next_attempt_at = now() WHERE id = $1", message.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := dispatcher.DispatchPending(ctx); err != nil {
+			t.Fatal(err)
+		}
+		message, err = ts.Queries.GetOutboxMessage(ctx, message.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if message.Status != outbox.StatusDead || message.Attempts != 2 {
+			t.Errorf("expected the message to be dead; got %+v", message)
+		}
+
+		attempts, err := ts.Queries.ListOutboxAttempts(ctx, message.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(attempts) != 2 || attempts[1].Error != m.fail.Error() {
+			t.Errorf("expected 2 failed attempts; got %+v", attempts)
+		}
+	})
+
+	t.Run("records sends that time out", func(t *testing.T) {
+		m.hang = true
+		defer func() { m.hang = false }()
+
+		slowCfg := cfg
+		slowCfg.Timeout = 50 * time.Millisecond
+		slow := outbox.NewDispatcher(ts.Queries, m, jsonlog.New(io.Discard, jsonlog.LevelInfo), slowCfg, "https://app.example.com")
+
+		message, err := outbox.Add(ctx, ts.Queries, "welcome:3", welcome("slow@example.com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := slow.DispatchNext(ctx); err != nil {
+			t.Fatal(err)
+		}
+		message, err = ts.Queries.GetOutboxMessage(ctx, message.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if message.Status != outbox.StatusPending || message.LastError != context.DeadlineExceeded.Error() {
+			t.Errorf("expected the message to be retried after its timeout; got %+v", message)
+		}
+
+		attempts, err := ts.Queries.ListOutboxAttempts(ctx, message.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(attempts) != 1 || attempts[0].Error != context.DeadlineExceeded.Error() {
+			t.Errorf("expected the attempt to be recorded; got %+v", attempts)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+// Package outbox delivers emails that must only go out when the change they are
+// about is committed. Messages are added with Add within the transaction of the
+// change, so that they are stored or rolled back together with it, and the
+// Dispatcher sends them once they are committed. Failed deliveries are retried
+// with exponential backoff until the message runs out of attempts, and every
+// attempt is recorded.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/queries"
+	"strings"
+)
+
+const (
+	StatusPending = "pending"
+	StatusSending = "sending"
+	StatusSent    = "sent"
+	// StatusDead is the state of messages that ran out of attempts or can't be sent
+	StatusDead = "dead"
+)
+
+// Statuses are all statuses of outbox messages
+var Statuses = []string{StatusPending, StatusSending, StatusSent, StatusDead}
+
+// Add stores the message for the dispatcher. The key identifies the message, a
+// message is only added once per key, e.g. "welcome:42". When it was added
+// before, the stored message is returned. To only send the message when a
+// transaction commits, pass the queries of the transaction:
+//
+//	dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+//		qtx := dbQueries.WithTx(tx)
+//		...
+//		_, err := outbox.Add(ctx, qtx, "welcome:42", mailer.NewMessage(...).To(...))
+//		return err
+//	})
+func Add(ctx context.Context, q *queries.Queries, key string, msg *mailer.MessageBuilder) (queries.OutboxMessage, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return queries.OutboxMessage{}, err
+	}
+
+	message, err := q.AddOutboxMessage(ctx, queries.AddOutboxMessageParams{
+		IdempotencyKey: key,
+		EmailType:      mailer.Name(msg.Email()),
+		Recipients:     strings.Join(msg.Recipients(), ", "),
+		Message:        data,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return q.GetOutboxMessageByKey(ctx, key)
+	}
+
+	return message, err
+}
@@ -0,0 +1,137 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: access_tokens.sql
+
+package queries
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createPersonalAccessToken = `-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (user_id, name, hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, name, hash, scopes, expires_at, last_used_at, created_at
+`
+
+type CreatePersonalAccessTokenParams struct {
+	UserID    int32
+	Name      string
+	Hash      []byte
+	Scopes    string
+	ExpiresAt sql.NullTime
+}
+
+func (q *Queries) CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error) {
+	row := q.db.QueryRowContext(ctx, createPersonalAccessToken,
+		arg.UserID,
+		arg.Name,
+		arg.Hash,
+		arg.Scopes,
+		arg.ExpiresAt,
+	)
+	var i PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Hash,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deletePersonalAccessToken = `-- name: DeletePersonalAccessToken :execrows
+DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2
+`
+
+type DeletePersonalAccessTokenParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) DeletePersonalAccessToken(ctx context.Context, arg DeletePersonalAccessTokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePersonalAccessToken, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getPersonalAccessTokenByHash = `-- name: GetPersonalAccessTokenByHash :one
+SELECT id, user_id, name, hash, scopes, expires_at, last_used_at, created_at FROM personal_access_tokens
+WHERE hash = $1
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND NOT EXISTS (
+    SELECT 1 FROM disabled_users WHERE disabled_users.user_id = personal_access_tokens.user_id
+  )
+`
+
+// tokens of disabled users don't work, they are kept for when the user is enabled
+func (q *Queries) GetPersonalAccessTokenByHash(ctx context.Context, hash []byte) (PersonalAccessToken, error) {
+	row := q.db.QueryRowContext(ctx, getPersonalAccessTokenByHash, hash)
+	var i PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Hash,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listPersonalAccessTokens = `-- name: ListPersonalAccessTokens :many
+SELECT id, user_id, name, hash, scopes, expires_at, last_used_at, created_at FROM personal_access_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListPersonalAccessTokens(ctx context.Context, userID int32) ([]PersonalAccessToken, error) {
+	rows, err := q.db.QueryContext(ctx, listPersonalAccessTokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PersonalAccessToken
+	for rows.Next() {
+		var i PersonalAccessToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Hash,
+			&i.Scopes,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchPersonalAccessToken = `-- name: TouchPersonalAccessToken :exec
+UPDATE personal_access_tokens SET last_used_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) TouchPersonalAccessToken(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, touchPersonalAccessToken, id)
+	return err
+}
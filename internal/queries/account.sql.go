@@ -8,6 +8,7 @@ package queries
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 const createAccount = `-- name: CreateAccount :one
@@ -49,6 +50,39 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 	return i, err
 }
 
+const createPendingAccountLink = `-- name: CreatePendingAccountLink :exec
+INSERT INTO pending_account_links (token_hash, provider_id, account_id)
+VALUES ($1, $2, $3)
+`
+
+type CreatePendingAccountLinkParams struct {
+	TokenHash  []byte
+	ProviderID string
+	AccountID  string
+}
+
+func (q *Queries) CreatePendingAccountLink(ctx context.Context, arg CreatePendingAccountLinkParams) error {
+	_, err := q.db.ExecContext(ctx, createPendingAccountLink, arg.TokenHash, arg.ProviderID, arg.AccountID)
+	return err
+}
+
+const deleteAccountByUserIdAndProvider = `-- name: DeleteAccountByUserIdAndProvider :execrows
+DELETE FROM accounts WHERE user_id = $1 AND provider_id = $2
+`
+
+type DeleteAccountByUserIdAndProviderParams struct {
+	UserID     int32
+	ProviderID sql.NullString
+}
+
+func (q *Queries) DeleteAccountByUserIdAndProvider(ctx context.Context, arg DeleteAccountByUserIdAndProviderParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAccountByUserIdAndProvider, arg.UserID, arg.ProviderID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteAccountsByUserId = `-- name: DeleteAccountsByUserId :exec
 DELETE FROM accounts WHERE user_id = $1
 `
@@ -83,12 +117,18 @@ func (q *Queries) GetAccountById(ctx context.Context, id int32) (Account, error)
 	return i, err
 }
 
-const getAccountByUserId = `-- name: GetAccountByUserId :one
-SELECT id, account_id, provider_id, user_id, access_token, refresh_token, id_token, access_token_expires_at, refresh_token_expires_at, scope, password, created_at, updated_at FROM accounts WHERE user_id = $1
+const getAccountByProvider = `-- name: GetAccountByProvider :one
+SELECT id, account_id, provider_id, user_id, access_token, refresh_token, id_token, access_token_expires_at, refresh_token_expires_at, scope, password, created_at, updated_at FROM accounts
+WHERE provider_id = $1 AND account_id = $2
 `
 
-func (q *Queries) GetAccountByUserId(ctx context.Context, userID int32) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccountByUserId, userID)
+type GetAccountByProviderParams struct {
+	ProviderID sql.NullString
+	AccountID  string
+}
+
+func (q *Queries) GetAccountByProvider(ctx context.Context, arg GetAccountByProviderParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByProvider, arg.ProviderID, arg.AccountID)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -139,6 +179,103 @@ func (q *Queries) GetAccountByUserIdAndProvider(ctx context.Context, arg GetAcco
 	return i, err
 }
 
+const getPasswordAccountByUserId = `-- name: GetPasswordAccountByUserId :one
+SELECT id, account_id, provider_id, user_id, access_token, refresh_token, id_token, access_token_expires_at, refresh_token_expires_at, scope, password, created_at, updated_at FROM accounts WHERE user_id = $1 AND provider_id IS NULL
+`
+
+// the email and password account, social accounts have a provider
+func (q *Queries) GetPasswordAccountByUserId(ctx context.Context, userID int32) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getPasswordAccountByUserId, userID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ProviderID,
+		&i.UserID,
+		&i.AccessToken,
+		&i.RefreshToken,
+		&i.IDToken,
+		&i.AccessTokenExpiresAt,
+		&i.RefreshTokenExpiresAt,
+		&i.Scope,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getPendingAccountLink = `-- name: GetPendingAccountLink :one
+SELECT tokens.user_id, pending_account_links.provider_id, pending_account_links.account_id
+FROM pending_account_links
+JOIN tokens ON tokens.hash = pending_account_links.token_hash
+WHERE pending_account_links.token_hash = $1
+  AND tokens.scope = $2
+  AND tokens.expiry > $3
+`
+
+type GetPendingAccountLinkParams struct {
+	TokenHash []byte
+	Scope     string
+	Expiry    time.Time
+}
+
+type GetPendingAccountLinkRow struct {
+	UserID     int64
+	ProviderID string
+	AccountID  string
+}
+
+func (q *Queries) GetPendingAccountLink(ctx context.Context, arg GetPendingAccountLinkParams) (GetPendingAccountLinkRow, error) {
+	row := q.db.QueryRowContext(ctx, getPendingAccountLink, arg.TokenHash, arg.Scope, arg.Expiry)
+	var i GetPendingAccountLinkRow
+	err := row.Scan(&i.UserID, &i.ProviderID, &i.AccountID)
+	return i, err
+}
+
+const listAccountsForUser = `-- name: ListAccountsForUser :many
+SELECT id, account_id, provider_id, user_id, access_token, refresh_token, id_token, access_token_expires_at, refresh_token_expires_at, scope, password, created_at, updated_at FROM accounts
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListAccountsForUser(ctx context.Context, userID int32) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Account
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.ProviderID,
+			&i.UserID,
+			&i.AccessToken,
+			&i.RefreshToken,
+			&i.IDToken,
+			&i.AccessTokenExpiresAt,
+			&i.RefreshTokenExpiresAt,
+			&i.Scope,
+			&i.Password,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateAccountOAuthTokens = `-- name: UpdateAccountOAuthTokens :exec
 UPDATE accounts 
 SET access_token = $1, 
@@ -0,0 +1,144 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: admin.sql
+
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+LEFT JOIN disabled_users ON disabled_users.user_id = users.id
+WHERE ($1::text = ''
+       OR users.name ILIKE $1::text
+       OR users.email ILIKE $1::text)
+  AND ($2::text = ''
+       OR ($2::text = 'verified' AND users.email_verified AND disabled_users.user_id IS NULL)
+       OR ($2::text = 'unverified' AND NOT users.email_verified AND disabled_users.user_id IS NULL)
+       OR ($2::text = 'disabled' AND disabled_users.user_id IS NOT NULL))
+`
+
+type CountUsersParams struct {
+	Search string
+	Status string
+}
+
+func (q *Queries) CountUsers(ctx context.Context, arg CountUsersParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsers, arg.Search, arg.Status)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const disableUser = `-- name: DisableUser :exec
+INSERT INTO disabled_users (user_id, disabled_by)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO NOTHING
+`
+
+type DisableUserParams struct {
+	UserID     int32
+	DisabledBy sql.NullInt32
+}
+
+func (q *Queries) DisableUser(ctx context.Context, arg DisableUserParams) error {
+	_, err := q.db.ExecContext(ctx, disableUser, arg.UserID, arg.DisabledBy)
+	return err
+}
+
+const enableUser = `-- name: EnableUser :exec
+DELETE FROM disabled_users WHERE user_id = $1
+`
+
+func (q *Queries) EnableUser(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, enableUser, userID)
+	return err
+}
+
+const getDisabledUser = `-- name: GetDisabledUser :one
+SELECT user_id, disabled_by, created_at FROM disabled_users WHERE user_id = $1
+`
+
+func (q *Queries) GetDisabledUser(ctx context.Context, userID int32) (DisabledUser, error) {
+	row := q.db.QueryRowContext(ctx, getDisabledUser, userID)
+	var i DisabledUser
+	err := row.Scan(&i.UserID, &i.DisabledBy, &i.CreatedAt)
+	return i, err
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT users.id, users.name, users.email, users.email_verified, users.image, users.created_at, users.updated_at, disabled_users.created_at AS disabled_at FROM users
+LEFT JOIN disabled_users ON disabled_users.user_id = users.id
+WHERE ($1::text = ''
+       OR users.name ILIKE $1::text
+       OR users.email ILIKE $1::text)
+  AND ($2::text = ''
+       OR ($2::text = 'verified' AND users.email_verified AND disabled_users.user_id IS NULL)
+       OR ($2::text = 'unverified' AND NOT users.email_verified AND disabled_users.user_id IS NULL)
+       OR ($2::text = 'disabled' AND disabled_users.user_id IS NOT NULL))
+ORDER BY users.created_at DESC, users.id DESC
+LIMIT $4::int OFFSET $3::int
+`
+
+type ListUsersParams struct {
+	Search     string
+	Status     string
+	PageOffset int32
+	PageSize   int32
+}
+
+type ListUsersRow struct {
+	ID            int32
+	Name          string
+	Email         string
+	EmailVerified bool
+	Image         sql.NullString
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DisabledAt    sql.NullTime
+}
+
+// users of the back office, newest first. search is an ILIKE pattern matched
+// against the name and the email address, empty for all users. status is one of
+// config.UserStatuses, empty for all users.
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers,
+		arg.Search,
+		arg.Status,
+		arg.PageOffset,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersRow
+	for rows.Next() {
+		var i ListUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.EmailVerified,
+			&i.Image,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DisabledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
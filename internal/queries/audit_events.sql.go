@@ -0,0 +1,156 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: audit_events.sql
+
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const countAuditEvents = `-- name: CountAuditEvents :one
+SELECT COUNT(*) FROM audit_events
+LEFT JOIN users actors ON actors.id = audit_events.actor_id
+LEFT JOIN users targets ON targets.id = audit_events.target_id
+WHERE ($1::text = '' OR audit_events.action = $1::text)
+  AND ($2::text = ''
+       OR actors.email ILIKE $2::text
+       OR targets.email ILIKE $2::text
+       OR audit_events.ip_address ILIKE $2::text)
+  AND ($3::timestamptz IS NULL OR audit_events.created_at >= $3::timestamptz)
+  AND ($4::timestamptz IS NULL OR audit_events.created_at < $4::timestamptz)
+`
+
+type CountAuditEventsParams struct {
+	Action string
+	Search string
+	Since  sql.NullTime
+	Until  sql.NullTime
+}
+
+func (q *Queries) CountAuditEvents(ctx context.Context, arg CountAuditEventsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAuditEvents,
+		arg.Action,
+		arg.Search,
+		arg.Since,
+		arg.Until,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAuditEvent = `-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (action, actor_id, target_id, ip_address, user_agent, metadata)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateAuditEventParams struct {
+	Action    string
+	ActorID   sql.NullInt32
+	TargetID  sql.NullInt32
+	IpAddress string
+	UserAgent string
+	Metadata  json.RawMessage
+}
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error {
+	_, err := q.db.ExecContext(ctx, createAuditEvent,
+		arg.Action,
+		arg.ActorID,
+		arg.TargetID,
+		arg.IpAddress,
+		arg.UserAgent,
+		arg.Metadata,
+	)
+	return err
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT audit_events.id, audit_events.action, audit_events.actor_id, audit_events.target_id,
+       audit_events.ip_address, audit_events.user_agent, audit_events.metadata, audit_events.created_at,
+       COALESCE(actors.email, '')::text AS actor_email,
+       COALESCE(targets.email, '')::text AS target_email
+FROM audit_events
+LEFT JOIN users actors ON actors.id = audit_events.actor_id
+LEFT JOIN users targets ON targets.id = audit_events.target_id
+WHERE ($1::text = '' OR audit_events.action = $1::text)
+  AND ($2::text = ''
+       OR actors.email ILIKE $2::text
+       OR targets.email ILIKE $2::text
+       OR audit_events.ip_address ILIKE $2::text)
+  AND ($3::timestamptz IS NULL OR audit_events.created_at >= $3::timestamptz)
+  AND ($4::timestamptz IS NULL OR audit_events.created_at < $4::timestamptz)
+ORDER BY audit_events.created_at DESC, audit_events.id DESC
+LIMIT $6::int OFFSET $5::int
+`
+
+type ListAuditEventsParams struct {
+	Action     string
+	Search     string
+	Since      sql.NullTime
+	Until      sql.NullTime
+	PageOffset int32
+	PageSize   int32
+}
+
+type ListAuditEventsRow struct {
+	ID          int64
+	Action      string
+	ActorID     sql.NullInt32
+	TargetID    sql.NullInt32
+	IpAddress   string
+	UserAgent   string
+	Metadata    json.RawMessage
+	CreatedAt   time.Time
+	ActorEmail  string
+	TargetEmail string
+}
+
+// events of the back office, newest first. action is empty for all actions, search
+// an ILIKE pattern matched against the email addresses of the actor and the target
+// and the IP address. The events between since and until are listed, both optional.
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]ListAuditEventsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEvents,
+		arg.Action,
+		arg.Search,
+		arg.Since,
+		arg.Until,
+		arg.PageOffset,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAuditEventsRow
+	for rows.Next() {
+		var i ListAuditEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.ActorID,
+			&i.TargetID,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.ActorEmail,
+			&i.TargetEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,59 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: email_change.sql
+
+package queries
+
+import (
+	"context"
+	"time"
+)
+
+const deleteEmailChange = `-- name: DeleteEmailChange :exec
+DELETE FROM email_changes WHERE user_id = $1
+`
+
+func (q *Queries) DeleteEmailChange(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteEmailChange, userID)
+	return err
+}
+
+const getEmailChange = `-- name: GetEmailChange :one
+SELECT user_id, new_email, expiry, created_at FROM email_changes WHERE user_id = $1 AND expiry > $2
+`
+
+type GetEmailChangeParams struct {
+	UserID int32
+	Expiry time.Time
+}
+
+func (q *Queries) GetEmailChange(ctx context.Context, arg GetEmailChangeParams) (EmailChange, error) {
+	row := q.db.QueryRowContext(ctx, getEmailChange, arg.UserID, arg.Expiry)
+	var i EmailChange
+	err := row.Scan(
+		&i.UserID,
+		&i.NewEmail,
+		&i.Expiry,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertEmailChange = `-- name: UpsertEmailChange :exec
+INSERT INTO email_changes (user_id, new_email, expiry)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET new_email = EXCLUDED.new_email, expiry = EXCLUDED.expiry, created_at = NOW()
+`
+
+type UpsertEmailChangeParams struct {
+	UserID   int32
+	NewEmail string
+	Expiry   time.Time
+}
+
+func (q *Queries) UpsertEmailChange(ctx context.Context, arg UpsertEmailChangeParams) error {
+	_, err := q.db.ExecContext(ctx, upsertEmailChange, arg.UserID, arg.NewEmail, arg.Expiry)
+	return err
+}
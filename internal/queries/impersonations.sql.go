@@ -0,0 +1,107 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: impersonations.sql
+
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createImpersonation = `-- name: CreateImpersonation :one
+INSERT INTO impersonations (admin_id, user_id, ip_address, user_agent)
+VALUES ($1, $2, $3, $4)
+RETURNING id, admin_id, user_id, ip_address, user_agent, started_at, ended_at
+`
+
+type CreateImpersonationParams struct {
+	AdminID   sql.NullInt32
+	UserID    sql.NullInt32
+	IpAddress string
+	UserAgent string
+}
+
+func (q *Queries) CreateImpersonation(ctx context.Context, arg CreateImpersonationParams) (Impersonation, error) {
+	row := q.db.QueryRowContext(ctx, createImpersonation,
+		arg.AdminID,
+		arg.UserID,
+		arg.IpAddress,
+		arg.UserAgent,
+	)
+	var i Impersonation
+	err := row.Scan(
+		&i.ID,
+		&i.AdminID,
+		&i.UserID,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.StartedAt,
+		&i.EndedAt,
+	)
+	return i, err
+}
+
+const endImpersonation = `-- name: EndImpersonation :exec
+UPDATE impersonations SET ended_at = NOW()
+WHERE id = $1 AND ended_at IS NULL
+`
+
+func (q *Queries) EndImpersonation(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, endImpersonation, id)
+	return err
+}
+
+const listImpersonationsOfUser = `-- name: ListImpersonationsOfUser :many
+SELECT impersonations.id, impersonations.admin_id, impersonations.user_id, impersonations.ip_address, impersonations.user_agent, impersonations.started_at, impersonations.ended_at, COALESCE(admins.name, '') AS admin_name
+FROM impersonations
+LEFT JOIN users admins ON admins.id = impersonations.admin_id
+WHERE impersonations.user_id = $1
+ORDER BY impersonations.started_at DESC
+LIMIT 10
+`
+
+type ListImpersonationsOfUserRow struct {
+	ID        int32
+	AdminID   sql.NullInt32
+	UserID    sql.NullInt32
+	IpAddress string
+	UserAgent string
+	StartedAt time.Time
+	EndedAt   sql.NullTime
+	AdminName string
+}
+
+func (q *Queries) ListImpersonationsOfUser(ctx context.Context, userID sql.NullInt32) ([]ListImpersonationsOfUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listImpersonationsOfUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListImpersonationsOfUserRow
+	for rows.Next() {
+		var i ListImpersonationsOfUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AdminID,
+			&i.UserID,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.AdminName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
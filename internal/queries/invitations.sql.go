@@ -0,0 +1,253 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: invitations.sql
+
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createInvitation = `-- name: CreateInvitation :one
+INSERT INTO invitations (organization_id, email, role, invited_by, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, organization_id, email, role, invited_by, token_hash, status, expires_at, responded_at, created_at
+`
+
+type CreateInvitationParams struct {
+	OrganizationID int32
+	Email          string
+	Role           string
+	InvitedBy      int32
+	TokenHash      []byte
+	ExpiresAt      time.Time
+}
+
+func (q *Queries) CreateInvitation(ctx context.Context, arg CreateInvitationParams) (Invitation, error) {
+	row := q.db.QueryRowContext(ctx, createInvitation,
+		arg.OrganizationID,
+		arg.Email,
+		arg.Role,
+		arg.InvitedBy,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Email,
+		&i.Role,
+		&i.InvitedBy,
+		&i.TokenHash,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.RespondedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getInvitation = `-- name: GetInvitation :one
+SELECT id, organization_id, email, role, invited_by, token_hash, status, expires_at, responded_at, created_at FROM invitations
+WHERE organization_id = $1 AND id = $2
+`
+
+type GetInvitationParams struct {
+	OrganizationID int32
+	ID             int32
+}
+
+func (q *Queries) GetInvitation(ctx context.Context, arg GetInvitationParams) (Invitation, error) {
+	row := q.db.QueryRowContext(ctx, getInvitation, arg.OrganizationID, arg.ID)
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Email,
+		&i.Role,
+		&i.InvitedBy,
+		&i.TokenHash,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.RespondedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getInvitationByToken = `-- name: GetInvitationByToken :one
+SELECT invitations.id, invitations.organization_id, invitations.email, invitations.role, invitations.invited_by, invitations.token_hash, invitations.status, invitations.expires_at, invitations.responded_at, invitations.created_at, organizations.name AS organization_name, users.name AS inviter_name
+FROM invitations
+JOIN organizations ON organizations.id = invitations.organization_id
+JOIN users ON users.id = invitations.invited_by
+WHERE invitations.token_hash = $1 AND invitations.status = 'pending' AND invitations.expires_at > $2
+`
+
+type GetInvitationByTokenParams struct {
+	TokenHash []byte
+	ExpiresAt time.Time
+}
+
+type GetInvitationByTokenRow struct {
+	ID               int32
+	OrganizationID   int32
+	Email            string
+	Role             string
+	InvitedBy        int32
+	TokenHash        []byte
+	Status           string
+	ExpiresAt        time.Time
+	RespondedAt      sql.NullTime
+	CreatedAt        time.Time
+	OrganizationName string
+	InviterName      string
+}
+
+func (q *Queries) GetInvitationByToken(ctx context.Context, arg GetInvitationByTokenParams) (GetInvitationByTokenRow, error) {
+	row := q.db.QueryRowContext(ctx, getInvitationByToken, arg.TokenHash, arg.ExpiresAt)
+	var i GetInvitationByTokenRow
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Email,
+		&i.Role,
+		&i.InvitedBy,
+		&i.TokenHash,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.RespondedAt,
+		&i.CreatedAt,
+		&i.OrganizationName,
+		&i.InviterName,
+	)
+	return i, err
+}
+
+const hasPendingInvitation = `-- name: HasPendingInvitation :one
+SELECT EXISTS (
+	SELECT 1 FROM invitations
+	WHERE organization_id = $1 AND lower(email) = lower($2)
+		AND status = 'pending' AND expires_at > $3
+)
+`
+
+type HasPendingInvitationParams struct {
+	OrganizationID int32
+	Email          string
+	Now            time.Time
+}
+
+func (q *Queries) HasPendingInvitation(ctx context.Context, arg HasPendingInvitationParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasPendingInvitation, arg.OrganizationID, arg.Email, arg.Now)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listInvitations = `-- name: ListInvitations :many
+SELECT invitations.id, invitations.organization_id, invitations.email, invitations.role, invitations.invited_by, invitations.token_hash, invitations.status, invitations.expires_at, invitations.responded_at, invitations.created_at, users.name AS inviter_name
+FROM invitations
+JOIN users ON users.id = invitations.invited_by
+WHERE invitations.organization_id = $1
+ORDER BY invitations.created_at DESC, invitations.id DESC
+`
+
+type ListInvitationsRow struct {
+	ID             int32
+	OrganizationID int32
+	Email          string
+	Role           string
+	InvitedBy      int32
+	TokenHash      []byte
+	Status         string
+	ExpiresAt      time.Time
+	RespondedAt    sql.NullTime
+	CreatedAt      time.Time
+	InviterName    string
+}
+
+func (q *Queries) ListInvitations(ctx context.Context, organizationID int32) ([]ListInvitationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listInvitations, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListInvitationsRow
+	for rows.Next() {
+		var i ListInvitationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.Email,
+			&i.Role,
+			&i.InvitedBy,
+			&i.TokenHash,
+			&i.Status,
+			&i.ExpiresAt,
+			&i.RespondedAt,
+			&i.CreatedAt,
+			&i.InviterName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const renewInvitation = `-- name: RenewInvitation :execrows
+UPDATE invitations
+SET token_hash = $3, expires_at = $4, invited_by = $5
+WHERE organization_id = $1 AND id = $2 AND status = 'pending'
+`
+
+type RenewInvitationParams struct {
+	OrganizationID int32
+	ID             int32
+	TokenHash      []byte
+	ExpiresAt      time.Time
+	InvitedBy      int32
+}
+
+func (q *Queries) RenewInvitation(ctx context.Context, arg RenewInvitationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, renewInvitation,
+		arg.OrganizationID,
+		arg.ID,
+		arg.TokenHash,
+		arg.ExpiresAt,
+		arg.InvitedBy,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setInvitationStatus = `-- name: SetInvitationStatus :execrows
+UPDATE invitations
+SET status = $2, token_hash = NULL, responded_at = NOW()
+WHERE id = $1 AND status = 'pending'
+`
+
+type SetInvitationStatusParams struct {
+	ID     int32
+	Status string
+}
+
+func (q *Queries) SetInvitationStatus(ctx context.Context, arg SetInvitationStatusParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setInvitationStatus, arg.ID, arg.Status)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
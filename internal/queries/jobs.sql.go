@@ -0,0 +1,164 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: jobs.sql
+
+package queries
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const claimJob = `-- name: ClaimJob :one
+UPDATE jobs
+SET status = 'running', attempts = attempts + 1, locked_at = CURRENT_TIMESTAMP
+WHERE id = (
+	SELECT id FROM jobs
+	WHERE (status = 'pending' AND run_at <= CURRENT_TIMESTAMP)
+	   OR (status = 'running' AND locked_at < $1::timestamptz)
+	ORDER BY run_at, id
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING id, kind, payload, status, attempts, max_attempts, run_at, locked_at, last_error, created_at, finished_at
+`
+
+// the next runnable job, locked for the worker. Running jobs locked before
+// stale_before belong to a worker that died and are picked again.
+func (q *Queries) ClaimJob(ctx context.Context, staleBefore time.Time) (Job, error) {
+	row := q.db.QueryRowContext(ctx, claimJob, staleBefore)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const completeJob = `-- name: CompleteJob :exec
+UPDATE jobs
+SET status = 'done', locked_at = NULL, last_error = '', finished_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+func (q *Queries) CompleteJob(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, completeJob, id)
+	return err
+}
+
+const deleteFinishedJobs = `-- name: DeleteFinishedJobs :execrows
+DELETE FROM jobs WHERE status = 'done' AND finished_at < $1::timestamptz
+`
+
+func (q *Queries) DeleteFinishedJobs(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFinishedJobs, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const enqueueJob = `-- name: EnqueueJob :one
+INSERT INTO jobs (kind, payload, max_attempts, run_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, kind, payload, status, attempts, max_attempts, run_at, locked_at, last_error, created_at, finished_at
+`
+
+type EnqueueJobParams struct {
+	Kind        string
+	Payload     json.RawMessage
+	MaxAttempts int32
+	RunAt       time.Time
+}
+
+func (q *Queries) EnqueueJob(ctx context.Context, arg EnqueueJobParams) (Job, error) {
+	row := q.db.QueryRowContext(ctx, enqueueJob,
+		arg.Kind,
+		arg.Payload,
+		arg.MaxAttempts,
+		arg.RunAt,
+	)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const getJob = `-- name: GetJob :one
+SELECT id, kind, payload, status, attempts, max_attempts, run_at, locked_at, last_error, created_at, finished_at FROM jobs WHERE id = $1
+`
+
+func (q *Queries) GetJob(ctx context.Context, id int64) (Job, error) {
+	row := q.db.QueryRowContext(ctx, getJob, id)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const killJob = `-- name: KillJob :exec
+UPDATE jobs
+SET status = 'dead', locked_at = NULL, last_error = $2, finished_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type KillJobParams struct {
+	ID        int64
+	LastError string
+}
+
+// moves the job to the dead letters, it isn't retried anymore
+func (q *Queries) KillJob(ctx context.Context, arg KillJobParams) error {
+	_, err := q.db.ExecContext(ctx, killJob, arg.ID, arg.LastError)
+	return err
+}
+
+const retryJob = `-- name: RetryJob :exec
+UPDATE jobs
+SET status = 'pending', locked_at = NULL, run_at = $2, last_error = $3
+WHERE id = $1
+`
+
+type RetryJobParams struct {
+	ID        int64
+	RunAt     time.Time
+	LastError string
+}
+
+func (q *Queries) RetryJob(ctx context.Context, arg RetryJobParams) error {
+	_, err := q.db.ExecContext(ctx, retryJob, arg.ID, arg.RunAt, arg.LastError)
+	return err
+}
@@ -0,0 +1,85 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: login_attempts.sql
+
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const deleteLoginAttempt = `-- name: DeleteLoginAttempt :exec
+DELETE FROM login_attempts
+WHERE email = $1
+`
+
+func (q *Queries) DeleteLoginAttempt(ctx context.Context, email string) error {
+	_, err := q.db.ExecContext(ctx, deleteLoginAttempt, email)
+	return err
+}
+
+const getLoginAttempt = `-- name: GetLoginAttempt :one
+SELECT email, failed_count, last_failed_at, locked_until FROM login_attempts
+WHERE email = $1
+`
+
+func (q *Queries) GetLoginAttempt(ctx context.Context, email string) (LoginAttempt, error) {
+	row := q.db.QueryRowContext(ctx, getLoginAttempt, email)
+	var i LoginAttempt
+	err := row.Scan(
+		&i.Email,
+		&i.FailedCount,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const lockLogin = `-- name: LockLogin :exec
+UPDATE login_attempts
+SET locked_until = $2
+WHERE email = $1
+`
+
+type LockLoginParams struct {
+	Email       string
+	LockedUntil sql.NullTime
+}
+
+func (q *Queries) LockLogin(ctx context.Context, arg LockLoginParams) error {
+	_, err := q.db.ExecContext(ctx, lockLogin, arg.Email, arg.LockedUntil)
+	return err
+}
+
+const recordFailedLogin = `-- name: RecordFailedLogin :one
+INSERT INTO login_attempts (email, failed_count, last_failed_at)
+VALUES ($1, 1, NOW())
+ON CONFLICT (email) DO UPDATE
+SET failed_count = CASE
+		WHEN login_attempts.last_failed_at < $2 THEN 1
+		ELSE login_attempts.failed_count + 1
+	END,
+	last_failed_at = NOW()
+RETURNING email, failed_count, last_failed_at, locked_until
+`
+
+type RecordFailedLoginParams struct {
+	Email       string
+	ResetBefore time.Time
+}
+
+// failures older than reset_before are forgotten and counting starts over
+func (q *Queries) RecordFailedLogin(ctx context.Context, arg RecordFailedLoginParams) (LoginAttempt, error) {
+	row := q.db.QueryRowContext(ctx, recordFailedLogin, arg.Email, arg.ResetBefore)
+	var i LoginAttempt
+	err := row.Scan(
+		&i.Email,
+		&i.FailedCount,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
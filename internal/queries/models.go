@@ -6,6 +6,7 @@ package queries
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -25,12 +26,181 @@ type Account struct {
 	UpdatedAt             time.Time
 }
 
+type AuditEvent struct {
+	ID        int64
+	Action    string
+	ActorID   sql.NullInt32
+	TargetID  sql.NullInt32
+	IpAddress string
+	UserAgent string
+	Metadata  json.RawMessage
+	CreatedAt time.Time
+}
+
 type Author struct {
 	ID   int32
 	Name string
 	Bio  sql.NullString
 }
 
+type DisabledUser struct {
+	UserID     int32
+	DisabledBy sql.NullInt32
+	CreatedAt  time.Time
+}
+
+type EmailChange struct {
+	UserID    int32
+	NewEmail  string
+	Expiry    time.Time
+	CreatedAt time.Time
+}
+
+type Impersonation struct {
+	ID        int32
+	AdminID   sql.NullInt32
+	UserID    sql.NullInt32
+	IpAddress string
+	UserAgent string
+	StartedAt time.Time
+	EndedAt   sql.NullTime
+}
+
+type Invitation struct {
+	ID             int32
+	OrganizationID int32
+	Email          string
+	Role           string
+	InvitedBy      int32
+	TokenHash      []byte
+	Status         string
+	ExpiresAt      time.Time
+	RespondedAt    sql.NullTime
+	CreatedAt      time.Time
+}
+
+type Job struct {
+	ID          int64
+	Kind        string
+	Payload     json.RawMessage
+	Status      string
+	Attempts    int32
+	MaxAttempts int32
+	RunAt       time.Time
+	LockedAt    sql.NullTime
+	LastError   string
+	CreatedAt   time.Time
+	FinishedAt  sql.NullTime
+}
+
+type LoginAttempt struct {
+	Email        string
+	FailedCount  int32
+	LastFailedAt time.Time
+	LockedUntil  sql.NullTime
+}
+
+type Membership struct {
+	OrganizationID int32
+	UserID         int32
+	Role           string
+	CreatedAt      time.Time
+}
+
+type Organization struct {
+	ID        int32
+	Name      string
+	Personal  bool
+	CreatedAt time.Time
+}
+
+type OutboxAttempt struct {
+	ID         int64
+	MessageID  int64
+	Attempt    int32
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Error      string
+}
+
+type OutboxMessage struct {
+	ID             int64
+	IdempotencyKey string
+	EmailType      string
+	Recipients     string
+	Message        json.RawMessage
+	Status         string
+	Attempts       int32
+	MaxAttempts    int32
+	NextAttemptAt  time.Time
+	LockedAt       sql.NullTime
+	LastError      string
+	CreatedAt      time.Time
+	SentAt         sql.NullTime
+}
+
+type PendingAccountLink struct {
+	TokenHash  []byte
+	ProviderID string
+	AccountID  string
+}
+
+type Permission struct {
+	ID          int32
+	Name        string
+	Description string
+}
+
+type PersonalAccessToken struct {
+	ID         int32
+	UserID     int32
+	Name       string
+	Hash       []byte
+	Scopes     string
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	CreatedAt  time.Time
+}
+
+type Project struct {
+	ID             int32
+	OrganizationID int32
+	Name           string
+	Description    string
+	CreatedBy      sql.NullInt32
+	CreatedAt      time.Time
+}
+
+type RecoveryCode struct {
+	Hash      []byte
+	UserID    int32
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type Role struct {
+	ID          int32
+	Name        string
+	Description string
+	CreatedAt   time.Time
+}
+
+type RolePermission struct {
+	RoleID       int32
+	PermissionID int32
+}
+
+type ScheduledTask struct {
+	Name           string
+	Schedule       string
+	NextRunAt      time.Time
+	LastStatus     string
+	LastError      string
+	LastStartedAt  sql.NullTime
+	LastFinishedAt sql.NullTime
+	LastDurationMs int64
+}
+
 type Session struct {
 	Token  string
 	Data   []byte
@@ -44,6 +214,14 @@ type Token struct {
 	Scope  string
 }
 
+type TotpSecret struct {
+	UserID       int32
+	Secret       string
+	ConfirmedAt  sql.NullTime
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
 type User struct {
 	ID            int32
 	Name          string
@@ -53,3 +231,35 @@ type User struct {
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 }
+
+type UserRole struct {
+	UserID    int32
+	RoleID    int32
+	CreatedAt time.Time
+}
+
+type UserSession struct {
+	ID         int32
+	Token      string
+	UserID     int32
+	UserAgent  string
+	IpAddress  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+}
+
+type WebauthnCredential struct {
+	ID              int32
+	UserID          int32
+	Name            string
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	Transports      string
+	Aaguid          []byte
+	Flags           int16
+	SignCount       int64
+	LastUsedAt      sql.NullTime
+	CreatedAt       time.Time
+}
@@ -0,0 +1,160 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: organizations.sql
+
+package queries
+
+import (
+	"context"
+	"time"
+)
+
+const createMembership = `-- name: CreateMembership :exec
+INSERT INTO memberships (organization_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (organization_id, user_id) DO NOTHING
+`
+
+type CreateMembershipParams struct {
+	OrganizationID int32
+	UserID         int32
+	Role           string
+}
+
+func (q *Queries) CreateMembership(ctx context.Context, arg CreateMembershipParams) error {
+	_, err := q.db.ExecContext(ctx, createMembership, arg.OrganizationID, arg.UserID, arg.Role)
+	return err
+}
+
+const createOrganization = `-- name: CreateOrganization :one
+INSERT INTO organizations (name, personal)
+VALUES ($1, $2)
+RETURNING id, name, personal, created_at
+`
+
+type CreateOrganizationParams struct {
+	Name     string
+	Personal bool
+}
+
+func (q *Queries) CreateOrganization(ctx context.Context, arg CreateOrganizationParams) (Organization, error) {
+	row := q.db.QueryRowContext(ctx, createOrganization, arg.Name, arg.Personal)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Personal,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const isMemberByEmail = `-- name: IsMemberByEmail :one
+SELECT EXISTS (
+	SELECT 1
+	FROM memberships
+	JOIN users ON users.id = memberships.user_id
+	WHERE memberships.organization_id = $1 AND lower(users.email) = lower($2)
+)
+`
+
+type IsMemberByEmailParams struct {
+	OrganizationID int32
+	Email          string
+}
+
+func (q *Queries) IsMemberByEmail(ctx context.Context, arg IsMemberByEmailParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isMemberByEmail, arg.OrganizationID, arg.Email)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listMembers = `-- name: ListMembers :many
+SELECT users.id, users.name, users.email, memberships.role, memberships.created_at
+FROM memberships
+JOIN users ON users.id = memberships.user_id
+WHERE memberships.organization_id = $1
+ORDER BY memberships.created_at, users.id
+`
+
+type ListMembersRow struct {
+	ID        int32
+	Name      string
+	Email     string
+	Role      string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListMembers(ctx context.Context, organizationID int32) ([]ListMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMembers, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMembersRow
+	for rows.Next() {
+		var i ListMembersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.Role,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMembershipsForUser = `-- name: ListMembershipsForUser :many
+SELECT organizations.id, organizations.name, organizations.personal, memberships.role
+FROM memberships
+JOIN organizations ON organizations.id = memberships.organization_id
+WHERE memberships.user_id = $1
+ORDER BY organizations.personal DESC, organizations.name, organizations.id
+`
+
+type ListMembershipsForUserRow struct {
+	ID       int32
+	Name     string
+	Personal bool
+	Role     string
+}
+
+func (q *Queries) ListMembershipsForUser(ctx context.Context, userID int32) ([]ListMembershipsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMembershipsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMembershipsForUserRow
+	for rows.Next() {
+		var i ListMembershipsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Personal,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,303 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: outbox.sql
+
+package queries
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const addOutboxAttempt = `-- name: AddOutboxAttempt :exec
+INSERT INTO outbox_attempts (message_id, attempt, started_at, error)
+VALUES ($1, $2, $3, $4)
+`
+
+type AddOutboxAttemptParams struct {
+	MessageID int64
+	Attempt   int32
+	StartedAt time.Time
+	Error     string
+}
+
+func (q *Queries) AddOutboxAttempt(ctx context.Context, arg AddOutboxAttemptParams) error {
+	_, err := q.db.ExecContext(ctx, addOutboxAttempt,
+		arg.MessageID,
+		arg.Attempt,
+		arg.StartedAt,
+		arg.Error,
+	)
+	return err
+}
+
+const addOutboxMessage = `-- name: AddOutboxMessage :one
+INSERT INTO outbox_messages (idempotency_key, email_type, recipients, message)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (idempotency_key) DO NOTHING
+RETURNING id, idempotency_key, email_type, recipients, message, status, attempts, max_attempts, next_attempt_at, locked_at, last_error, created_at, sent_at
+`
+
+type AddOutboxMessageParams struct {
+	IdempotencyKey string
+	EmailType      string
+	Recipients     string
+	Message        json.RawMessage
+}
+
+// adds the message unless one with the key was added before, then no row is returned
+func (q *Queries) AddOutboxMessage(ctx context.Context, arg AddOutboxMessageParams) (OutboxMessage, error) {
+	row := q.db.QueryRowContext(ctx, addOutboxMessage,
+		arg.IdempotencyKey,
+		arg.EmailType,
+		arg.Recipients,
+		arg.Message,
+	)
+	var i OutboxMessage
+	err := row.Scan(
+		&i.ID,
+		&i.IdempotencyKey,
+		&i.EmailType,
+		&i.Recipients,
+		&i.Message,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.NextAttemptAt,
+		&i.LockedAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.SentAt,
+	)
+	return i, err
+}
+
+const claimOutboxMessage = `-- name: ClaimOutboxMessage :one
+UPDATE outbox_messages
+SET status = 'sending', attempts = attempts + 1, locked_at = CURRENT_TIMESTAMP
+WHERE id = (
+	SELECT id FROM outbox_messages
+	WHERE (status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP)
+	   OR (status = 'sending' AND locked_at < $1::timestamptz)
+	ORDER BY next_attempt_at, id
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING id, idempotency_key, email_type, recipients, message, status, attempts, max_attempts, next_attempt_at, locked_at, last_error, created_at, sent_at
+`
+
+// the next message due, locked for the dispatcher. Messages locked before
+// stale_before belong to a dispatcher that died and are picked again.
+func (q *Queries) ClaimOutboxMessage(ctx context.Context, staleBefore time.Time) (OutboxMessage, error) {
+	row := q.db.QueryRowContext(ctx, claimOutboxMessage, staleBefore)
+	var i OutboxMessage
+	err := row.Scan(
+		&i.ID,
+		&i.IdempotencyKey,
+		&i.EmailType,
+		&i.Recipients,
+		&i.Message,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.NextAttemptAt,
+		&i.LockedAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.SentAt,
+	)
+	return i, err
+}
+
+const deleteSentOutboxMessages = `-- name: DeleteSentOutboxMessages :execrows
+DELETE FROM outbox_messages WHERE status = 'sent' AND sent_at < $1::timestamptz
+`
+
+func (q *Queries) DeleteSentOutboxMessages(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSentOutboxMessages, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getOutboxMessage = `-- name: GetOutboxMessage :one
+SELECT id, idempotency_key, email_type, recipients, message, status, attempts, max_attempts, next_attempt_at, locked_at, last_error, created_at, sent_at FROM outbox_messages WHERE id = $1
+`
+
+func (q *Queries) GetOutboxMessage(ctx context.Context, id int64) (OutboxMessage, error) {
+	row := q.db.QueryRowContext(ctx, getOutboxMessage, id)
+	var i OutboxMessage
+	err := row.Scan(
+		&i.ID,
+		&i.IdempotencyKey,
+		&i.EmailType,
+		&i.Recipients,
+		&i.Message,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.NextAttemptAt,
+		&i.LockedAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.SentAt,
+	)
+	return i, err
+}
+
+const getOutboxMessageByKey = `-- name: GetOutboxMessageByKey :one
+SELECT id, idempotency_key, email_type, recipients, message, status, attempts, max_attempts, next_attempt_at, locked_at, last_error, created_at, sent_at FROM outbox_messages WHERE idempotency_key = $1
+`
+
+func (q *Queries) GetOutboxMessageByKey(ctx context.Context, idempotencyKey string) (OutboxMessage, error) {
+	row := q.db.QueryRowContext(ctx, getOutboxMessageByKey, idempotencyKey)
+	var i OutboxMessage
+	err := row.Scan(
+		&i.ID,
+		&i.IdempotencyKey,
+		&i.EmailType,
+		&i.Recipients,
+		&i.Message,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.NextAttemptAt,
+		&i.LockedAt,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.SentAt,
+	)
+	return i, err
+}
+
+const killOutboxMessage = `-- name: KillOutboxMessage :exec
+UPDATE outbox_messages
+SET status = 'dead', locked_at = NULL, last_error = $2
+WHERE id = $1
+`
+
+type KillOutboxMessageParams struct {
+	ID        int64
+	LastError string
+}
+
+// gives up on the message, it isn't retried anymore
+func (q *Queries) KillOutboxMessage(ctx context.Context, arg KillOutboxMessageParams) error {
+	_, err := q.db.ExecContext(ctx, killOutboxMessage, arg.ID, arg.LastError)
+	return err
+}
+
+const listOutboxAttempts = `-- name: ListOutboxAttempts :many
+SELECT id, message_id, attempt, started_at, finished_at, error FROM outbox_attempts WHERE message_id = $1 ORDER BY attempt, id
+`
+
+func (q *Queries) ListOutboxAttempts(ctx context.Context, messageID int64) ([]OutboxAttempt, error) {
+	rows, err := q.db.QueryContext(ctx, listOutboxAttempts, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OutboxAttempt
+	for rows.Next() {
+		var i OutboxAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.Attempt,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOutboxMessages = `-- name: ListOutboxMessages :many
+SELECT id, idempotency_key, email_type, recipients, message, status, attempts, max_attempts, next_attempt_at, locked_at, last_error, created_at, sent_at FROM outbox_messages
+WHERE $2::text = '' OR status = $2::text
+ORDER BY id DESC
+LIMIT $1
+`
+
+type ListOutboxMessagesParams struct {
+	Limit  int32
+	Status string
+}
+
+// the latest messages, of the status unless it is empty
+func (q *Queries) ListOutboxMessages(ctx context.Context, arg ListOutboxMessagesParams) ([]OutboxMessage, error) {
+	rows, err := q.db.QueryContext(ctx, listOutboxMessages, arg.Limit, arg.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OutboxMessage
+	for rows.Next() {
+		var i OutboxMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.IdempotencyKey,
+			&i.EmailType,
+			&i.Recipients,
+			&i.Message,
+			&i.Status,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.NextAttemptAt,
+			&i.LockedAt,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.SentAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOutboxMessageSent = `-- name: MarkOutboxMessageSent :exec
+UPDATE outbox_messages
+SET status = 'sent', message = '{}', locked_at = NULL, last_error = '', sent_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+func (q *Queries) MarkOutboxMessageSent(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markOutboxMessageSent, id)
+	return err
+}
+
+const retryOutboxMessage = `-- name: RetryOutboxMessage :exec
+UPDATE outbox_messages
+SET status = 'pending', locked_at = NULL, next_attempt_at = $2, last_error = $3
+WHERE id = $1
+`
+
+type RetryOutboxMessageParams struct {
+	ID            int64
+	NextAttemptAt time.Time
+	LastError     string
+}
+
+func (q *Queries) RetryOutboxMessage(ctx context.Context, arg RetryOutboxMessageParams) error {
+	_, err := q.db.ExecContext(ctx, retryOutboxMessage, arg.ID, arg.NextAttemptAt, arg.LastError)
+	return err
+}
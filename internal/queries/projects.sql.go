@@ -0,0 +1,123 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: projects.sql
+
+package queries
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createProject = `-- name: CreateProject :one
+INSERT INTO projects (organization_id, name, description, created_by)
+VALUES ($1, $2, $3, $4)
+RETURNING id, organization_id, name, description, created_by, created_at
+`
+
+type CreateProjectParams struct {
+	OrganizationID int32
+	Name           string
+	Description    string
+	CreatedBy      sql.NullInt32
+}
+
+func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (Project, error) {
+	row := q.db.QueryRowContext(ctx, createProject,
+		arg.OrganizationID,
+		arg.Name,
+		arg.Description,
+		arg.CreatedBy,
+	)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteProject = `-- name: DeleteProject :execrows
+DELETE FROM projects
+WHERE organization_id = $1 AND id = $2
+`
+
+type DeleteProjectParams struct {
+	OrganizationID int32
+	ID             int32
+}
+
+func (q *Queries) DeleteProject(ctx context.Context, arg DeleteProjectParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteProject, arg.OrganizationID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getProject = `-- name: GetProject :one
+SELECT id, organization_id, name, description, created_by, created_at FROM projects
+WHERE organization_id = $1 AND id = $2
+`
+
+type GetProjectParams struct {
+	OrganizationID int32
+	ID             int32
+}
+
+func (q *Queries) GetProject(ctx context.Context, arg GetProjectParams) (Project, error) {
+	row := q.db.QueryRowContext(ctx, getProject, arg.OrganizationID, arg.ID)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listProjects = `-- name: ListProjects :many
+
+SELECT id, organization_id, name, description, created_by, created_at FROM projects
+WHERE organization_id = $1
+ORDER BY created_at DESC, id DESC
+`
+
+// Every project query is filtered by organization_id, see service.Tenant.
+func (q *Queries) ListProjects(ctx context.Context, organizationID int32) ([]Project, error) {
+	rows, err := q.db.QueryContext(ctx, listProjects, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Project
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
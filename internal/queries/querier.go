@@ -6,33 +6,162 @@ package queries
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 type Querier interface {
+	AddOutboxAttempt(ctx context.Context, arg AddOutboxAttemptParams) error
+	// adds the message unless one with the key was added before, then no row is returned
+	AddOutboxMessage(ctx context.Context, arg AddOutboxMessageParams) (OutboxMessage, error)
+	AssignRole(ctx context.Context, arg AssignRoleParams) error
+	// the next runnable job, locked for the worker. Running jobs locked before
+	// stale_before belong to a worker that died and are picked again.
+	ClaimJob(ctx context.Context, staleBefore time.Time) (Job, error)
+	// the next message due, locked for the dispatcher. Messages locked before
+	// stale_before belong to a dispatcher that died and are picked again.
+	ClaimOutboxMessage(ctx context.Context, staleBefore time.Time) (OutboxMessage, error)
+	// no rows when the task isn't due, e.g. because another replica just ran it
+	ClaimScheduledTask(ctx context.Context, arg ClaimScheduledTaskParams) (int64, error)
+	CompleteJob(ctx context.Context, id int64) error
+	ConfirmTOTPSecret(ctx context.Context, userID int32) error
+	ConsumeToken(ctx context.Context, arg ConsumeTokenParams) (int64, error)
+	CountAuditEvents(ctx context.Context, arg CountAuditEventsParams) (int64, error)
+	CountUnusedRecoveryCodes(ctx context.Context, userID int32) (int64, error)
+	CountUsers(ctx context.Context, arg CountUsersParams) (int64, error)
+	CountWebAuthnCredentialsForUser(ctx context.Context, userID int32) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error
 	CreateAuthor(ctx context.Context, arg CreateAuthorParams) (Author, error)
+	CreateImpersonation(ctx context.Context, arg CreateImpersonationParams) (Impersonation, error)
+	CreateInvitation(ctx context.Context, arg CreateInvitationParams) (Invitation, error)
+	CreateMembership(ctx context.Context, arg CreateMembershipParams) error
+	CreateOrganization(ctx context.Context, arg CreateOrganizationParams) (Organization, error)
+	CreatePendingAccountLink(ctx context.Context, arg CreatePendingAccountLinkParams) error
+	CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error)
+	CreateProject(ctx context.Context, arg CreateProjectParams) (Project, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error
 	CreateToken(ctx context.Context, arg CreateTokenParams) (Token, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateUserSession(ctx context.Context, arg CreateUserSessionParams) (UserSession, error)
+	CreateWebAuthnCredential(ctx context.Context, arg CreateWebAuthnCredentialParams) (WebauthnCredential, error)
+	DeleteAccountByUserIdAndProvider(ctx context.Context, arg DeleteAccountByUserIdAndProviderParams) (int64, error)
 	DeleteAccountsByUserId(ctx context.Context, userID int32) error
+	DeleteAllExpiredUserSessions(ctx context.Context) (int64, error)
 	DeleteAllForUser(ctx context.Context, arg DeleteAllForUserParams) error
+	DeleteAllUserSessions(ctx context.Context, userID int32) error
 	DeleteAuthor(ctx context.Context, id int32) error
+	DeleteEmailChange(ctx context.Context, userID int32) error
+	DeleteExpiredSessions(ctx context.Context) (int64, error)
+	DeleteExpiredTokens(ctx context.Context) (int64, error)
+	DeleteExpiredUserSessions(ctx context.Context, userID int32) error
+	DeleteFinishedJobs(ctx context.Context, before time.Time) (int64, error)
+	DeleteLoginAttempt(ctx context.Context, email string) error
+	DeleteOtherUserSessions(ctx context.Context, arg DeleteOtherUserSessionsParams) error
+	DeletePersonalAccessToken(ctx context.Context, arg DeletePersonalAccessTokenParams) (int64, error)
+	DeleteProject(ctx context.Context, arg DeleteProjectParams) (int64, error)
+	DeleteRecoveryCodesForUser(ctx context.Context, userID int32) error
+	DeleteSentOutboxMessages(ctx context.Context, before time.Time) (int64, error)
+	DeleteTOTPSecret(ctx context.Context, userID int32) error
 	DeleteToken(ctx context.Context, hash []byte) error
 	DeleteTokensByUserId(ctx context.Context, userID int64) error
 	DeleteUser(ctx context.Context, id int32) error
+	DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) (int64, error)
+	DeleteUserSessionByToken(ctx context.Context, token string) error
+	DeleteWebAuthnCredential(ctx context.Context, arg DeleteWebAuthnCredentialParams) (int64, error)
+	DisableUser(ctx context.Context, arg DisableUserParams) error
+	EnableUser(ctx context.Context, userID int32) error
+	EndImpersonation(ctx context.Context, id int32) error
+	EnqueueJob(ctx context.Context, arg EnqueueJobParams) (Job, error)
+	FinishScheduledTask(ctx context.Context, arg FinishScheduledTaskParams) error
 	GetAccountById(ctx context.Context, id int32) (Account, error)
-	GetAccountByUserId(ctx context.Context, userID int32) (Account, error)
+	GetAccountByProvider(ctx context.Context, arg GetAccountByProviderParams) (Account, error)
 	GetAccountByUserIdAndProvider(ctx context.Context, arg GetAccountByUserIdAndProviderParams) (Account, error)
 	GetAuthor(ctx context.Context, id int32) (Author, error)
+	GetDisabledUser(ctx context.Context, userID int32) (DisabledUser, error)
+	GetEmailChange(ctx context.Context, arg GetEmailChangeParams) (EmailChange, error)
+	GetInvitation(ctx context.Context, arg GetInvitationParams) (Invitation, error)
+	GetInvitationByToken(ctx context.Context, arg GetInvitationByTokenParams) (GetInvitationByTokenRow, error)
+	GetJob(ctx context.Context, id int64) (Job, error)
+	GetLoginAttempt(ctx context.Context, email string) (LoginAttempt, error)
+	GetOutboxMessage(ctx context.Context, id int64) (OutboxMessage, error)
+	GetOutboxMessageByKey(ctx context.Context, idempotencyKey string) (OutboxMessage, error)
+	// the email and password account, social accounts have a provider
+	GetPasswordAccountByUserId(ctx context.Context, userID int32) (Account, error)
+	GetPendingAccountLink(ctx context.Context, arg GetPendingAccountLinkParams) (GetPendingAccountLinkRow, error)
+	// tokens of disabled users don't work, they are kept for when the user is enabled
+	GetPersonalAccessTokenByHash(ctx context.Context, hash []byte) (PersonalAccessToken, error)
+	GetProject(ctx context.Context, arg GetProjectParams) (Project, error)
+	GetRoleByName(ctx context.Context, name string) (Role, error)
 	GetSessionByToken(ctx context.Context, token string) (Session, error)
+	GetTOTPSecret(ctx context.Context, userID int32) (TotpSecret, error)
 	GetTokensForUser(ctx context.Context, userID int64) (Token, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserById(ctx context.Context, id int32) (User, error)
 	GetUserByToken(ctx context.Context, arg GetUserByTokenParams) (GetUserByTokenRow, error)
+	GetUserSessionByToken(ctx context.Context, token string) (UserSession, error)
+	GetWebAuthnCredentialByCredentialID(ctx context.Context, credentialID []byte) (WebauthnCredential, error)
+	HasPendingInvitation(ctx context.Context, arg HasPendingInvitationParams) (bool, error)
+	IsMemberByEmail(ctx context.Context, arg IsMemberByEmailParams) (bool, error)
+	// moves the job to the dead letters, it isn't retried anymore
+	KillJob(ctx context.Context, arg KillJobParams) error
+	// gives up on the message, it isn't retried anymore
+	KillOutboxMessage(ctx context.Context, arg KillOutboxMessageParams) error
+	ListAccountsForUser(ctx context.Context, userID int32) ([]Account, error)
+	// events of the back office, newest first. action is empty for all actions, search
+	// an ILIKE pattern matched against the email addresses of the actor and the target
+	// and the IP address. The events between since and until are listed, both optional.
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]ListAuditEventsRow, error)
 	ListAuthors(ctx context.Context) ([]Author, error)
+	ListImpersonationsOfUser(ctx context.Context, userID sql.NullInt32) ([]ListImpersonationsOfUserRow, error)
+	ListInvitations(ctx context.Context, organizationID int32) ([]ListInvitationsRow, error)
+	ListMembers(ctx context.Context, organizationID int32) ([]ListMembersRow, error)
+	ListMembershipsForUser(ctx context.Context, userID int32) ([]ListMembershipsForUserRow, error)
+	ListOutboxAttempts(ctx context.Context, messageID int64) ([]OutboxAttempt, error)
+	// the latest messages, of the status unless it is empty
+	ListOutboxMessages(ctx context.Context, arg ListOutboxMessagesParams) ([]OutboxMessage, error)
+	ListPermissionsForUser(ctx context.Context, userID int32) ([]string, error)
+	ListPersonalAccessTokens(ctx context.Context, userID int32) ([]PersonalAccessToken, error)
+	// Every project query is filtered by organization_id, see service.Tenant.
+	ListProjects(ctx context.Context, organizationID int32) ([]Project, error)
+	ListRoles(ctx context.Context) ([]Role, error)
+	ListRolesForUser(ctx context.Context, userID int32) ([]Role, error)
+	ListScheduledTasks(ctx context.Context) ([]ScheduledTask, error)
+	ListUnverifiedUsersCreatedBefore(ctx context.Context, arg ListUnverifiedUsersCreatedBeforeParams) ([]User, error)
+	ListUserSessions(ctx context.Context, userID int32) ([]UserSession, error)
+	// users of the back office, newest first. search is an ILIKE pattern matched
+	// against the name and the email address, empty for all users. status is one of
+	// config.UserStatuses, empty for all users.
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error)
+	ListWebAuthnCredentialsForUser(ctx context.Context, userID int32) ([]WebauthnCredential, error)
+	LockLogin(ctx context.Context, arg LockLoginParams) error
+	MarkOutboxMessageSent(ctx context.Context, id int64) error
+	// failures older than reset_before are forgotten and counting starts over
+	RecordFailedLogin(ctx context.Context, arg RecordFailedLoginParams) (LoginAttempt, error)
+	// a changed schedule starts over with its next run
+	RegisterScheduledTask(ctx context.Context, arg RegisterScheduledTaskParams) error
+	RemoveRole(ctx context.Context, arg RemoveRoleParams) (int64, error)
+	RenameWebAuthnCredential(ctx context.Context, arg RenameWebAuthnCredentialParams) (int64, error)
+	RenewInvitation(ctx context.Context, arg RenewInvitationParams) (int64, error)
+	RetryJob(ctx context.Context, arg RetryJobParams) error
+	RetryOutboxMessage(ctx context.Context, arg RetryOutboxMessageParams) error
+	SetInvitationStatus(ctx context.Context, arg SetInvitationStatusParams) (int64, error)
+	TouchPersonalAccessToken(ctx context.Context, id int32) error
+	TouchUserSession(ctx context.Context, id int32) error
+	// session level, the lock must be released on the same connection
+	TryLockScheduledTask(ctx context.Context, name string) (bool, error)
+	UnlockScheduledTask(ctx context.Context, name string) error
 	UpdateAccountOAuthTokens(ctx context.Context, arg UpdateAccountOAuthTokensParams) error
 	UpdateAccountPassword(ctx context.Context, arg UpdateAccountPasswordParams) error
 	UpdateAuthor(ctx context.Context, arg UpdateAuthorParams) error
+	UpdateTOTPLastUsedStep(ctx context.Context, arg UpdateTOTPLastUsedStepParams) (int64, error)
+	UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (User, error)
 	UpdateUserNameAndImage(ctx context.Context, arg UpdateUserNameAndImageParams) (User, error)
+	UpdateWebAuthnCredentialUsage(ctx context.Context, arg UpdateWebAuthnCredentialUsageParams) error
+	UpsertEmailChange(ctx context.Context, arg UpsertEmailChangeParams) error
+	UpsertTOTPSecret(ctx context.Context, arg UpsertTOTPSecretParams) (TotpSecret, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
+	VerifyUserEmail(ctx context.Context, id int32) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,158 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: roles.sql
+
+package queries
+
+import (
+	"context"
+)
+
+const assignRole = `-- name: AssignRole :exec
+INSERT INTO user_roles (user_id, role_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type AssignRoleParams struct {
+	UserID int32
+	RoleID int32
+}
+
+func (q *Queries) AssignRole(ctx context.Context, arg AssignRoleParams) error {
+	_, err := q.db.ExecContext(ctx, assignRole, arg.UserID, arg.RoleID)
+	return err
+}
+
+const getRoleByName = `-- name: GetRoleByName :one
+SELECT id, name, description, created_at FROM roles WHERE name = $1
+`
+
+func (q *Queries) GetRoleByName(ctx context.Context, name string) (Role, error) {
+	row := q.db.QueryRowContext(ctx, getRoleByName, name)
+	var i Role
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listPermissionsForUser = `-- name: ListPermissionsForUser :many
+SELECT DISTINCT permissions.name FROM permissions
+JOIN role_permissions ON role_permissions.permission_id = permissions.id
+JOIN user_roles ON user_roles.role_id = role_permissions.role_id
+WHERE user_roles.user_id = $1
+ORDER BY permissions.name
+`
+
+func (q *Queries) ListPermissionsForUser(ctx context.Context, userID int32) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listPermissionsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRoles = `-- name: ListRoles :many
+SELECT id, name, description, created_at FROM roles
+ORDER BY name
+`
+
+func (q *Queries) ListRoles(ctx context.Context) ([]Role, error) {
+	rows, err := q.db.QueryContext(ctx, listRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Role
+	for rows.Next() {
+		var i Role
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRolesForUser = `-- name: ListRolesForUser :many
+SELECT roles.id, roles.name, roles.description, roles.created_at FROM roles
+JOIN user_roles ON user_roles.role_id = roles.id
+WHERE user_roles.user_id = $1
+ORDER BY roles.name
+`
+
+func (q *Queries) ListRolesForUser(ctx context.Context, userID int32) ([]Role, error) {
+	rows, err := q.db.QueryContext(ctx, listRolesForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Role
+	for rows.Next() {
+		var i Role
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeRole = `-- name: RemoveRole :execrows
+DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2
+`
+
+type RemoveRoleParams struct {
+	UserID int32
+	RoleID int32
+}
+
+func (q *Queries) RemoveRole(ctx context.Context, arg RemoveRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeRole, arg.UserID, arg.RoleID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,134 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: scheduled_tasks.sql
+
+package queries
+
+import (
+	"context"
+	"time"
+)
+
+const claimScheduledTask = `-- name: ClaimScheduledTask :execrows
+UPDATE scheduled_tasks
+SET next_run_at = $2, last_status = 'running', last_started_at = CURRENT_TIMESTAMP
+WHERE name = $1 AND next_run_at <= CURRENT_TIMESTAMP
+`
+
+type ClaimScheduledTaskParams struct {
+	Name      string
+	NextRunAt time.Time
+}
+
+// no rows when the task isn't due, e.g. because another replica just ran it
+func (q *Queries) ClaimScheduledTask(ctx context.Context, arg ClaimScheduledTaskParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, claimScheduledTask, arg.Name, arg.NextRunAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const finishScheduledTask = `-- name: FinishScheduledTask :exec
+UPDATE scheduled_tasks
+SET last_status = $2, last_error = $3, last_duration_ms = $4, last_finished_at = CURRENT_TIMESTAMP
+WHERE name = $1
+`
+
+type FinishScheduledTaskParams struct {
+	Name           string
+	LastStatus     string
+	LastError      string
+	LastDurationMs int64
+}
+
+func (q *Queries) FinishScheduledTask(ctx context.Context, arg FinishScheduledTaskParams) error {
+	_, err := q.db.ExecContext(ctx, finishScheduledTask,
+		arg.Name,
+		arg.LastStatus,
+		arg.LastError,
+		arg.LastDurationMs,
+	)
+	return err
+}
+
+const listScheduledTasks = `-- name: ListScheduledTasks :many
+SELECT name, schedule, next_run_at, last_status, last_error, last_started_at, last_finished_at, last_duration_ms FROM scheduled_tasks ORDER BY name
+`
+
+func (q *Queries) ListScheduledTasks(ctx context.Context) ([]ScheduledTask, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledTasks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ScheduledTask
+	for rows.Next() {
+		var i ScheduledTask
+		if err := rows.Scan(
+			&i.Name,
+			&i.Schedule,
+			&i.NextRunAt,
+			&i.LastStatus,
+			&i.LastError,
+			&i.LastStartedAt,
+			&i.LastFinishedAt,
+			&i.LastDurationMs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const registerScheduledTask = `-- name: RegisterScheduledTask :exec
+INSERT INTO scheduled_tasks (name, schedule, next_run_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE
+SET schedule = EXCLUDED.schedule,
+	next_run_at = CASE
+		WHEN scheduled_tasks.schedule = EXCLUDED.schedule THEN scheduled_tasks.next_run_at
+		ELSE EXCLUDED.next_run_at
+	END
+`
+
+type RegisterScheduledTaskParams struct {
+	Name      string
+	Schedule  string
+	NextRunAt time.Time
+}
+
+// a changed schedule starts over with its next run
+func (q *Queries) RegisterScheduledTask(ctx context.Context, arg RegisterScheduledTaskParams) error {
+	_, err := q.db.ExecContext(ctx, registerScheduledTask, arg.Name, arg.Schedule, arg.NextRunAt)
+	return err
+}
+
+const tryLockScheduledTask = `-- name: TryLockScheduledTask :one
+SELECT pg_try_advisory_lock(hashtext('scheduled_tasks'), hashtext($1::text))::boolean AS locked
+`
+
+// session level, the lock must be released on the same connection
+func (q *Queries) TryLockScheduledTask(ctx context.Context, name string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, tryLockScheduledTask, name)
+	var locked bool
+	err := row.Scan(&locked)
+	return locked, err
+}
+
+const unlockScheduledTask = `-- name: UnlockScheduledTask :exec
+SELECT pg_advisory_unlock(hashtext('scheduled_tasks'), hashtext($1::text))
+`
+
+func (q *Queries) UnlockScheduledTask(ctx context.Context, name string) error {
+	_, err := q.db.ExecContext(ctx, unlockScheduledTask, name)
+	return err
+}
@@ -7,13 +7,131 @@ package queries
 
 import (
 	"context"
+	"time"
 )
 
+const createUserSession = `-- name: CreateUserSession :one
+INSERT INTO user_sessions (token, user_id, user_agent, ip_address, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, token, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at
+`
+
+type CreateUserSessionParams struct {
+	Token     string
+	UserID    int32
+	UserAgent string
+	IpAddress string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateUserSession(ctx context.Context, arg CreateUserSessionParams) (UserSession, error) {
+	row := q.db.QueryRowContext(ctx, createUserSession,
+		arg.Token,
+		arg.UserID,
+		arg.UserAgent,
+		arg.IpAddress,
+		arg.ExpiresAt,
+	)
+	var i UserSession
+	err := row.Scan(
+		&i.ID,
+		&i.Token,
+		&i.UserID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.CreatedAt,
+		&i.LastSeenAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const deleteAllExpiredUserSessions = `-- name: DeleteAllExpiredUserSessions :execrows
+DELETE FROM user_sessions WHERE expires_at < CURRENT_TIMESTAMP
+`
+
+func (q *Queries) DeleteAllExpiredUserSessions(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAllExpiredUserSessions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteAllUserSessions = `-- name: DeleteAllUserSessions :exec
+DELETE FROM user_sessions WHERE user_id = $1
+`
+
+func (q *Queries) DeleteAllUserSessions(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteAllUserSessions, userID)
+	return err
+}
+
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions WHERE expiry < CURRENT_TIMESTAMP
+`
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredSessions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExpiredUserSessions = `-- name: DeleteExpiredUserSessions :exec
+DELETE FROM user_sessions WHERE user_id = $1 AND expires_at <= NOW()
+`
+
+func (q *Queries) DeleteExpiredUserSessions(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredUserSessions, userID)
+	return err
+}
+
+const deleteOtherUserSessions = `-- name: DeleteOtherUserSessions :exec
+DELETE FROM user_sessions WHERE user_id = $1 AND token <> $2
+`
+
+type DeleteOtherUserSessionsParams struct {
+	UserID int32
+	Token  string
+}
+
+func (q *Queries) DeleteOtherUserSessions(ctx context.Context, arg DeleteOtherUserSessionsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteOtherUserSessions, arg.UserID, arg.Token)
+	return err
+}
+
+const deleteUserSession = `-- name: DeleteUserSession :execrows
+DELETE FROM user_sessions WHERE id = $1 AND user_id = $2
+`
+
+type DeleteUserSessionParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserSession, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserSessionByToken = `-- name: DeleteUserSessionByToken :exec
+DELETE FROM user_sessions WHERE token = $1
+`
+
+func (q *Queries) DeleteUserSessionByToken(ctx context.Context, token string) error {
+	_, err := q.db.ExecContext(ctx, deleteUserSessionByToken, token)
+	return err
+}
+
 const getSessionByToken = `-- name: GetSessionByToken :one
 SELECT token, data, expiry FROM sessions
 WHERE token = $1
-  AND revoked_at IS NULL
-  AND expires_at > NOW()
+  AND expiry > NOW()
 `
 
 func (q *Queries) GetSessionByToken(ctx context.Context, token string) (Session, error) {
@@ -22,3 +140,73 @@ func (q *Queries) GetSessionByToken(ctx context.Context, token string) (Session,
 	err := row.Scan(&i.Token, &i.Data, &i.Expiry)
 	return i, err
 }
+
+const getUserSessionByToken = `-- name: GetUserSessionByToken :one
+SELECT id, token, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at FROM user_sessions
+WHERE token = $1
+  AND expires_at > NOW()
+`
+
+func (q *Queries) GetUserSessionByToken(ctx context.Context, token string) (UserSession, error) {
+	row := q.db.QueryRowContext(ctx, getUserSessionByToken, token)
+	var i UserSession
+	err := row.Scan(
+		&i.ID,
+		&i.Token,
+		&i.UserID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.CreatedAt,
+		&i.LastSeenAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT id, token, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at FROM user_sessions
+WHERE user_id = $1
+  AND expires_at > NOW()
+ORDER BY last_seen_at DESC
+`
+
+func (q *Queries) ListUserSessions(ctx context.Context, userID int32) ([]UserSession, error) {
+	rows, err := q.db.QueryContext(ctx, listUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserSession
+	for rows.Next() {
+		var i UserSession
+		if err := rows.Scan(
+			&i.ID,
+			&i.Token,
+			&i.UserID,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchUserSession = `-- name: TouchUserSession :exec
+UPDATE user_sessions SET last_seen_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) TouchUserSession(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, touchUserSession, id)
+	return err
+}
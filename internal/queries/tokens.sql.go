@@ -10,6 +10,25 @@ import (
 	"time"
 )
 
+const consumeToken = `-- name: ConsumeToken :one
+DELETE FROM tokens
+WHERE hash = $1 AND scope = $2 AND expiry > $3
+RETURNING user_id
+`
+
+type ConsumeTokenParams struct {
+	Hash   []byte
+	Scope  string
+	Expiry time.Time
+}
+
+func (q *Queries) ConsumeToken(ctx context.Context, arg ConsumeTokenParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, consumeToken, arg.Hash, arg.Scope, arg.Expiry)
+	var user_id int64
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
 const createToken = `-- name: CreateToken :one
 INSERT INTO tokens (hash,user_id,expiry,scope) VALUES ($1,$2,$3,$4) RETURNING hash, user_id, expiry, scope
 `
@@ -52,6 +71,18 @@ func (q *Queries) DeleteAllForUser(ctx context.Context, arg DeleteAllForUserPara
 	return err
 }
 
+const deleteExpiredTokens = `-- name: DeleteExpiredTokens :execrows
+DELETE FROM tokens WHERE expiry < CURRENT_TIMESTAMP
+`
+
+func (q *Queries) DeleteExpiredTokens(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredTokens)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteToken = `-- name: DeleteToken :exec
 DELETE FROM tokens WHERE hash = $1
 `
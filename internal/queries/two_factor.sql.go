@@ -0,0 +1,141 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: two_factor.sql
+
+package queries
+
+import (
+	"context"
+)
+
+const confirmTOTPSecret = `-- name: ConfirmTOTPSecret :exec
+UPDATE totp_secrets SET confirmed_at = NOW() WHERE user_id = $1
+`
+
+func (q *Queries) ConfirmTOTPSecret(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, confirmTOTPSecret, userID)
+	return err
+}
+
+const countUnusedRecoveryCodes = `-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) CountUnusedRecoveryCodes(ctx context.Context, userID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnusedRecoveryCodes, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (hash, user_id) VALUES ($1, $2)
+`
+
+type CreateRecoveryCodeParams struct {
+	Hash   []byte
+	UserID int32
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.ExecContext(ctx, createRecoveryCode, arg.Hash, arg.UserID)
+	return err
+}
+
+const deleteRecoveryCodesForUser = `-- name: DeleteRecoveryCodesForUser :exec
+DELETE FROM recovery_codes WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodesForUser(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteRecoveryCodesForUser, userID)
+	return err
+}
+
+const deleteTOTPSecret = `-- name: DeleteTOTPSecret :exec
+DELETE FROM totp_secrets WHERE user_id = $1
+`
+
+func (q *Queries) DeleteTOTPSecret(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteTOTPSecret, userID)
+	return err
+}
+
+const getTOTPSecret = `-- name: GetTOTPSecret :one
+SELECT user_id, secret, confirmed_at, last_used_step, created_at FROM totp_secrets WHERE user_id = $1
+`
+
+func (q *Queries) GetTOTPSecret(ctx context.Context, userID int32) (TotpSecret, error) {
+	row := q.db.QueryRowContext(ctx, getTOTPSecret, userID)
+	var i TotpSecret
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.ConfirmedAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const updateTOTPLastUsedStep = `-- name: UpdateTOTPLastUsedStep :execrows
+UPDATE totp_secrets SET last_used_step = $2
+WHERE user_id = $1 AND last_used_step < $2
+`
+
+type UpdateTOTPLastUsedStepParams struct {
+	UserID       int32
+	LastUsedStep int64
+}
+
+func (q *Queries) UpdateTOTPLastUsedStep(ctx context.Context, arg UpdateTOTPLastUsedStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateTOTPLastUsedStep, arg.UserID, arg.LastUsedStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertTOTPSecret = `-- name: UpsertTOTPSecret :one
+INSERT INTO totp_secrets (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, confirmed_at = NULL, last_used_step = 0, created_at = NOW()
+RETURNING user_id, secret, confirmed_at, last_used_step, created_at
+`
+
+type UpsertTOTPSecretParams struct {
+	UserID int32
+	Secret string
+}
+
+func (q *Queries) UpsertTOTPSecret(ctx context.Context, arg UpsertTOTPSecretParams) (TotpSecret, error) {
+	row := q.db.QueryRowContext(ctx, upsertTOTPSecret, arg.UserID, arg.Secret)
+	var i TotpSecret
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.ConfirmedAt,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes SET used_at = NOW()
+WHERE hash = $1 AND user_id = $2 AND used_at IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	Hash   []byte
+	UserID int32
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useRecoveryCode, arg.Hash, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -125,6 +125,73 @@ func (q *Queries) GetUserByToken(ctx context.Context, arg GetUserByTokenParams)
 	return i, err
 }
 
+const listUnverifiedUsersCreatedBefore = `-- name: ListUnverifiedUsersCreatedBefore :many
+SELECT id, name, email, email_verified, image, created_at, updated_at FROM users
+WHERE email_verified = false AND created_at < $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListUnverifiedUsersCreatedBeforeParams struct {
+	CreatedAt time.Time
+	Limit     int32
+}
+
+func (q *Queries) ListUnverifiedUsersCreatedBefore(ctx context.Context, arg ListUnverifiedUsersCreatedBeforeParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUnverifiedUsersCreatedBefore, arg.CreatedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.EmailVerified,
+			&i.Image,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateUserEmail = `-- name: UpdateUserEmail :one
+UPDATE users SET email = $1, email_verified = TRUE, updated_at = NOW() WHERE id = $2 RETURNING id, name, email, email_verified, image, created_at, updated_at
+`
+
+type UpdateUserEmailParams struct {
+	Email string
+	ID    int32
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserEmail, arg.Email, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.EmailVerified,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const updateUserNameAndImage = `-- name: UpdateUserNameAndImage :one
 UPDATE users SET name = $1, image = $2 WHERE id = $3 RETURNING id, name, email, email_verified, image, created_at, updated_at
 `
@@ -149,3 +216,22 @@ func (q *Queries) UpdateUserNameAndImage(ctx context.Context, arg UpdateUserName
 	)
 	return i, err
 }
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users SET email_verified = TRUE, updated_at = NOW() WHERE id = $1 RETURNING id, name, email, email_verified, image, created_at, updated_at
+`
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserEmail, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.EmailVerified,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,189 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: webauthn.sql
+
+package queries
+
+import (
+	"context"
+)
+
+const countWebAuthnCredentialsForUser = `-- name: CountWebAuthnCredentialsForUser :one
+SELECT COUNT(*) FROM webauthn_credentials WHERE user_id = $1
+`
+
+func (q *Queries) CountWebAuthnCredentialsForUser(ctx context.Context, userID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countWebAuthnCredentialsForUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createWebAuthnCredential = `-- name: CreateWebAuthnCredential :one
+INSERT INTO webauthn_credentials (user_id, name, credential_id, public_key, attestation_type, transports, aaguid, flags, sign_count)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, user_id, name, credential_id, public_key, attestation_type, transports, aaguid, flags, sign_count, last_used_at, created_at
+`
+
+type CreateWebAuthnCredentialParams struct {
+	UserID          int32
+	Name            string
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	Transports      string
+	Aaguid          []byte
+	Flags           int16
+	SignCount       int64
+}
+
+func (q *Queries) CreateWebAuthnCredential(ctx context.Context, arg CreateWebAuthnCredentialParams) (WebauthnCredential, error) {
+	row := q.db.QueryRowContext(ctx, createWebAuthnCredential,
+		arg.UserID,
+		arg.Name,
+		arg.CredentialID,
+		arg.PublicKey,
+		arg.AttestationType,
+		arg.Transports,
+		arg.Aaguid,
+		arg.Flags,
+		arg.SignCount,
+	)
+	var i WebauthnCredential
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.CredentialID,
+		&i.PublicKey,
+		&i.AttestationType,
+		&i.Transports,
+		&i.Aaguid,
+		&i.Flags,
+		&i.SignCount,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteWebAuthnCredential = `-- name: DeleteWebAuthnCredential :execrows
+DELETE FROM webauthn_credentials
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteWebAuthnCredentialParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) DeleteWebAuthnCredential(ctx context.Context, arg DeleteWebAuthnCredentialParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWebAuthnCredential, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getWebAuthnCredentialByCredentialID = `-- name: GetWebAuthnCredentialByCredentialID :one
+SELECT id, user_id, name, credential_id, public_key, attestation_type, transports, aaguid, flags, sign_count, last_used_at, created_at FROM webauthn_credentials WHERE credential_id = $1
+`
+
+func (q *Queries) GetWebAuthnCredentialByCredentialID(ctx context.Context, credentialID []byte) (WebauthnCredential, error) {
+	row := q.db.QueryRowContext(ctx, getWebAuthnCredentialByCredentialID, credentialID)
+	var i WebauthnCredential
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.CredentialID,
+		&i.PublicKey,
+		&i.AttestationType,
+		&i.Transports,
+		&i.Aaguid,
+		&i.Flags,
+		&i.SignCount,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listWebAuthnCredentialsForUser = `-- name: ListWebAuthnCredentialsForUser :many
+SELECT id, user_id, name, credential_id, public_key, attestation_type, transports, aaguid, flags, sign_count, last_used_at, created_at FROM webauthn_credentials
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListWebAuthnCredentialsForUser(ctx context.Context, userID int32) ([]WebauthnCredential, error) {
+	rows, err := q.db.QueryContext(ctx, listWebAuthnCredentialsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebauthnCredential
+	for rows.Next() {
+		var i WebauthnCredential
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.CredentialID,
+			&i.PublicKey,
+			&i.AttestationType,
+			&i.Transports,
+			&i.Aaguid,
+			&i.Flags,
+			&i.SignCount,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const renameWebAuthnCredential = `-- name: RenameWebAuthnCredential :execrows
+UPDATE webauthn_credentials SET name = $3
+WHERE id = $1 AND user_id = $2
+`
+
+type RenameWebAuthnCredentialParams struct {
+	ID     int32
+	UserID int32
+	Name   string
+}
+
+func (q *Queries) RenameWebAuthnCredential(ctx context.Context, arg RenameWebAuthnCredentialParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, renameWebAuthnCredential, arg.ID, arg.UserID, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateWebAuthnCredentialUsage = `-- name: UpdateWebAuthnCredentialUsage :exec
+UPDATE webauthn_credentials
+SET sign_count = $2, flags = $3, last_used_at = NOW()
+WHERE credential_id = $1
+`
+
+type UpdateWebAuthnCredentialUsageParams struct {
+	CredentialID []byte
+	SignCount    int64
+	Flags        int16
+}
+
+func (q *Queries) UpdateWebAuthnCredentialUsage(ctx context.Context, arg UpdateWebAuthnCredentialUsageParams) error {
+	_, err := q.db.ExecContext(ctx, updateWebAuthnCredentialUsage, arg.CredentialID, arg.SignCount, arg.Flags)
+	return err
+}
@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	from := time.Date(2025, time.January, 15, 10, 7, 30, 0, time.UTC) // a Wednesday
+
+	testCases := []struct {
+		spec string
+		want []time.Time
+	}{
+		{"*/15 * * * *", []time.Time{
+			time.Date(2025, time.January, 15, 10, 15, 0, 0, time.UTC),
+			time.Date(2025, time.January, 15, 10, 30, 0, 0, time.UTC),
+		}},
+		{"@hourly", []time.Time{
+			time.Date(2025, time.January, 15, 11, 0, 0, 0, time.UTC),
+			time.Date(2025, time.January, 15, 12, 0, 0, 0, time.UTC),
+		}},
+		{"30 3 * * *", []time.Time{
+			time.Date(2025, time.January, 16, 3, 30, 0, 0, time.UTC),
+			time.Date(2025, time.January, 17, 3, 30, 0, 0, time.UTC),
+		}},
+		{"0 9 * * 1-5", []time.Time{
+			time.Date(2025, time.January, 16, 9, 0, 0, 0, time.UTC),
+			time.Date(2025, time.January, 17, 9, 0, 0, 0, time.UTC),
+			time.Date(2025, time.January, 20, 9, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 * * 7", []time.Time{
+			time.Date(2025, time.January, 19, 0, 0, 0, 0, time.UTC),
+		}},
+		// either day matches when both are restricted
+		{"0 0 1 * 5", []time.Time{
+			time.Date(2025, time.January, 17, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, time.January, 24, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, time.January, 31, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 29 2 *", []time.Time{
+			time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+		}},
+		{"5,50 1-2 * * *", []time.Time{
+			time.Date(2025, time.January, 16, 1, 5, 0, 0, time.UTC),
+			time.Date(2025, time.January, 16, 1, 50, 0, 0, time.UTC),
+			time.Date(2025, time.January, 16, 2, 5, 0, 0, time.UTC),
+		}},
+		{"@every 90m", []time.Time{
+			time.Date(2025, time.January, 15, 11, 37, 30, 0, time.UTC),
+			time.Date(2025, time.January, 15, 13, 7, 30, 0, time.UTC),
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			schedule, err := Parse(tc.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			next := from
+			for _, want := range tc.want {
+				next = schedule.Next(next)
+				if !next.Equal(want) {
+					t.Fatalf("expected %v; got %v", want, next)
+				}
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every 30s",
+		"@every soon",
+		"@sometimes",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expected %q to be invalid", spec)
+		}
+	}
+}
+
+func TestNeverMatches(t *testing.T) {
+	schedule, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next := schedule.Next(time.Now()); !next.IsZero() {
+		t.Errorf("expected no next run; got %v", next)
+	}
+}
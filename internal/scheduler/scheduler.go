@@ -0,0 +1,179 @@
+// Package scheduler runs recurring tasks, like cleaning up expired rows, on cron
+// schedules. Every replica of the app can run the scheduler: a task runs on the
+// replica that holds its Postgres advisory lock and claims the due run, so each
+// run happens once. The outcome of the last run is stored in scheduled_tasks.
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-web-starter/internal/jsonlog"
+	"go-web-starter/internal/queries"
+	"sync"
+	"time"
+)
+
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Task is the work of a scheduled task
+type Task func(ctx context.Context) error
+
+type task struct {
+	name     string
+	spec     string
+	schedule Schedule
+	run      Task
+}
+
+// Scheduler runs the tasks registered with Register
+type Scheduler struct {
+	db     *sql.DB
+	logger *jsonlog.Logger
+	tasks  []task
+}
+
+func New(db *sql.DB, logger *jsonlog.Logger) *Scheduler {
+	return &Scheduler{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Register adds a task running on the schedule, see Parse. The name identifies
+// the task across replicas and restarts.
+func (s *Scheduler) Register(name, spec string, run Task) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("task %s: %w", name, err)
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return fmt.Errorf("task %s: %q never runs", name, spec)
+	}
+
+	for _, t := range s.tasks {
+		if t.name == name {
+			return fmt.Errorf("task %s is registered twice", name)
+		}
+	}
+
+	s.tasks = append(s.tasks, task{name: name, spec: spec, schedule: schedule, run: run})
+
+	return nil
+}
+
+// Run checks for due tasks every minute until ctx is done and the running tasks
+// are finished. A long task doesn't hold up the others.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		s.start(ctx, &wg)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute))):
+		}
+	}
+}
+
+// RunDue runs the tasks that are due and waits for them
+func (s *Scheduler) RunDue(ctx context.Context) {
+	var wg sync.WaitGroup
+	s.start(ctx, &wg)
+	wg.Wait()
+}
+
+func (s *Scheduler) start(ctx context.Context, wg *sync.WaitGroup) {
+	q := queries.New(s.db)
+
+	for _, t := range s.tasks {
+		err := q.RegisterScheduledTask(ctx, queries.RegisterScheduledTaskParams{
+			Name:      t.name,
+			Schedule:  t.spec,
+			NextRunAt: t.schedule.Next(time.Now()),
+		})
+		if err != nil {
+			s.logError(t, err)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.runTask(ctx, t); err != nil {
+				s.logError(t, err)
+			}
+		}()
+	}
+}
+
+// runTask runs the task if it is due and no other replica is running it. The
+// error is about the scheduler, the error of the task is stored with its run.
+func (s *Scheduler) runTask(ctx context.Context, t task) (err error) {
+	// advisory locks belong to the connection that took them
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	q := queries.New(conn)
+
+	locked, err := q.TryLockScheduledTask(ctx, t.name)
+	if err != nil || !locked {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, q.UnlockScheduledTask(context.WithoutCancel(ctx), t.name))
+	}()
+
+	claimed, err := q.ClaimScheduledTask(ctx, queries.ClaimScheduledTaskParams{
+		Name:      t.name,
+		NextRunAt: t.schedule.Next(time.Now()),
+	})
+	if err != nil || claimed == 0 {
+		return err
+	}
+
+	started := time.Now()
+	taskErr := runSafely(ctx, t.run)
+
+	finished := queries.FinishScheduledTaskParams{
+		Name:           t.name,
+		LastStatus:     StatusSucceeded,
+		LastDurationMs: time.Since(started).Milliseconds(),
+	}
+	if taskErr != nil {
+		finished.LastStatus = StatusFailed
+		finished.LastError = taskErr.Error()
+		s.logError(t, taskErr)
+	}
+
+	// the outcome is stored even when the scheduler is stopping
+	return q.FinishScheduledTask(context.WithoutCancel(ctx), finished)
+}
+
+func runSafely(ctx context.Context, run Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return run(ctx)
+}
+
+func (s *Scheduler) logError(t task, err error) {
+	s.logger.PrintError(err, map[string]string{
+		"component": "scheduler",
+		"task":      t.name,
+	})
+}
@@ -0,0 +1,135 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"go-web-starter/internal/jsonlog"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/scheduler"
+	"go-web-starter/internal/tests"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestScheduler(t *testing.T) {
+	ts := tests.NewTestServer(t)
+	defer ts.Close()
+
+	ctx := context.Background()
+
+	// the tasks run concurrently
+	var mu sync.Mutex
+	runs := map[string]int{}
+	count := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		runs[name]++
+	}
+
+	tasks := scheduler.New(ts.DB, jsonlog.New(io.Discard, jsonlog.LevelInfo))
+	err := errors.Join(
+		tasks.Register("cleanup", "@hourly", func(ctx context.Context) error {
+			count("cleanup")
+			return nil
+		}),
+		tasks.Register("failing", "@daily", func(ctx context.Context) error {
+			count("failing")
+			return errors.New("unavailable")
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tasks.Register("cleanup", "@daily", nil); err == nil {
+		t.Error("expected a task name to be registered once")
+	}
+	if err := tasks.Register("invalid", "0 0 30 2 *", nil); err == nil {
+		t.Error("expected a schedule that never runs to be rejected")
+	}
+
+	task := func(t *testing.T, name string) queries.ScheduledTask {
+		t.Helper()
+
+		list, err := ts.Queries.ListScheduledTasks(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, task := range list {
+			if task.Name == name {
+				return task
+			}
+		}
+
+		t.Fatalf("expected task %s to be stored", name)
+		return queries.ScheduledTask{}
+	}
+	makeDue := func(t *testing.T) {
+		t.Helper()
+
+		if _, err := ts.DB.ExecContext(ctx, "UPDATE scheduled_tasks SET next_run_at = now()"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("tasks wait for their schedule", func(t *testing.T) {
+		tasks.RunDue(ctx)
+
+		if len(runs) != 0 {
+			t.Errorf("expected no runs before the schedule; got %v", runs)
+		}
+		if cleanup := task(t, "cleanup"); cleanup.LastStatus != "" || cleanup.Schedule != "@hourly" {
+			t.Errorf("unexpected task %+v", cleanup)
+		}
+	})
+
+	t.Run("due tasks run once", func(t *testing.T) {
+		makeDue(t)
+		tasks.RunDue(ctx)
+		// another replica checking right after
+		tasks.RunDue(ctx)
+
+		if runs["cleanup"] != 1 || runs["failing"] != 1 {
+			t.Errorf("expected every task to run once; got %v", runs)
+		}
+
+		cleanup := task(t, "cleanup")
+		if cleanup.LastStatus != scheduler.StatusSucceeded || !cleanup.LastFinishedAt.Valid {
+			t.Errorf("expected the run to succeed; got %+v", cleanup)
+		}
+		failing := task(t, "failing")
+		if failing.LastStatus != scheduler.StatusFailed || failing.LastError != "unavailable" {
+			t.Errorf("expected the run to fail; got %+v", failing)
+		}
+	})
+
+	t.Run("locked tasks are skipped", func(t *testing.T) {
+		makeDue(t)
+
+		conn, err := ts.DB.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		locked, err := queries.New(conn).TryLockScheduledTask(ctx, "cleanup")
+		if err != nil || !locked {
+			t.Fatalf("failed to lock the task: %v", err)
+		}
+
+		tasks.RunDue(ctx)
+		if runs["cleanup"] != 1 {
+			t.Errorf("expected the locked task to be skipped; got %d runs", runs["cleanup"])
+		}
+
+		if err := queries.New(conn).UnlockScheduledTask(ctx, "cleanup"); err != nil {
+			t.Fatal(err)
+		}
+
+		tasks.RunDue(ctx)
+		if runs["cleanup"] != 2 {
+			t.Errorf("expected the task to run once unlocked; got %d runs", runs["cleanup"])
+		}
+	})
+}
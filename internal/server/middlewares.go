@@ -2,13 +2,42 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
 	"fmt"
 	"go-web-starter/internal/config"
+	"go-web-starter/internal/handlers/api"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/service"
+	"go-web-starter/internal/types"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/angelofallars/htmx-go"
 	"github.com/justinas/nosurf"
 )
 
+// identifyClient stores the IP address and user agent of the request for the audit log
+func (s *Server) identifyClient(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		ctx := context.WithValue(r.Context(), config.ClientContextKey, types.Client{
+			IPAddress: ip,
+			UserAgent: r.UserAgent(),
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func (s *Server) authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// sessionManager
@@ -18,6 +47,33 @@ func (s *Server) authenticate(next http.Handler) http.Handler {
 			return
 		}
 
+		// while an admin impersonates the user the session stays in the index of the admin
+		owner := id
+		impersonatorID := s.SessionManager.GetInt32(r.Context(), string(config.ImpersonatorID))
+		if impersonatorID != 0 {
+			owner = impersonatorID
+		}
+
+		// sessions that were signed out remotely or expired are no longer in the
+		// session index, treat them as logged out
+		session, err := s.Queries.GetUserSessionByToken(r.Context(), s.SessionManager.Token(r.Context()))
+		if err != nil || session.UserID != owner {
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				s.Logger.PrintError(err, nil)
+			}
+
+			s.SessionManager.Destroy(r.Context())
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// only write the last seen time once a minute
+		if time.Since(session.LastSeenAt) > time.Minute {
+			if err := s.Queries.TouchUserSession(r.Context(), session.ID); err != nil {
+				s.Logger.PrintError(err, nil)
+			}
+		}
+
 		user, err := s.Queries.GetUserById(r.Context(), id)
 		if err != nil {
 			// app.serverError(w, err)
@@ -31,9 +87,27 @@ func (s *Server) authenticate(next http.Handler) http.Handler {
 			isCollapsedSidebar = cookie.Value == "false"
 		}
 
+		// the permissions are needed by requirePermission and to hide UI in the templates
+		permissions, err := s.Queries.ListPermissionsForUser(r.Context(), user.ID)
+		if err != nil {
+			s.Logger.PrintError(err, nil)
+		}
+
 		ctx := context.WithValue(r.Context(), config.IsAuthenticatedContextKey, true)
 		ctx = context.WithValue(ctx, config.UserContextKey, user)
 		ctx = context.WithValue(ctx, config.SidebarStateContextKey, isCollapsedSidebar)
+		ctx = context.WithValue(ctx, config.PermissionsContextKey, permissions)
+
+		if impersonatorID != 0 {
+			impersonator, err := s.Queries.GetUserById(r.Context(), impersonatorID)
+			if err != nil {
+				s.Logger.PrintError(err, nil)
+				s.SessionManager.Destroy(r.Context())
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx = context.WithValue(ctx, config.ImpersonatorContextKey, impersonator)
+		}
 
 		r = r.WithContext(ctx)
 
@@ -41,6 +115,196 @@ func (s *Server) authenticate(next http.Handler) http.Handler {
 	})
 }
 
+// loadOrganization puts the organizations of the authenticated user into the context,
+// along with the current one: the organization they switched to, or the first.
+func (s *Server) loadOrganization(orgService *service.OrganizationService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value(config.UserContextKey).(queries.User)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			memberships, err := orgService.ListMemberships(r.Context(), user.ID)
+			if err != nil {
+				s.Logger.PrintError(err, nil)
+			}
+
+			ctx := context.WithValue(r.Context(), config.OrganizationsContextKey, memberships)
+
+			if len(memberships) > 0 {
+				current := memberships[0]
+
+				// the user may have left the organization they switched to
+				currentID := s.SessionManager.GetInt32(r.Context(), string(config.CurrentOrganizationID))
+				for _, membership := range memberships {
+					if membership.OrganizationID == currentID {
+						current = membership
+						break
+					}
+				}
+
+				ctx = context.WithValue(ctx, config.OrganizationContextKey, current)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requireOrganization sends users without an organization to create one. Routes
+// working with tenant data go behind it.
+func (s *Server) requireOrganization(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(config.OrganizationContextKey).(types.Membership); !ok {
+			s.SessionManager.Put(r.Context(), "flash", "Create an organization to continue.")
+
+			if htmx.IsHTMX(r) {
+				htmx.NewResponse().Redirect("/organizations/new").Write(w)
+				return
+			}
+
+			http.Redirect(w, r, "/organizations/new", http.StatusSeeOther)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forbidImpersonation keeps admins who are logged in as another user from changing
+// the credentials, sessions and account of that user
+func (s *Server) forbidImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(config.ImpersonatorContextKey).(queries.User); ok {
+			s.SessionManager.Put(r.Context(), "flash", "This isn't possible while you are logged in as another user.")
+
+			if htmx.IsHTMX(r) {
+				htmx.NewResponse().Redirect("/profile").Write(w)
+				return
+			}
+
+			http.Redirect(w, r, "/profile", http.StatusSeeOther)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticateToken authenticates requests with a personal access token in the
+// Authorization: Bearer header, without session or cookies. The user of the session
+// is ignored, without a token the request is anonymous. Tokens without the write
+// scope can only read.
+func (s *Server) authenticateToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the API skips CSRF protection, so the user of the session must not count
+		r = r.WithContext(withoutSession(r.Context()))
+
+		plaintext, ok := bearerToken(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		hash := sha256.Sum256([]byte(plaintext))
+
+		token, err := s.Queries.GetPersonalAccessTokenByHash(r.Context(), hash[:])
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				s.Logger.PrintError(err, nil)
+			}
+
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			api.WriteError(w, http.StatusUnauthorized, "invalid or expired access token")
+			return
+		}
+
+		scope := config.AccessTokenScopeWrite
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			scope = config.AccessTokenScopeRead
+		}
+
+		if !service.AccessTokenHasScope(token, scope) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope))
+			api.WriteError(w, http.StatusForbidden, fmt.Sprintf("the access token is missing the %s scope", scope))
+			return
+		}
+
+		user, err := s.Queries.GetUserById(r.Context(), token.UserID)
+		if err != nil {
+			s.Logger.PrintError(err, nil)
+			api.WriteError(w, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+			return
+		}
+
+		// only write the last used time once a minute
+		if !token.LastUsedAt.Valid || time.Since(token.LastUsedAt.Time) > time.Minute {
+			if err := s.Queries.TouchPersonalAccessToken(r.Context(), token.ID); err != nil {
+				s.Logger.PrintError(err, nil)
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), config.IsAuthenticatedContextKey, true)
+		ctx = context.WithValue(ctx, config.UserContextKey, user)
+		ctx = context.WithValue(ctx, config.AccessTokenContextKey, token)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// withoutSession removes what authenticate and loadOrganization took from the
+// session cookie from the context
+func withoutSession(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, config.IsAuthenticatedContextKey, false)
+	for _, key := range []any{
+		config.UserContextKey,
+		config.PermissionsContextKey,
+		config.ImpersonatorContextKey,
+		config.OrganizationContextKey,
+		config.OrganizationsContextKey,
+	} {
+		ctx = context.WithValue(ctx, key, nil)
+	}
+
+	return ctx
+}
+
+// requireAPIAuth is requireAuth for the JSON API. Only access tokens count, a session
+// cookie alone is not enough because the API skips CSRF protection. It answers 401
+// instead of redirecting to the login page.
+func (s *Server) requireAPIAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(config.AccessTokenContextKey).(queries.PersonalAccessToken); !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			api.WriteError(w, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		// Apply the unverified email policy, like requireAuth
+		user, ok := r.Context().Value(config.UserContextKey).(queries.User)
+		if ok && !user.EmailVerified && !s.unverifiedUserAllowed(r) {
+			api.WriteError(w, http.StatusForbidden, "please verify your email address to continue")
+			return
+		}
+
+		w.Header().Add("Cache-Control", "no-store")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bearerToken returns the token of an Authorization: Bearer header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+
+	return strings.TrimSpace(token), true
+}
+
 func (s *Server) requireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		isAuthenticated, ok := r.Context().Value(config.IsAuthenticatedContextKey).(bool)
@@ -54,6 +318,20 @@ func (s *Server) requireAuth(next http.Handler) http.Handler {
 			return
 		}
 
+		// Apply the unverified email policy to logged in users
+		user, ok := r.Context().Value(config.UserContextKey).(queries.User)
+		if ok && !user.EmailVerified && !s.unverifiedUserAllowed(r) {
+			s.SessionManager.Put(r.Context(), "flash", "Please verify your email address to continue.")
+
+			if htmx.IsHTMX(r) {
+				htmx.NewResponse().Redirect("/verify-email").Write(w)
+				return
+			}
+
+			http.Redirect(w, r, "/verify-email", http.StatusSeeOther)
+			return
+		}
+
 		// Set the Cache-Control header so that pages require auth are not stored in the users browser cache or any intermediary cache
 		w.Header().Add("Cache-Control", "no-store")
 
@@ -61,6 +339,47 @@ func (s *Server) requireAuth(next http.Handler) http.Handler {
 	})
 }
 
+// requirePermission only lets users through whose roles grant the permission. It goes
+// after requireAuth, which sends logged out users to the login page.
+func (s *Server) requirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			permissions, _ := r.Context().Value(config.PermissionsContextKey).([]string)
+			if !slices.Contains(permissions, permission) {
+				s.Logger.PrintInfo("permission denied", map[string]string{
+					"permission": permission,
+					"path":       r.URL.Path,
+				})
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// unverifiedAllowedPaths can always be reached by users that have not verified their email address
+var unverifiedAllowedPaths = []string{"/verify-email", "/verify-email/resend", "/logout"}
+
+// unverifiedUserAllowed reports whether the configured UnverifiedUserPolicy lets
+// a user with an unverified email address make this request.
+func (s *Server) unverifiedUserAllowed(r *http.Request) bool {
+	if slices.Contains(unverifiedAllowedPaths, r.URL.Path) {
+		return true
+	}
+
+	switch s.Config.Auth.UnverifiedUserPolicy {
+	case config.UnverifiedPolicyBlock:
+		return false
+	case config.UnverifiedPolicyLimit:
+		// read only access
+		return r.Method == http.MethodGet || r.Method == http.MethodHead
+	default:
+		return true
+	}
+}
+
 func (s *Server) requireNoAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		isAuthenticated, ok := r.Context().Value(config.IsAuthenticatedContextKey).(bool)
@@ -89,6 +408,12 @@ func (s *Server) noSurf(next http.Handler) http.Handler {
 
 	csrfHandler := nosurf.New(next)
 
+	// the API ignores the session cookie and only accepts access tokens, which
+	// browsers never add on their own, so its requests can't be forged
+	csrfHandler.ExemptFunc(func(r *http.Request) bool {
+		return r.URL.Path == "/api/v1" || strings.HasPrefix(r.URL.Path, "/api/v1/")
+	})
+
 	// Set Secure flag based on environment - only true for production
 	isProduction := s.Config.AppEnv == "production"
 	csrfHandler.SetBaseCookie(http.Cookie{
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/jsonlog"
+	"go-web-starter/internal/queries"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+func TestRequirePermission(t *testing.T) {
+	s := &Server{Logger: jsonlog.New(io.Discard, jsonlog.LevelInfo)}
+
+	handler := s.requirePermission(config.PermissionUsersManage)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		name        string
+		permissions []string
+		want        int
+	}{
+		{name: "logged out", permissions: nil, want: http.StatusForbidden},
+		{name: "no roles", permissions: []string{}, want: http.StatusForbidden},
+		{name: "other permission", permissions: []string{config.PermissionUsersView}, want: http.StatusForbidden},
+		{name: "granted", permissions: []string{config.PermissionUsersView, config.PermissionUsersManage}, want: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tc.permissions != nil {
+				r = r.WithContext(context.WithValue(r.Context(), config.PermissionsContextKey, tc.permissions))
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tc.want {
+				t.Errorf("expected status %d; got %d", tc.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestForbidImpersonation(t *testing.T) {
+	s := &Server{SessionManager: scs.New()}
+
+	handler := s.SessionManager.LoadAndSave(s.forbidImpersonation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	t.Run("own account", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/profile/update-password", nil)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("expected status %d; got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("impersonating", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/profile/update-password", nil)
+		r = r.WithContext(context.WithValue(r.Context(), config.ImpersonatorContextKey, queries.User{ID: 1}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/profile" {
+			t.Errorf("expected a redirect to /profile; got %d %q", w.Code, w.Header().Get("Location"))
+		}
+	})
+}
+
+func TestNoSurf(t *testing.T) {
+	s := &Server{Config: config.Config{AppEnv: "production"}}
+
+	handler := s.noSurf(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		name string
+		path string
+		want int
+	}{
+		{name: "web form", path: "/profile/update", want: http.StatusBadRequest},
+		{name: "api", path: "/api/v1/profile", want: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// an access token doesn't exempt the session cookie of the web routes
+			r := httptest.NewRequest(http.MethodPost, "https://example.com"+tc.path, nil)
+			r.Header.Set("Authorization", "Bearer forged")
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tc.want {
+				t.Errorf("expected status %d; got %d", tc.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestAuthenticateTokenIgnoresSession(t *testing.T) {
+	s := &Server{}
+
+	handler := s.authenticateToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(config.UserContextKey).(queries.User); ok {
+			t.Error("expected the user of the session to be dropped")
+		}
+		if r.Context().Value(config.IsAuthenticatedContextKey) != false {
+			t.Error("expected the request not to be authenticated")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/profile", nil)
+	ctx := context.WithValue(r.Context(), config.IsAuthenticatedContextKey, true)
+	ctx = context.WithValue(ctx, config.UserContextKey, queries.User{ID: 1})
+
+	handler.ServeHTTP(httptest.NewRecorder(), r.WithContext(ctx))
+}
@@ -0,0 +1,267 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/forms"
+	"go-web-starter/internal/handlers/api"
+	"go-web-starter/internal/jsonlog"
+	"go-web-starter/internal/openapi"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+)
+
+// apiVersion is the version of the JSON API under /api/v1
+const apiVersion = "1.0.0"
+
+const formDescription = "An HTML form post. Outside of tests the form must carry the csrf_token " +
+	"of the page it was rendered on. Errors and confirmations are rendered into the form."
+
+var (
+	errorResponses = []openapi.Response{
+		{Status: http.StatusBadRequest, Description: "The body is not a single valid JSON object", JSON: api.ErrorResponse{}},
+		{Status: http.StatusUnprocessableEntity, Description: "The request has invalid fields", JSON: api.ErrorResponse{}},
+	}
+	authErrorResponses = []openapi.Response{
+		{Status: http.StatusUnauthorized, Description: "The access token is missing, invalid or expired", JSON: api.ErrorResponse{}},
+		{Status: http.StatusForbidden, Description: "The access token lacks the scope or the email address is not verified", JSON: api.ErrorResponse{}},
+	}
+)
+
+func responses(groups ...[]openapi.Response) []openapi.Response {
+	var all []openapi.Response
+	for _, group := range groups {
+		all = append(all, group...)
+	}
+	return all
+}
+
+// documentedRoutes are the routes described by the OpenAPI document. Each must be
+// registered in RegisterRoutes, openapi.Build fails otherwise.
+var documentedRoutes = []openapi.Route{
+	{
+		Method:      http.MethodGet,
+		Path:        "/health",
+		OperationID: "health",
+		Summary:     "Health of the app and its database",
+		Tags:        []string{"health"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "Status and connection pool statistics of the database", JSON: map[string]string{}},
+		},
+	},
+
+	// auth forms of the HTMX views
+	{
+		Method:      http.MethodPost,
+		Path:        "/login",
+		OperationID: "loginForm",
+		Summary:     "Log in with email and password",
+		Description: formDescription,
+		Tags:        []string{"auth forms"},
+		Form:        forms.UserLoginForm{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "The login failed, or an HX-Redirect for HTMX requests", HTML: true},
+			{Status: http.StatusSeeOther, Description: "Logged in, or on to the two-factor challenge", Redirect: true},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/signup",
+		OperationID: "signUpForm",
+		Summary:     "Create an account",
+		Description: formDescription,
+		Tags:        []string{"auth forms"},
+		Form:        forms.UserSignUpForm{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "The form with errors, or an HX-Redirect for HTMX requests", HTML: true},
+			{Status: http.StatusSeeOther, Description: "Signed up, on to the login page", Redirect: true},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/forgot-password",
+		OperationID: "forgotPasswordForm",
+		Summary:     "Email a password reset link",
+		Description: formDescription,
+		Tags:        []string{"auth forms"},
+		Form:        forms.ForgotPasswordForm{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "The form with errors or a confirmation", HTML: true},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/reset-password",
+		OperationID: "resetPasswordForm",
+		Summary:     "Set a new password with the emailed token",
+		Description: formDescription,
+		Tags:        []string{"auth forms"},
+		Form:        forms.ResetPasswordForm{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "The form with errors or a confirmation", HTML: true},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/login/magic-link",
+		OperationID: "magicLinkForm",
+		Summary:     "Email a sign-in link",
+		Description: formDescription,
+		Tags:        []string{"auth forms"},
+		Form:        forms.MagicLinkForm{},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "The form with errors or a confirmation", HTML: true},
+		},
+	},
+
+	// JSON API
+	{
+		Method:      http.MethodPost,
+		Path:        "/api/v1/signup",
+		OperationID: "signUp",
+		Summary:     "Create an account",
+		Description: "Sends the welcome email and the email verification link.",
+		Tags:        []string{"auth"},
+		JSON:        forms.UserSignUpForm{},
+		Responses: responses(
+			[]openapi.Response{{Status: http.StatusCreated, Description: "The new user", JSON: api.Envelope[api.User]{}}},
+			errorResponses,
+		),
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/api/v1/login",
+		OperationID: "login",
+		Summary:     "Log in and create an access token",
+		Description: "The token is valid for 30 days and has the read and write scopes. Users with two-factor authentication send the code of their authenticator app or a recovery code.",
+		Tags:        []string{"auth"},
+		JSON:        api.LoginRequest{},
+		Responses: responses(
+			[]openapi.Response{
+				{Status: http.StatusCreated, Description: "The access token", JSON: api.Envelope[api.LoginResponse]{}},
+				{Status: http.StatusUnauthorized, Description: "Wrong credentials or two-factor code", JSON: api.ErrorResponse{}},
+				{Status: http.StatusForbidden, Description: "The account is disabled", JSON: api.ErrorResponse{}},
+				{Status: http.StatusTooManyRequests, Description: "The account is locked after too many failed logins", JSON: api.ErrorResponse{}},
+			},
+			errorResponses,
+		),
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/profile",
+		OperationID: "getProfile",
+		Summary:     "The authenticated user",
+		Tags:        []string{"profile"},
+		Auth:        true,
+		Responses: responses(
+			[]openapi.Response{{Status: http.StatusOK, Description: "The user", JSON: api.Envelope[api.User]{}}},
+			authErrorResponses,
+		),
+	},
+	{
+		Method:      http.MethodPatch,
+		Path:        "/api/v1/profile",
+		OperationID: "updateProfile",
+		Summary:     "Change the name and image",
+		Tags:        []string{"profile"},
+		Auth:        true,
+		JSON:        forms.UpdateUserNameAndImageForm{},
+		Responses: responses(
+			[]openapi.Response{{Status: http.StatusOK, Description: "The updated user", JSON: api.Envelope[api.User]{}}},
+			errorResponses,
+			authErrorResponses,
+		),
+	},
+	{
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/profile",
+		OperationID: "deleteAccount",
+		Summary:     "Delete the account",
+		Tags:        []string{"profile"},
+		Auth:        true,
+		JSON:        forms.DeleteAccountForm{},
+		Responses: responses(
+			[]openapi.Response{{Status: http.StatusNoContent, Description: "The account is deleted"}},
+			errorResponses,
+			authErrorResponses,
+		),
+	},
+	{
+		Method:      http.MethodPut,
+		Path:        "/api/v1/profile/password",
+		OperationID: "updatePassword",
+		Summary:     "Change the password",
+		Description: "Signs out all browser sessions of the user. Access tokens stay valid.",
+		Tags:        []string{"profile"},
+		Auth:        true,
+		JSON:        forms.UpdateAccountPasswordForm{},
+		Responses: responses(
+			[]openapi.Response{{Status: http.StatusNoContent, Description: "The password is changed"}},
+			errorResponses,
+			authErrorResponses,
+		),
+	},
+}
+
+// buildOpenAPIDocument describes the documented routes registered on routes
+func buildOpenAPIDocument(cfg config.Config, routes chi.Routes) (*openapi.Document, error) {
+	doc, err := openapi.Build(openapi.Info{
+		Title:       cfg.AppName,
+		Description: "Authenticate API requests with a personal access token in the Authorization: Bearer header.",
+		Version:     apiVersion,
+	}, routes, documentedRoutes)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.Servers = []openapi.Server{{URL: cfg.AppURL}}
+
+	return doc, nil
+}
+
+// OpenAPIDocument generates the OpenAPI document without a database, for the
+// openapi command. The routes are registered like in the server, the handlers are
+// never called.
+func OpenAPIDocument(cfg config.Config) (*openapi.Document, error) {
+	s := &Server{
+		Config:         cfg,
+		Logger:         jsonlog.New(io.Discard, jsonlog.LevelInfo),
+		SessionManager: scs.New(),
+	}
+
+	return buildOpenAPIDocument(cfg, s.RegisterRoutes().(chi.Routes))
+}
+
+// openAPIHandler serves the document of the routes registered on r. It is
+// generated on the first request, once all routes are registered.
+func (s *Server) openAPIHandler(r chi.Routes) http.HandlerFunc {
+	var once sync.Once
+	var doc *openapi.Document
+	var err error
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		once.Do(func() {
+			doc, err = buildOpenAPIDocument(s.Config, r)
+		})
+		if err != nil {
+			s.Logger.PrintError(err, nil)
+			api.WriteError(w, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+			return
+		}
+
+		js, err := json.Marshal(doc)
+		if err != nil {
+			s.Logger.PrintError(err, nil)
+			api.WriteError(w, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(js)
+	}
+}
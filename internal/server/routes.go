@@ -5,8 +5,14 @@ import (
 	"time"
 
 	"go-web-starter/cmd/web"
+	"go-web-starter/internal/config"
 	"go-web-starter/internal/handlers"
+	"go-web-starter/internal/handlers/admin"
+	"go-web-starter/internal/handlers/api"
 	"go-web-starter/internal/handlers/auth"
+	"go-web-starter/internal/handlers/dev"
+	"go-web-starter/internal/handlers/organizations"
+	"go-web-starter/internal/openapi"
 	"go-web-starter/internal/service"
 
 	"github.com/go-chi/chi/v5"
@@ -22,6 +28,7 @@ func (s *Server) RegisterRoutes() http.Handler {
 	r.Use(middleware.Recoverer)
 	// removes trailing slashed from the url
 	r.Use(middleware.CleanPath)
+	r.Use(s.identifyClient)
 	//  r.Use(s.secureHeaders)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
@@ -34,16 +41,30 @@ func (s *Server) RegisterRoutes() http.Handler {
 	r.Use(s.SessionManager.LoadAndSave)
 	r.Use(s.authenticate)
 
+	// the organizations of the user and the current one
+	orgService := service.NewOrganizationService(&s.Queries, s.Db)
+	r.Use(s.loadOrganization(orgService))
+
 	// static file server
 	fileServer := http.FileServer(http.FS(web.Files))
 	r.Handle("/assets/*", fileServer)
 
 	// s.Db is useless without the queries
-	appHandlers := handlers.NewHandlers(s.Queries, s.Db, s.Logger, s.Mailer, s.SessionManager, s.Config)
+	appHandlers := handlers.NewHandlers(s.Queries, s.Db, s.Logger, s.Mailer, s.SessionManager, s.Config, s.SocialProviders)
 
-	authService := service.NewAuthService(&s.Queries, s.Db)
+	authService := service.NewAuthService(&s.Queries, s.Db, s.Mailer, s.Config.Auth)
 	authHandlers := auth.NewAuthHandler(appHandlers, authService)
 
+	orgHandlers := organizations.NewOrganizationHandler(appHandlers, orgService)
+
+	adminHandlers := admin.NewAdminHandler(appHandlers, authService)
+
+	// development tools, only served when turned on and never in production
+	if s.Config.DevToolsEnabled() {
+		devHandlers := dev.NewDevHandler(appHandlers)
+		r.Get("/_dev/mail", devHandlers.MailViewHandler)
+	}
+
 	// No auth routes
 	r.With(
 		//middlewares
@@ -54,6 +75,19 @@ func (s *Server) RegisterRoutes() http.Handler {
 		r.Get("/login", authHandlers.LoginViewHandler)
 		r.Post("/login", authHandlers.LoginPostHandler)
 
+		// second step of the login for users with two-factor authentication
+		r.Get("/login/2fa", authHandlers.TwoFactorChallengeView)
+		r.With(httprate.LimitByIP(10, 1*time.Minute)).Post("/login/2fa", authHandlers.TwoFactorChallengePostHandler)
+
+		// passwordless login with a link sent by email
+		r.With(httprate.LimitByIP(5, 10*time.Minute)).Post("/login/magic-link", authHandlers.MagicLinkPostHandler)
+		r.Get("/login/magic", authHandlers.MagicLinkView)
+		r.Post("/login/magic", authHandlers.MagicLinkLoginHandler)
+
+		// passwordless login with a passkey
+		r.With(httprate.LimitByIP(10, 1*time.Minute)).Post("/login/passkey/begin", authHandlers.PasskeyLoginBeginHandler)
+		r.With(httprate.LimitByIP(10, 1*time.Minute)).Post("/login/passkey/finish", authHandlers.PasskeyLoginFinishHandler)
+
 		r.Get("/signup", authHandlers.SignUpViewHandler)
 		r.Post("/signup", authHandlers.SignUpPostHandler)
 
@@ -65,13 +99,31 @@ func (s *Server) RegisterRoutes() http.Handler {
 
 		// social logins
 		r.Get("/auth/{provider}", authHandlers.SocialAuthHandler)
-		r.Get("/auth/{provider}/callback", authHandlers.SocialAuthCallbackHandler)
 	})
 
 	// Public routes
 	r.Get("/", appHandlers.LandingViewHandler)
 	r.Get("/health", appHandlers.HealthHandler)
 
+	// email verification link, works both logged in and logged out
+	r.Get("/activate", authHandlers.ActivateHandler)
+
+	// social login callback, logs in or connects the account to the logged in user
+	r.Get("/auth/{provider}/callback", authHandlers.SocialAuthCallbackHandler)
+	// confirmation link of a social account with the email of an existing user. The
+	// link only shows the confirmation, mail scanners follow links.
+	r.Get("/connections/confirm", authHandlers.ConfirmAccountLinkView)
+	r.Post("/connections/confirm", authHandlers.ConfirmAccountLinkHandler)
+	// email change links, sent to the new and the current address. Like the
+	// connection links, they only show the confirmation.
+	r.Get("/email/confirm", authHandlers.ConfirmEmailChangeView)
+	r.Post("/email/confirm", authHandlers.ConfirmEmailChangeHandler)
+	r.Get("/email/cancel", authHandlers.CancelEmailChangeView)
+	r.Post("/email/cancel", authHandlers.CancelEmailChangeHandler)
+	// invitation links, for invitees with and without an account
+	r.Get("/invitations/accept", orgHandlers.InvitationViewHandler)
+	r.Post("/invitations/decline", orgHandlers.DeclineInvitationHandler)
+
 	// Protected routes
 	r.With(
 		//middlewares
@@ -79,16 +131,124 @@ func (s *Server) RegisterRoutes() http.Handler {
 	).Group(func(r chi.Router) {
 		r.Post("/logout", authHandlers.LogoutPostHandler)
 
+		r.Get("/verify-email", authHandlers.VerifyEmailView)
+		r.With(httprate.LimitByIP(5, 10*time.Minute)).Post("/verify-email/resend", authHandlers.ResendActivationHandler)
+
 		r.Get("/profile", authHandlers.ProfileViewHandler)
 		r.Post("/profile/update", authHandlers.UpdateUserNameAndImageHandler)
-		r.Post("/profile/update-password", authHandlers.UpdateAccountPasswordHandler)
-		r.Post("/profile/delete-account", authHandlers.DeleteAccountHandler)
-
-		r.Get("/projects", appHandlers.ProjectViewHandler)
+		r.Get("/profile/email", authHandlers.EmailSectionHandler)
+		r.Get("/profile/2fa", authHandlers.TwoFactorSectionHandler)
+		r.Get("/profile/passkeys", authHandlers.PasskeysSectionHandler)
+		r.Get("/profile/sessions", authHandlers.SessionsSectionHandler)
+		r.Get("/profile/tokens", authHandlers.AccessTokensSectionHandler)
+		r.Get("/profile/connections", authHandlers.ConnectionsSectionHandler)
+
+		// credentials, sessions and the account stay out of reach of impersonating admins
+		r.With(s.forbidImpersonation).Group(func(r chi.Router) {
+			r.Post("/profile/update-password", authHandlers.UpdateAccountPasswordHandler)
+			r.Post("/profile/delete-account", authHandlers.DeleteAccountHandler)
+
+			r.Post("/profile/email", authHandlers.ChangeEmailHandler)
+			r.Post("/profile/email/cancel", authHandlers.CancelPendingEmailChangeHandler)
+
+			r.Post("/profile/2fa/setup", authHandlers.TwoFactorSetupHandler)
+			r.Post("/profile/2fa/confirm", authHandlers.TwoFactorConfirmHandler)
+			r.Post("/profile/2fa/disable", authHandlers.DisableTwoFactorHandler)
+			r.Post("/profile/2fa/recovery-codes", authHandlers.RegenerateRecoveryCodesHandler)
+
+			r.Post("/profile/passkeys/register/begin", authHandlers.PasskeyRegisterBeginHandler)
+			r.Post("/profile/passkeys/register/finish", authHandlers.PasskeyRegisterFinishHandler)
+			r.Post("/profile/passkeys/{id}/rename", authHandlers.RenamePasskeyHandler)
+			r.Post("/profile/passkeys/{id}/delete", authHandlers.DeletePasskeyHandler)
+
+			r.Post("/profile/sessions/{id}/revoke", authHandlers.RevokeSessionHandler)
+			r.Post("/profile/sessions/revoke-others", authHandlers.RevokeOtherSessionsHandler)
+
+			r.Post("/profile/tokens", authHandlers.CreateAccessTokenHandler)
+			r.Post("/profile/tokens/{id}/revoke", authHandlers.RevokeAccessTokenHandler)
+
+			r.Post("/profile/connections/{provider}/connect", authHandlers.ConnectSocialAccountHandler)
+			r.Post("/profile/connections/{provider}/disconnect", authHandlers.DisconnectSocialAccountHandler)
+		})
+
+		r.Get("/organizations/new", orgHandlers.NewOrganizationView)
+		r.Post("/organizations", orgHandlers.CreateOrganizationHandler)
+		r.Post("/organizations/switch", orgHandlers.SwitchOrganizationHandler)
+		r.Post("/invitations/accept", orgHandlers.AcceptInvitationHandler)
+
+		// data of the current organization
+		r.With(s.requireOrganization).Group(func(r chi.Router) {
+			r.Get("/projects", orgHandlers.ProjectsViewHandler)
+			r.Post("/projects", orgHandlers.CreateProjectHandler)
+			r.Post("/projects/{id}/delete", orgHandlers.DeleteProjectHandler)
+
+			r.Get("/members", orgHandlers.MembersViewHandler)
+			r.Post("/members/invitations", orgHandlers.InviteHandler)
+			r.Post("/members/invitations/{id}/resend", orgHandlers.ResendInvitationHandler)
+			r.Post("/members/invitations/{id}/revoke", orgHandlers.RevokeInvitationHandler)
+		})
+
+		// back office
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(s.requirePermission(config.PermissionUsersView))
+
+			r.Get("/", http.RedirectHandler("/admin/users", http.StatusSeeOther).ServeHTTP)
+			r.Get("/users", adminHandlers.UsersViewHandler)
+			r.Get("/users/{id}", adminHandlers.UserViewHandler)
+
+			r.With(s.requirePermission(config.PermissionUsersManage)).Group(func(r chi.Router) {
+				r.Post("/users/{id}/verify", adminHandlers.VerifyUserHandler)
+				r.Post("/users/{id}/disable", adminHandlers.DisableUserHandler)
+				r.Post("/users/{id}/enable", adminHandlers.EnableUserHandler)
+				r.Post("/users/{id}/reset-password", adminHandlers.ResetPasswordHandler)
+				r.Post("/users/{id}/delete", adminHandlers.DeleteUserHandler)
+			})
+
+			r.With(s.requirePermission(config.PermissionUsersImpersonate)).Post("/users/{id}/impersonate", adminHandlers.ImpersonateHandler)
+
+			r.With(s.requirePermission(config.PermissionAuditView)).Get("/audit", adminHandlers.AuditViewHandler)
+			r.With(s.requirePermission(config.PermissionTasksView)).Get("/tasks", adminHandlers.TasksViewHandler)
+
+			r.With(s.requirePermission(config.PermissionOutboxView)).Group(func(r chi.Router) {
+				r.Get("/outbox", adminHandlers.OutboxViewHandler)
+				r.Get("/outbox/{id}", adminHandlers.OutboxMessageViewHandler)
+			})
+		})
+
+		// the impersonator has no permissions while logged in as the user
+		r.Post("/impersonation/stop", adminHandlers.StopImpersonatingHandler)
 
 		r.Get("/dashboard", appHandlers.DashboardViewHandler)
 		r.Post("/hello", appHandlers.HelloWebHandler)
 	})
 
+	// OpenAPI document of the JSON API and the auth forms
+	r.Get("/api/openapi.json", s.openAPIHandler(r))
+	if s.Config.APIDocs {
+		r.Get("/api/docs", openapi.DocsHandler(s.Config.AppName, "/api/openapi.json"))
+	}
+
+	// JSON API for scripts and integrations. Requests authenticate with a personal
+	// access token, never with the session cookie, so CSRF doesn't apply.
+	apiHandlers := api.NewAPIHandler(appHandlers, authService)
+
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(s.authenticateToken)
+
+		r.NotFound(apiHandlers.NotFoundHandler)
+		r.MethodNotAllowed(apiHandlers.MethodNotAllowedHandler)
+
+		r.With(httprate.LimitByIP(100, 1*time.Minute)).Post("/signup", apiHandlers.SignUpHandler)
+		// the login takes the two-factor code too, so it is limited like /login/2fa
+		r.With(httprate.LimitByIP(10, 1*time.Minute)).Post("/login", apiHandlers.LoginHandler)
+
+		r.With(s.requireAPIAuth).Group(func(r chi.Router) {
+			r.Get("/profile", apiHandlers.ProfileHandler)
+			r.Patch("/profile", apiHandlers.UpdateProfileHandler)
+			r.Delete("/profile", apiHandlers.DeleteAccountHandler)
+			r.Put("/profile/password", apiHandlers.UpdatePasswordHandler)
+		})
+	})
+
 	return r
 }
@@ -9,14 +9,17 @@ import (
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/joho/godotenv"
-	"github.com/markbates/goth"
-	"github.com/markbates/goth/providers/google"
 
 	"go-web-starter/internal/config"
 	"go-web-starter/internal/database"
+	"go-web-starter/internal/jobs"
 	"go-web-starter/internal/jsonlog"
 	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/outbox"
 	"go-web-starter/internal/queries"
+	"go-web-starter/internal/scheduler"
+	"go-web-starter/internal/service"
+	"go-web-starter/internal/types"
 
 	"github.com/alexedwards/scs/postgresstore"
 )
@@ -29,6 +32,8 @@ type Server struct {
 	Logger         *jsonlog.Logger
 	SessionManager *scs.SessionManager
 	Config         config.Config
+	// SocialProviders are the social logins registered with goth
+	SocialProviders []types.SocialProvider
 }
 
 func NewServer(cfg config.Config, db database.Service, q *queries.Queries, logger *jsonlog.Logger, mailer mailer.Mailer, sessionManager *scs.SessionManager) *Server {
@@ -42,6 +47,8 @@ func NewServer(cfg config.Config, db database.Service, q *queries.Queries, logge
 		Config:         cfg,
 	}
 
+	s.SocialProviders = useSocialProviders(cfg, logger)
+
 	return s
 }
 
@@ -58,7 +65,8 @@ func NewSessionManager(db *sql.DB) *scs.SessionManager {
 	return sessionManager
 }
 
-func NewHttpServer() *http.Server {
+// NewFromEnv sets up the server with the configuration of the environment
+func NewFromEnv() *Server {
 	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
 
 	// load the .env file. by default, it will load the .env file in the root directory
@@ -69,34 +77,55 @@ func NewHttpServer() *http.Server {
 
 	config := config.LoadConfigFromEnv()
 
+	mailer, err := mailer.New(config.Mailer, mailer.NewBrand(config), logger)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
 	dbService := database.New(config.Database)
 	sqlDb := dbService.GetDB()
 
-	goth.UseProviders(
-		google.New(
-			config.SocialLogins.GoogleClientID,
-			config.SocialLogins.GoogleClientSecret,
-			fmt.Sprintf("%s/auth/google/callback", config.AppURL),
-		),
-	)
-
-	s := NewServer(
+	return NewServer(
 		config,
 		dbService,
 		queries.New(sqlDb),
 		logger,
-		mailer.New(config.Mailer),
+		mailer,
 		NewSessionManager(sqlDb),
 	)
+}
 
+// HTTPServer serves the routes on the configured port
+func (s *Server) HTTPServer() *http.Server {
 	// Declare Server config
-	httpServer := &http.Server{
+	return &http.Server{
 		Addr:         fmt.Sprintf(":%d", s.Port),
 		Handler:      s.RegisterRoutes(),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
+}
+
+// NewWorker returns a worker of the job queue with the handlers of all jobs
+func (s *Server) NewWorker() *jobs.Worker {
+	worker := jobs.NewWorker(&s.Queries, s.Logger, s.Config.Jobs)
+
+	service.NewAuthService(&s.Queries, s.Db, s.Mailer, s.Config.Auth).RegisterJobs(worker)
+
+	return worker
+}
+
+// NewDispatcher returns a dispatcher that delivers the emails of the outbox
+func (s *Server) NewDispatcher() *outbox.Dispatcher {
+	return outbox.NewDispatcher(&s.Queries, s.Mailer, s.Logger, s.Config.Jobs, s.Config.AppURL)
+}
+
+// NewScheduler returns a scheduler with all recurring tasks
+func (s *Server) NewScheduler() (*scheduler.Scheduler, error) {
+	tasks := scheduler.New(s.Db.GetDB(), s.Logger)
+
+	err := service.NewAuthService(&s.Queries, s.Db, s.Mailer, s.Config.Auth).RegisterTasks(tasks)
 
-	return httpServer
+	return tasks, err
 }
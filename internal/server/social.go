@@ -0,0 +1,95 @@
+package server
+
+import (
+	"crypto/rand"
+	"fmt"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/jsonlog"
+	"go-web-starter/internal/types"
+
+	"github.com/gorilla/sessions"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
+	"github.com/markbates/goth/providers/github"
+	"github.com/markbates/goth/providers/gitlab"
+	"github.com/markbates/goth/providers/google"
+	"github.com/markbates/goth/providers/microsoftonline"
+	"github.com/markbates/goth/providers/openidConnect"
+)
+
+// useSocialProviders registers the social login providers configured in cfg with
+// goth and returns them in the order their buttons are shown. Providers that fail
+// to set up are logged and left out.
+func useSocialProviders(cfg config.Config, logger *jsonlog.Logger) []types.SocialProvider {
+	social := cfg.SocialLogins
+
+	callbackURL := func(name string) string {
+		return fmt.Sprintf("%s/auth/%s/callback", cfg.AppURL, name)
+	}
+
+	var providers []goth.Provider
+	var configured []types.SocialProvider
+
+	add := func(provider goth.Provider, displayName string) {
+		providers = append(providers, provider)
+		configured = append(configured, types.SocialProvider{
+			Name:        provider.Name(),
+			DisplayName: displayName,
+		})
+	}
+
+	if social.GoogleClientID != "" {
+		add(google.New(social.GoogleClientID, social.GoogleClientSecret, callbackURL("google"), "email", "profile"), "Google")
+	}
+
+	if social.GitHubClientID != "" {
+		add(github.New(social.GitHubClientID, social.GitHubClientSecret, callbackURL("github"), "read:user", "user:email"), "GitHub")
+	}
+
+	if social.GitLabClientID != "" {
+		add(gitlab.New(social.GitLabClientID, social.GitLabClientSecret, callbackURL("gitlab"), "read_user"), "GitLab")
+	}
+
+	if social.MicrosoftClientID != "" {
+		microsoft := microsoftonline.New(social.MicrosoftClientID, social.MicrosoftClientSecret, callbackURL("microsoft"))
+		microsoft.SetName("microsoft")
+		add(microsoft, "Microsoft")
+	}
+
+	if social.OIDCClientID != "" {
+		// discovery fetches the provider metadata, so this fails when the provider is down
+		oidc, err := openidConnect.New(social.OIDCClientID, social.OIDCClientSecret, callbackURL("oidc"), social.OIDCDiscoveryURL, "email", "profile")
+		if err != nil {
+			logger.PrintError(fmt.Errorf("openid connect provider disabled: %w", err), map[string]string{
+				"discovery_url": social.OIDCDiscoveryURL,
+			})
+		} else {
+			oidc.SetName("oidc")
+			add(oidc, social.OIDCName)
+		}
+	}
+
+	goth.UseProviders(providers...)
+
+	gothic.Store = newGothicStore(cfg)
+
+	return configured
+}
+
+// newGothicStore returns the cookie store gothic keeps the OAuth state in. gothic
+// reads SESSION_SECRET before the .env file is loaded, so it is set up here. Without
+// a secret a random one is used and logins in progress break on restart.
+func newGothicStore(cfg config.Config) sessions.Store {
+	secret := []byte(cfg.SocialLogins.SessionSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+
+	store := sessions.NewCookieStore(secret)
+	store.Options.Path = "/"
+	store.Options.HttpOnly = true
+	store.Options.Secure = cfg.AppEnv == "production"
+
+	return store
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"go-web-starter/internal/queries"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AccessTokenPrefix starts every personal access token so they are easy to recognize,
+// e.g. by secret scanners
+const AccessTokenPrefix = "pat_"
+
+var ErrAccessTokenNotFound = errors.New("access token not found")
+
+// CreateAccessToken creates a personal access token and returns its plaintext, which
+// is shown to the user once. Only the hash is stored. A ttl of 0 never expires.
+func (as *AuthService) CreateAccessToken(ctx context.Context, userID int32, name string, scopes []string, ttl time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	// 20 random bytes encode to 32 base32 characters without padding
+	randomBytes := make([]byte, 20)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+
+	plaintext := AccessTokenPrefix + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes))
+	hash := sha256.Sum256([]byte(plaintext))
+
+	var expiresAt sql.NullTime
+	if ttl > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().Add(ttl), Valid: true}
+	}
+
+	_, err := as.dbQueries.CreatePersonalAccessToken(ctx, queries.CreatePersonalAccessTokenParams{
+		UserID:    userID,
+		Name:      name,
+		Hash:      hash[:],
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return plaintext, nil
+}
+
+func (as *AuthService) ListAccessTokens(ctx context.Context, userID int32) ([]queries.PersonalAccessToken, error) {
+	return as.dbQueries.ListPersonalAccessTokens(ctx, userID)
+}
+
+// RevokeAccessToken deletes a token of the user. Tokens of other users are reported
+// as not found.
+func (as *AuthService) RevokeAccessToken(ctx context.Context, userID, tokenID int32) error {
+	deleted, err := as.dbQueries.DeletePersonalAccessToken(ctx, queries.DeletePersonalAccessTokenParams{
+		ID:     tokenID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrAccessTokenNotFound
+	}
+
+	return nil
+}
+
+// AccessTokenHasScope reports whether the token was granted scope
+func AccessTokenHasScope(token queries.PersonalAccessToken, scope string) bool {
+	return slices.Contains(strings.Fields(token.Scopes), scope)
+}
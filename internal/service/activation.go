@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/jobs"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/outbox"
+	"go-web-starter/internal/queries"
+	"time"
+)
+
+var ErrEmailAlreadyVerified = errors.New("email address is already verified")
+
+// queueWelcomeEmails adds the email greeting a user that just signed up to the
+// outbox and queues the one sending them the link to verify their email address,
+// unless it is verified already. Both are part of the transaction of qtx, so they
+// are only sent when the signup commits, and a slow mail server doesn't hold it up.
+func (as *AuthService) queueWelcomeEmails(ctx context.Context, qtx *queries.Queries, user queries.User, baseURL string) error {
+	welcome := mailer.NewMessage(mailer.WelcomeEmail{Name: user.Name}).To(user.Email)
+	_, err := outbox.Add(ctx, qtx, fmt.Sprintf("welcome:%d", user.ID), welcome)
+	if err != nil || user.EmailVerified {
+		return err
+	}
+
+	_, err = jobs.Enqueue(ctx, qtx, ActivationEmailJob{UserID: user.ID, BaseURL: baseURL})
+	return err
+}
+
+// SendActivationEmail creates a fresh activation token for the user and mails
+// them a link to verify their email address. Older activation links stop working.
+func (as *AuthService) SendActivationEmail(ctx context.Context, user *queries.User, baseURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+
+	// only the latest activation link should be valid
+	err := as.dbQueries.DeleteAllForUser(ctx, queries.DeleteAllForUserParams{
+		Scope:  config.ScopeActivation,
+		UserID: int64(user.ID),
+	})
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := as.GenerateToken(ctx, int64(user.ID), as.config.ActivationTokenTTL, config.ScopeActivation)
+	if err != nil {
+		return err
+	}
+
+	return as.mailer.SendMessage(ctx, mailer.NewMessage(mailer.ActivationEmail{
+		Name:      user.Name,
+		Link:      fmt.Sprintf("%s/activate?token=%s", baseURL, plaintext),
+		ExpiresIn: as.config.ActivationTokenTTL,
+	}).To(user.Email))
+}
+
+// ActivateUser marks the email address of the token owner as verified and
+// removes all of their activation tokens.
+func (as *AuthService) ActivateUser(ctx context.Context, token string) (*queries.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	user, err := as.getTokenUser(ctx, token, config.ScopeActivation)
+	if err != nil {
+		return nil, err
+	}
+
+	verifiedUser, err := as.dbQueries.VerifyUserEmail(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = as.dbQueries.DeleteAllForUser(ctx, queries.DeleteAllForUserParams{
+		Scope:  config.ScopeActivation,
+		UserID: int64(user.ID),
+	})
+
+	return &verifiedUser, err
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/outbox"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/types"
+	"strings"
+	"time"
+)
+
+// UsersPageSize is the number of users on a page of the back office
+const UsersPageSize = 20
+
+// passwordResetRequiredTTL is how long the link of a forced password reset works
+const passwordResetRequiredTTL = 24 * time.Hour
+
+var (
+	ErrUserDisabled      = errors.New("user is disabled")
+	ErrOwnUser           = errors.New("admins can't disable or delete themselves")
+	ErrNoPasswordAccount = errors.New("user logs in without a password")
+)
+
+// likeEscaper escapes the wildcards of LIKE patterns
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListUsers returns a page of the users matching the filter, newest first
+func (as *AuthService) ListUsers(ctx context.Context, filter types.UserFilter) (types.UserPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	search := ""
+	if s := strings.TrimSpace(filter.Search); s != "" {
+		search = "%" + likeEscaper.Replace(s) + "%"
+	}
+
+	total, err := as.dbQueries.CountUsers(ctx, queries.CountUsersParams{
+		Search: search,
+		Status: filter.Status,
+	})
+	if err != nil {
+		return types.UserPage{}, err
+	}
+
+	totalPages := max(int((total+UsersPageSize-1)/UsersPageSize), 1)
+	filter.Page = min(max(filter.Page, 1), totalPages)
+
+	users, err := as.dbQueries.ListUsers(ctx, queries.ListUsersParams{
+		Search:     search,
+		Status:     filter.Status,
+		PageSize:   UsersPageSize,
+		PageOffset: int32((filter.Page - 1) * UsersPageSize),
+	})
+	if err != nil {
+		return types.UserPage{}, err
+	}
+
+	return types.UserPage{
+		Users:      users,
+		Filter:     filter,
+		Total:      total,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// GetUserDetails returns the user with their accounts, active sessions, roles and
+// the latest impersonations
+func (as *AuthService) GetUserDetails(ctx context.Context, id int32) (types.UserDetails, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	var details types.UserDetails
+
+	user, err := as.getUser(ctx, id)
+	if err != nil {
+		return details, err
+	}
+	details.User = user
+
+	disabled, err := as.dbQueries.GetDisabledUser(ctx, id)
+	switch {
+	case err == nil:
+		details.DisabledAt = sql.NullTime{Time: disabled.CreatedAt, Valid: true}
+	case !errors.Is(err, sql.ErrNoRows):
+		return details, err
+	}
+
+	details.Accounts, err = as.dbQueries.ListAccountsForUser(ctx, id)
+	if err != nil {
+		return details, err
+	}
+
+	// the admin is never looking at one of their own sessions as the current one
+	details.Sessions, err = as.ListSessions(ctx, id, "")
+	if err != nil {
+		return details, err
+	}
+
+	details.Roles, err = as.dbQueries.ListRolesForUser(ctx, id)
+	if err != nil {
+		return details, err
+	}
+
+	details.Impersonations, err = as.dbQueries.ListImpersonationsOfUser(ctx, sql.NullInt32{Int32: id, Valid: true})
+
+	return details, err
+}
+
+// VerifyUser marks the email address of the user as verified
+func (as *AuthService) VerifyUser(ctx context.Context, admin *queries.User, id int32) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	return as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		_, err := qtx.VerifyUserEmail(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		return recordAuditEvent(ctx, qtx, AuditEvent{
+			Action:   config.AuditUserVerified,
+			ActorID:  admin.ID,
+			TargetID: id,
+		})
+	})
+}
+
+// DisableUser keeps the user from logging in and signs out all of their sessions.
+// Their access tokens stop working until the user is enabled again.
+func (as *AuthService) DisableUser(ctx context.Context, admin *queries.User, id int32) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	if admin.ID == id {
+		return ErrOwnUser
+	}
+
+	if _, err := as.getUser(ctx, id); err != nil {
+		return err
+	}
+
+	return as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		err := qtx.DisableUser(ctx, queries.DisableUserParams{
+			UserID:     id,
+			DisabledBy: sql.NullInt32{Int32: admin.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := qtx.DeleteAllUserSessions(ctx, id); err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, qtx, AuditEvent{
+			Action:   config.AuditUserDisabled,
+			ActorID:  admin.ID,
+			TargetID: id,
+		})
+	})
+}
+
+// EnableUser lets a disabled user log in again
+func (as *AuthService) EnableUser(ctx context.Context, admin *queries.User, id int32) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	if _, err := as.getUser(ctx, id); err != nil {
+		return err
+	}
+
+	return as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		if err := qtx.EnableUser(ctx, id); err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, qtx, AuditEvent{
+			Action:   config.AuditUserEnabled,
+			ActorID:  admin.ID,
+			TargetID: id,
+		})
+	})
+}
+
+// ForcePasswordReset removes the password of the user, signs out their sessions
+// and emails them a link to choose a new password. Until then the user can only log
+// in without a password, e.g. with a magic link or a social login.
+func (as *AuthService) ForcePasswordReset(ctx context.Context, admin *queries.User, id int32, baseURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	user, err := as.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		account, err := qtx.GetPasswordAccountByUserId(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNoPasswordAccount
+			}
+			return err
+		}
+
+		err = qtx.UpdateAccountPassword(ctx, queries.UpdateAccountPasswordParams{
+			ID:       account.ID,
+			Password: sql.NullString{},
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := qtx.DeleteAllUserSessions(ctx, id); err != nil {
+			return err
+		}
+
+		// earlier reset links would still work otherwise
+		err = qtx.DeleteAllForUser(ctx, queries.DeleteAllForUserParams{
+			Scope:  config.ScopePasswordReset,
+			UserID: int64(id),
+		})
+		if err != nil {
+			return err
+		}
+
+		plaintext, hash, err := createToken(ctx, qtx, int64(id), passwordResetRequiredTTL, config.ScopePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		err = recordAuditEvent(ctx, qtx, AuditEvent{
+			Action:   config.AuditUserPasswordReset,
+			ActorID:  admin.ID,
+			TargetID: id,
+		})
+		if err != nil {
+			return err
+		}
+
+		// the email only goes out when the password was removed
+		msg := mailer.NewMessage(mailer.PasswordResetRequiredEmail{
+			Name:      user.Name,
+			Link:      fmt.Sprintf("%s/reset-password?token=%s", baseURL, plaintext),
+			ExpiresIn: passwordResetRequiredTTL,
+		}).To(user.Email)
+
+		_, err = outbox.Add(ctx, qtx, fmt.Sprintf("password-reset-required:%d:%x", id, hash[:8]), msg)
+		return err
+	})
+}
+
+// DeleteUser deletes the user and all of their data
+func (as *AuthService) DeleteUser(ctx context.Context, admin *queries.User, id int32) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	if admin.ID == id {
+		return ErrOwnUser
+	}
+
+	user, err := as.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		if err := deleteUser(ctx, qtx, id); err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, qtx, AuditEvent{
+			Action:   config.AuditUserDeleted,
+			ActorID:  admin.ID,
+			TargetID: id,
+			Metadata: map[string]any{"email": user.Email},
+		})
+	})
+}
+
+// checkDisabled returns ErrUserDisabled for disabled users
+func (as *AuthService) checkDisabled(ctx context.Context, userID int32) error {
+	_, err := as.dbQueries.GetDisabledUser(ctx, userID)
+	switch {
+	case err == nil:
+		return ErrUserDisabled
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	default:
+		return err
+	}
+}
+
+func (as *AuthService) getUser(ctx context.Context, id int32) (queries.User, error) {
+	user, err := as.dbQueries.GetUserById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, ErrUserNotFound
+	}
+
+	return user, err
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/types"
+	"io"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuditPageSize is the number of events on a page of the audit log
+const AuditPageSize = 50
+
+// auditExportBatchSize is the number of events read at a time by ExportAuditEvents
+const auditExportBatchSize = 500
+
+// AuditEvent is a security-relevant event of the audit log
+type AuditEvent struct {
+	// Action is one of config.AuditActions
+	Action string
+	// ActorID is the user who did it, 0 for logged out visitors
+	ActorID int32
+	// TargetID is the user it was done to, 0 when there is none
+	TargetID int32
+	Metadata map[string]any
+}
+
+// RecordAuditEvent writes the event to the audit log
+func (as *AuthService) RecordAuditEvent(ctx context.Context, event AuditEvent) error {
+	return recordAuditEvent(ctx, as.dbQueries, event)
+}
+
+// recordAuditEvent writes the event with q, within the transaction of q if there is
+// one. The client is taken from the context, as is the admin impersonating the
+// actor, who is added to the metadata.
+func recordAuditEvent(ctx context.Context, q *queries.Queries, event AuditEvent) error {
+	client, _ := ctx.Value(config.ClientContextKey).(types.Client)
+
+	metadata := maps.Clone(event.Metadata)
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	if impersonator, ok := ctx.Value(config.ImpersonatorContextKey).(queries.User); ok {
+		metadata["impersonator_id"] = impersonator.ID
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	return q.CreateAuditEvent(ctx, queries.CreateAuditEventParams{
+		Action:    event.Action,
+		ActorID:   auditUserID(event.ActorID),
+		TargetID:  auditUserID(event.TargetID),
+		IpAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Metadata:  encoded,
+	})
+}
+
+func auditUserID(id int32) sql.NullInt32 {
+	return sql.NullInt32{Int32: id, Valid: id != 0}
+}
+
+// ListAuditEvents returns a page of the events matching the filter, newest first
+func (as *AuthService) ListAuditEvents(ctx context.Context, filter types.AuditFilter) (types.AuditPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	params := auditEventsParams(filter)
+
+	total, err := as.dbQueries.CountAuditEvents(ctx, queries.CountAuditEventsParams{
+		Action: params.Action,
+		Search: params.Search,
+		Since:  params.Since,
+		Until:  params.Until,
+	})
+	if err != nil {
+		return types.AuditPage{}, err
+	}
+
+	totalPages := max(int((total+AuditPageSize-1)/AuditPageSize), 1)
+	filter.Page = min(max(filter.Page, 1), totalPages)
+
+	params.PageSize = AuditPageSize
+	params.PageOffset = int32((filter.Page - 1) * AuditPageSize)
+
+	events, err := as.dbQueries.ListAuditEvents(ctx, params)
+	if err != nil {
+		return types.AuditPage{}, err
+	}
+
+	return types.AuditPage{
+		Events:     events,
+		Filter:     filter,
+		Total:      total,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ExportAuditEvents writes the events matching the filter to w as CSV, newest first,
+// and returns how many there were. The page of the filter is ignored.
+func (as *AuthService) ExportAuditEvents(ctx context.Context, filter types.AuditFilter, w io.Writer) (int, error) {
+	params := auditEventsParams(filter)
+	// events written during the export would shift the batches
+	if !params.Until.Valid {
+		params.Until = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	params.PageSize = auditExportBatchSize
+
+	out := csv.NewWriter(w)
+	err := out.Write([]string{
+		"id", "created_at", "action", "actor_id", "actor_email", "target_id",
+		"target_email", "ip_address", "user_agent", "metadata",
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for {
+		events, err := as.dbQueries.ListAuditEvents(ctx, params)
+		if err != nil {
+			return count, err
+		}
+
+		for _, event := range events {
+			err := out.Write([]string{
+				strconv.FormatInt(event.ID, 10),
+				event.CreatedAt.UTC().Format(time.RFC3339),
+				event.Action,
+				formatAuditUserID(event.ActorID),
+				event.ActorEmail,
+				formatAuditUserID(event.TargetID),
+				event.TargetEmail,
+				event.IpAddress,
+				event.UserAgent,
+				string(event.Metadata),
+			})
+			if err != nil {
+				return count, err
+			}
+		}
+		count += len(events)
+
+		if len(events) < auditExportBatchSize {
+			break
+		}
+		params.PageOffset += auditExportBatchSize
+	}
+
+	out.Flush()
+
+	return count, out.Error()
+}
+
+func formatAuditUserID(id sql.NullInt32) string {
+	if !id.Valid {
+		return ""
+	}
+
+	return strconv.Itoa(int(id.Int32))
+}
+
+// auditEventsParams turns the filter into the parameters of ListAuditEvents,
+// without the page
+func auditEventsParams(filter types.AuditFilter) queries.ListAuditEventsParams {
+	params := queries.ListAuditEventsParams{Action: filter.Action}
+
+	if s := strings.TrimSpace(filter.Search); s != "" {
+		params.Search = "%" + likeEscaper.Replace(s) + "%"
+	}
+
+	if since, err := time.ParseInLocation(time.DateOnly, filter.Since, time.UTC); err == nil {
+		params.Since = sql.NullTime{Time: since, Valid: true}
+	}
+	// the day of until is included
+	if until, err := time.ParseInLocation(time.DateOnly, filter.Until, time.UTC); err == nil {
+		params.Until = sql.NullTime{Time: until.AddDate(0, 0, 1), Valid: true}
+	}
+
+	return params
+}
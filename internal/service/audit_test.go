@@ -0,0 +1,39 @@
+package service
+
+import (
+	"go-web-starter/internal/types"
+	"testing"
+	"time"
+)
+
+func TestAuditEventsParams(t *testing.T) {
+	params := auditEventsParams(types.AuditFilter{
+		Action: "login.failed",
+		Search: " 100% ",
+		Since:  "2025-01-31",
+		Until:  "2025-02-01",
+		Page:   3,
+	})
+
+	if params.Action != "login.failed" {
+		t.Errorf("expected the action; got %q", params.Action)
+	}
+	if params.Search != `%100\%%` {
+		t.Errorf("expected an escaped pattern; got %q", params.Search)
+	}
+	if want := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC); !params.Since.Valid || !params.Since.Time.Equal(want) {
+		t.Errorf("expected since %v; got %v", want, params.Since)
+	}
+	// the day of until is included
+	if want := time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC); !params.Until.Valid || !params.Until.Time.Equal(want) {
+		t.Errorf("expected until %v; got %v", want, params.Until)
+	}
+	if params.PageSize != 0 || params.PageOffset != 0 {
+		t.Errorf("expected no page; got size %d offset %d", params.PageSize, params.PageOffset)
+	}
+
+	params = auditEventsParams(types.AuditFilter{Since: "yesterday", Until: ""})
+	if params.Since.Valid || params.Until.Valid || params.Search != "" {
+		t.Errorf("expected invalid and empty filters to be ignored; got %+v", params)
+	}
+}
@@ -10,23 +10,39 @@ import (
 	"fmt"
 	"go-web-starter/internal/config"
 	"go-web-starter/internal/database"
+	"go-web-starter/internal/mailer"
 	"go-web-starter/internal/queries"
+	"go-web-starter/internal/types"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/markbates/goth"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// PasswordResetTokenTTL is how long the link of a password reset email is valid
+const PasswordResetTokenTTL = 45 * time.Minute
+
+var ErrInvalidPassword = errors.New("invalid password")
+
 type AuthService struct {
 	dbQueries *queries.Queries
 	dbService database.Service
+	mailer    mailer.Mailer
+	config    config.Auth
+	// webAuthn is nil when passkeys are not configured
+	webAuthn *webauthn.WebAuthn
 }
 
-func NewAuthService(dbQueries *queries.Queries, db database.Service) *AuthService {
+func NewAuthService(dbQueries *queries.Queries, db database.Service, mailer mailer.Mailer, cfg config.Auth) *AuthService {
 	return &AuthService{
 		dbQueries: dbQueries,
 		dbService: db,
+		mailer:    mailer,
+		config:    cfg,
+		webAuthn:  newWebAuthn(cfg),
 	}
 }
 
@@ -42,6 +58,12 @@ func hashPassword(plainTextPassword string) (string, error) {
 }
 
 func (as *AuthService) GenerateToken(ctx context.Context, userID int64, ttl time.Duration, scope string) (string, error) {
+	plaintext, _, err := createToken(ctx, as.dbQueries, userID, ttl, scope)
+	return plaintext, err
+}
+
+// createToken stores a new token of the user and returns it along with its hash
+func createToken(ctx context.Context, q *queries.Queries, userID int64, ttl time.Duration, scope string) (string, []byte, error) {
 	// Initialize a zero-valued byte slice with a length of 16 bytes.
 	randomBytes := make([]byte, 16)
 
@@ -49,7 +71,7 @@ func (as *AuthService) GenerateToken(ctx context.Context, userID int64, ttl time
 	// random bytes from your operating system's CSPRNG.
 	_, err := rand.Read(randomBytes)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	// Encode the byte slice to a base-32-encoded string and assign it to the token
@@ -67,7 +89,7 @@ func (as *AuthService) GenerateToken(ctx context.Context, userID int64, ttl time
 	hash := sha256.Sum256([]byte(plaintext))
 	newHash := hash[:]
 
-	_, err = as.dbQueries.CreateToken(ctx, queries.CreateTokenParams{
+	_, err = q.CreateToken(ctx, queries.CreateTokenParams{
 		UserID: userID,
 		Expiry: time.Now().Add(ttl),
 		Scope:  scope,
@@ -75,97 +97,80 @@ func (as *AuthService) GenerateToken(ctx context.Context, userID int64, ttl time
 	})
 
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return plaintext, nil
+	return plaintext, newHash, nil
 }
 
-// ProcessSocialAuth handles both login and signup for social authentication
-func (as *AuthService) ProcessSocialAuth(ctx context.Context, gothUser goth.User, provider string) (*queries.User, error) {
+// ProcessSocialAuth handles both login and signup for social authentication. When
+// the email belongs to a user that hasn't connected this provider account yet, the
+// user is asked by email to confirm the link and ErrAccountLinkPending is returned.
+func (as *AuthService) ProcessSocialAuth(ctx context.Context, gothUser goth.User, provider types.SocialProvider, baseURL string) (*queries.User, error) {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	var user *queries.User
-	var err error
+	var linkUser *queries.User
 
 	// Use transaction for consistency
-	err = as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+	err := as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
 		qtx := as.dbQueries.WithTx(tx)
 
-		// Check if user exists
-		existingUser, userErr := qtx.GetUserByEmail(ctx, gothUser.Email)
+		// Check if the provider account is connected to a user already
+		account, err := qtx.GetAccountByProvider(ctx, queries.GetAccountByProviderParams{
+			ProviderID: sql.NullString{String: provider.Name, Valid: true},
+			AccountID:  gothUser.UserID,
+		})
 
-		if userErr == nil {
-			// User exists - verify provider linkage
-			user, err = as.linkOrVerifyProvider(ctx, qtx, existingUser, gothUser, provider)
+		switch {
+		case err == nil:
+			existingUser, err := qtx.GetUserById(ctx, account.UserID)
 			if err != nil {
-				return fmt.Errorf("failed to link provider: %w", err)
+				return fmt.Errorf("database error: %w", err)
 			}
-		} else if errors.Is(userErr, sql.ErrNoRows) {
-			// New user - create account
-			user, err = as.createSocialUser(ctx, qtx, gothUser, provider)
-			if err != nil {
-				return fmt.Errorf("failed to create user: %w", err)
+			user = &existingUser
+		case errors.Is(err, sql.ErrNoRows):
+			existingUser, userErr := qtx.GetUserByEmail(ctx, gothUser.Email)
+
+			if userErr == nil {
+				// Same email but not connected - the owner of the email has to confirm
+				linkUser = &existingUser
+				return nil
+			} else if errors.Is(userErr, sql.ErrNoRows) {
+				// New user - create account
+				user, err = as.createSocialUser(ctx, qtx, gothUser, provider.Name)
+				if err != nil {
+					return fmt.Errorf("failed to create user: %w", err)
+				}
+			} else {
+				// Database error
+				return fmt.Errorf("database error: %w", userErr)
 			}
-		} else {
-			// Database error
-			return fmt.Errorf("database error: %w", userErr)
+		default:
+			return fmt.Errorf("database error: %w", err)
 		}
 
 		// TODO: no need to store OAuth tokens in database
 		// Update OAuth tokens
-		if err := as.updateOAuthTokens(ctx, qtx, user.ID, gothUser, provider); err != nil {
+		if err := as.updateOAuthTokens(ctx, qtx, user.ID, gothUser, provider.Name); err != nil {
 			return fmt.Errorf("failed to update tokens: %w", err)
 		}
 
 		return nil
 	})
-
-	return user, err
-}
-
-// linkOrVerifyProvider handles existing users logging in with social auth
-func (as *AuthService) linkOrVerifyProvider(
-	ctx context.Context,
-	qtx *queries.Queries,
-	user queries.User,
-	gothUser goth.User,
-	provider string,
-) (*queries.User, error) {
-	// Check if this provider is already linked
-	_, err := qtx.GetAccountByUserIdAndProvider(ctx, queries.GetAccountByUserIdAndProviderParams{
-		UserID:     user.ID,
-		ProviderID: sql.NullString{String: provider, Valid: true},
-	})
-
-	if err == nil {
-		// Provider already linked - just return the user
-		return &user, nil
-	}
-
-	if !errors.Is(err, sql.ErrNoRows) {
+	if err != nil {
 		return nil, err
 	}
 
-	// Provider not linked - for security, we should verify email ownership
-	// In production, you might want to send a verification email
-	// For now, we'll create the link if the email matches
-	if user.Email != gothUser.Email {
-		return nil, errors.New("email mismatch - cannot link provider")
+	if linkUser != nil {
+		if err := as.sendAccountLinkEmail(ctx, linkUser, gothUser, provider, baseURL); err != nil {
+			return nil, fmt.Errorf("failed to request account link: %w", err)
+		}
+		return nil, ErrAccountLinkPending
 	}
 
-	// TODO:  send verification email - user should verify their email
-	// Dont do it from here.
-
-	// Create new account link
-	_, err = qtx.CreateAccount(ctx, queries.CreateAccountParams{
-		UserID:     user.ID,
-		AccountID:  gothUser.UserID,
-		ProviderID: sql.NullString{String: provider, Valid: true},
-	})
-
-	return &user, err
+	return user, nil
 }
 
 // createSocialUser creates a new user from social auth
@@ -177,11 +182,19 @@ func (as *AuthService) createSocialUser(
 ) (*queries.User, error) {
 	log.Println("createSocialUser gothUser", gothUser)
 
+	name := gothUser.Name
+	if name == "" {
+		name = gothUser.NickName
+	}
+	if name == "" {
+		name, _, _ = strings.Cut(gothUser.Email, "@")
+	}
+
 	// Create user
 	user, err := qtx.CreateUser(ctx, queries.CreateUserParams{
-		Name:          gothUser.Name,
+		Name:          name,
 		Email:         gothUser.Email,
-		EmailVerified: gothUser.RawData["verified_email"].(bool),
+		EmailVerified: providerVerifiedEmail(gothUser),
 		Image:         sql.NullString{String: gothUser.AvatarURL, Valid: gothUser.AvatarURL != ""},
 	})
 
@@ -200,9 +213,39 @@ func (as *AuthService) createSocialUser(
 		return nil, err
 	}
 
+	if err := createPersonalOrganization(ctx, qtx, user); err != nil {
+		return nil, err
+	}
+
+	err = recordAuditEvent(ctx, qtx, AuditEvent{
+		Action:   config.AuditSignup,
+		ActorID:  user.ID,
+		TargetID: user.ID,
+		Metadata: map[string]any{"method": provider},
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &user, nil
 }
 
+// providerVerifiedEmail reports whether the provider says it has verified the email
+// address of the user. Google sends verified_email, OpenID Connect providers send the
+// email_verified claim, sometimes as a string. Everything else counts as unverified.
+func providerVerifiedEmail(gothUser goth.User) bool {
+	for _, claim := range []string{"verified_email", "email_verified"} {
+		switch verified := gothUser.RawData[claim].(type) {
+		case bool:
+			return verified
+		case string:
+			return verified == "true"
+		}
+	}
+
+	return false
+}
+
 // updateOAuthTokens updates the OAuth tokens for a user
 func (as *AuthService) updateOAuthTokens(
 	ctx context.Context,
@@ -229,10 +272,70 @@ func (as *AuthService) updateOAuthTokens(
 	return err
 }
 
-func (as *AuthService) Login(ctx context.Context, email string, password string) (*queries.User, error) {
+// Login checks the email and password. Repeated failures lock the email address
+// with exponential backoff, the owner is notified by an email with a password
+// reset link built from baseURL.
+func (as *AuthService) Login(ctx context.Context, email string, password string, baseURL string) (*queries.User, error) {
 	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
+	// don't even check the password of a locked address
+	if err := as.checkLockout(ctx, email); err != nil {
+		if errors.Is(err, ErrAccountLocked) {
+			if auditErr := as.recordFailedLoginEvent(ctx, email, nil, "locked"); auditErr != nil {
+				return nil, auditErr
+			}
+		}
+		return nil, err
+	}
+
+	user, err := as.checkCredentials(ctx, email, password)
+	if err != nil {
+		// unknown addresses are counted too, so a lockout doesn't reveal whether an account exists
+		if recordErr := as.recordFailedLogin(ctx, email, user, baseURL); recordErr != nil {
+			return nil, recordErr
+		}
+
+		if auditErr := as.recordFailedLoginEvent(ctx, email, user, "invalid_credentials"); auditErr != nil {
+			return nil, auditErr
+		}
+
+		return nil, err
+	}
+
+	if err := as.checkDisabled(ctx, user.ID); err != nil {
+		if errors.Is(err, ErrUserDisabled) {
+			if auditErr := as.recordFailedLoginEvent(ctx, email, user, "disabled"); auditErr != nil {
+				return nil, auditErr
+			}
+		}
+		return nil, err
+	}
+
+	if err := as.dbQueries.DeleteLoginAttempt(ctx, loginAttemptKey(email)); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// recordFailedLoginEvent writes a failed login to the audit log. user is nil when no
+// account exists for the address.
+func (as *AuthService) recordFailedLoginEvent(ctx context.Context, email string, user *queries.User, reason string) error {
+	event := AuditEvent{
+		Action:   config.AuditLoginFailed,
+		Metadata: map[string]any{"email": email, "reason": reason},
+	}
+	if user != nil {
+		event.TargetID = user.ID
+	}
+
+	return as.RecordAuditEvent(ctx, event)
+}
+
+// checkCredentials returns the user with the given email and password. When the
+// password is wrong, the user is returned along with the error.
+func (as *AuthService) checkCredentials(ctx context.Context, email string, password string) (*queries.User, error) {
 	var user queries.User
 	var account queries.Account
 	var userErr, accountErr error
@@ -240,7 +343,7 @@ func (as *AuthService) Login(ctx context.Context, email string, password string)
 	// Always perform both operations to prevent timing attacks
 	user, userErr = as.dbQueries.GetUserByEmail(ctx, email)
 	if userErr == nil {
-		account, accountErr = as.dbQueries.GetAccountByUserId(ctx, user.ID)
+		account, accountErr = as.dbQueries.GetPasswordAccountByUserId(ctx, user.ID)
 	}
 
 	// Always perform password check, even with dummy hash to prevent timing attacks
@@ -253,14 +356,21 @@ func (as *AuthService) Login(ctx context.Context, email string, password string)
 		passwordValid = false
 	}
 
-	if userErr != nil || accountErr != nil || !passwordValid {
+	if userErr != nil {
 		return nil, errors.New("invalid email or password")
 	}
 
+	if accountErr != nil || !passwordValid {
+		return &user, errors.New("invalid email or password")
+	}
+
 	return &user, nil
 }
 
-func (as *AuthService) SignUp(ctx context.Context, name, email, password string, emailVerified bool) (*queries.User, error) {
+// SignUp creates the account of a user that signed up with their email address.
+// The welcome and activation emails are queued with it, the activation links
+// point to baseURL.
+func (as *AuthService) SignUp(ctx context.Context, name, email, password, baseURL string) (*queries.User, error) {
 	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
@@ -268,32 +378,108 @@ func (as *AuthService) SignUp(ctx context.Context, name, email, password string,
 	err := as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
 		qtx := as.dbQueries.WithTx(tx)
 
-		u, err := qtx.CreateUser(ctx, queries.CreateUserParams{
-			Name:          name,
-			Email:         email,
-			EmailVerified: emailVerified,
-			Image:         sql.NullString{String: "", Valid: false},
-		})
+		var err error
+		createdUser, err = as.signUp(ctx, qtx, name, email, password, false)
 		if err != nil {
 			return err
 		}
-		createdUser = u
 
-		hashedPassword, err := hashPassword(password)
+		return as.queueWelcomeEmails(ctx, qtx, createdUser, baseURL)
+	})
+
+	return &createdUser, err
+}
+
+// CreateUser creates the account of a user with a verified email address without
+// emailing them, e.g. to seed the database
+func (as *AuthService) CreateUser(ctx context.Context, name, email, password string) (*queries.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	var createdUser queries.User
+	err := as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		var err error
+		createdUser, err = as.signUp(ctx, as.dbQueries.WithTx(tx), name, email, password, true)
+		return err
+	})
+
+	return &createdUser, err
+}
+
+// SignUpWithInvitation creates the account of an invited user and adds them to the
+// organization of the invitation. The email address is the invited one, following
+// the emailed link verified it. The welcome email is queued with it.
+func (as *AuthService) SignUpWithInvitation(ctx context.Context, name, password, token string) (*queries.User, types.Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	var createdUser queries.User
+	var membership types.Membership
+
+	err := as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		invitation, err := getInvitationByToken(ctx, qtx, token)
 		if err != nil {
 			return err
 		}
 
-		_, err = qtx.CreateAccount(ctx, queries.CreateAccountParams{
-			UserID:    createdUser.ID,
-			AccountID: createdUser.Name,
-			Password:  sql.NullString{String: hashedPassword, Valid: true},
-		})
+		createdUser, err = as.signUp(ctx, qtx, name, invitation.Email, password, true)
+		if err != nil {
+			return err
+		}
 
-		return err
+		membership, err = acceptInvitation(ctx, qtx, invitation, createdUser)
+		if err != nil {
+			return err
+		}
+
+		return as.queueWelcomeEmails(ctx, qtx, createdUser, "")
 	})
 
-	return &createdUser, err
+	return &createdUser, membership, err
+}
+
+func (as *AuthService) signUp(ctx context.Context, qtx *queries.Queries, name, email, password string, emailVerified bool) (queries.User, error) {
+	if err := as.checkEmailAvailable(ctx, qtx, email); err != nil {
+		return queries.User{}, err
+	}
+
+	createdUser, err := qtx.CreateUser(ctx, queries.CreateUserParams{
+		Name:          name,
+		Email:         email,
+		EmailVerified: emailVerified,
+		Image:         sql.NullString{String: "", Valid: false},
+	})
+	if err != nil {
+		return createdUser, err
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return createdUser, err
+	}
+
+	_, err = qtx.CreateAccount(ctx, queries.CreateAccountParams{
+		UserID:    createdUser.ID,
+		AccountID: createdUser.Name,
+		Password:  sql.NullString{String: hashedPassword, Valid: true},
+	})
+	if err != nil {
+		return createdUser, err
+	}
+
+	err = recordAuditEvent(ctx, qtx, AuditEvent{
+		Action:   config.AuditSignup,
+		ActorID:  createdUser.ID,
+		TargetID: createdUser.ID,
+		Metadata: map[string]any{"method": "password"},
+	})
+	if err != nil {
+		return createdUser, err
+	}
+
+	return createdUser, createPersonalOrganization(ctx, qtx, createdUser)
 }
 
 func (as *AuthService) GetPasswordResetLink(ctx context.Context, email string, baseURL string) (string, error) {
@@ -308,8 +494,7 @@ func (as *AuthService) GetPasswordResetLink(ctx context.Context, email string, b
 		return "", err
 	}
 
-	// create token with ttl of 45min
-	plaintext, err := as.GenerateToken(ctx, int64(user.ID), 45*time.Minute, config.ScopePasswordReset)
+	plaintext, err := as.GenerateToken(ctx, int64(user.ID), PasswordResetTokenTTL, config.ScopePasswordReset)
 	if err != nil {
 		println(err)
 		return "", err
@@ -321,13 +506,19 @@ func (as *AuthService) GetPasswordResetLink(ctx context.Context, email string, b
 }
 
 func (as *AuthService) GetValidTokenUser(ctx context.Context, token string) (*queries.User, error) {
+	return as.getTokenUser(ctx, token, config.ScopePasswordReset)
+}
+
+// getTokenUser returns the owner of a plaintext token, as long as the token has
+// the given scope and has not expired yet.
+func (as *AuthService) getTokenUser(ctx context.Context, token, scope string) (*queries.User, error) {
 	// hash the plainText token
 	tokenHash := sha256.Sum256([]byte(token))
 
 	// compare the token with the hashed one in the database
 	user, err := as.dbQueries.GetUserByToken(ctx, queries.GetUserByTokenParams{
 		Hash:   tokenHash[:],
-		Scope:  config.ScopePasswordReset,
+		Scope:  scope,
 		Expiry: time.Now(),
 	})
 
@@ -345,7 +536,7 @@ func (as *AuthService) ResetPassword(ctx context.Context, token, password string
 	}
 
 	// get user account
-	account, err := as.dbQueries.GetAccountByUserId(ctx, user.ID)
+	account, err := as.dbQueries.GetPasswordAccountByUserId(ctx, user.ID)
 	if err != nil {
 		return user, err
 	}
@@ -368,6 +559,29 @@ func (as *AuthService) ResetPassword(ctx context.Context, token, password string
 		Scope:  config.ScopePasswordReset,
 		UserID: int64(user.ID),
 	})
+	if err != nil {
+		return user, err
+	}
+
+	// whoever knew the old password must not stay logged in
+	err = as.dbQueries.DeleteAllUserSessions(ctx, user.ID)
+	if err != nil {
+		return user, err
+	}
+
+	// the reset link doubles as the way to unlock a locked account, whether the
+	// password or the second factor was locked
+	for _, key := range []string{loginAttemptKey(user.Email), twoFactorAttemptKey(user.Email)} {
+		if err := as.dbQueries.DeleteLoginAttempt(ctx, key); err != nil {
+			return user, err
+		}
+	}
+
+	err = as.RecordAuditEvent(ctx, AuditEvent{
+		Action:   config.AuditPasswordReset,
+		ActorID:  user.ID,
+		TargetID: user.ID,
+	})
 
 	return user, err
 }
@@ -385,19 +599,20 @@ func (as *AuthService) UpdateUserNameAndImage(ctx context.Context, id int32, nam
 	return user, err
 }
 
-func (as *AuthService) UpdateAccountPassword(ctx context.Context, userId int32, currentPassword, newPassword string) error {
+// UpdateAccountPassword changes the password of the user and signs out all of their
+// sessions except the one with currentSessionToken.
+func (as *AuthService) UpdateAccountPassword(ctx context.Context, userId int32, currentPassword, newPassword, currentSessionToken string) error {
 	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	// get user account
-	account, err := as.dbQueries.GetAccountByUserId(ctx, userId)
+	account, err := as.dbQueries.GetPasswordAccountByUserId(ctx, userId)
 	if err != nil {
 		return err
 	}
 
 	if !checkPasswordHash(account.Password.String, currentPassword) {
-		// invalid password - handle errors in login page
-		return errors.New("invalid password")
+		return ErrInvalidPassword
 	}
 
 	hashedNewPassword, err := hashPassword(newPassword)
@@ -409,39 +624,66 @@ func (as *AuthService) UpdateAccountPassword(ctx context.Context, userId int32,
 		ID:       account.ID,
 		Password: sql.NullString{String: hashedNewPassword, Valid: true},
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	err = as.RecordAuditEvent(ctx, AuditEvent{
+		Action:   config.AuditPasswordChanged,
+		ActorID:  userId,
+		TargetID: userId,
+	})
+	if err != nil {
+		return err
+	}
+
+	return as.RevokeOtherSessions(ctx, userId, currentSessionToken)
 }
 
 func (as *AuthService) DeleteAccount(ctx context.Context, email, password string) error {
 	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
-	user, err := as.Login(ctx, email, password)
+	user, err := as.checkCredentials(ctx, email, password)
 	if err != nil {
-		return errors.New("something went wrong")
+		return ErrInvalidPassword
 	}
 
 	// Use transaction to ensure all deletions succeed or fail together
 	return as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
 		qtx := as.dbQueries.WithTx(tx)
 
-		// Delete related data in the correct order (to respect foreign key constraints)
-		// Delete tokens
-		if err := qtx.DeleteTokensByUserId(ctx, int64(user.ID)); err != nil {
-			return fmt.Errorf("failed to delete tokens: %w", err)
+		if err := deleteUser(ctx, qtx, user.ID); err != nil {
+			return err
 		}
 
-		// Delete accounts
-		if err := qtx.DeleteAccountsByUserId(ctx, user.ID); err != nil {
-			return fmt.Errorf("failed to delete accounts: %w", err)
-		}
+		// the email is kept, the user is gone
+		return recordAuditEvent(ctx, qtx, AuditEvent{
+			Action:   config.AuditAccountDeleted,
+			ActorID:  user.ID,
+			TargetID: user.ID,
+			Metadata: map[string]any{"email": user.Email},
+		})
+	})
+}
 
-		// Delete user (this should be last)
-		if err := qtx.DeleteUser(ctx, user.ID); err != nil {
-			return fmt.Errorf("failed to delete user: %w", err)
-		}
+// deleteUser deletes the user along with their tokens and accounts
+func deleteUser(ctx context.Context, qtx *queries.Queries, userID int32) error {
+	// Delete related data in the correct order (to respect foreign key constraints)
+	// Delete tokens
+	if err := qtx.DeleteTokensByUserId(ctx, int64(userID)); err != nil {
+		return fmt.Errorf("failed to delete tokens: %w", err)
+	}
 
-		return nil
-	})
+	// Delete accounts
+	if err := qtx.DeleteAccountsByUserId(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete accounts: %w", err)
+	}
+
+	// Delete user (this should be last)
+	if err := qtx.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/queries"
+	"strings"
+	"time"
+)
+
+const emailChangeTokenTTL = 24 * time.Hour
+
+var (
+	ErrEmailUnchanged = errors.New("new email address is the current one")
+	ErrEmailTaken     = errors.New("email address is used by another user")
+)
+
+// GetPendingEmailChange returns the email change the user has yet to confirm, or
+// sql.ErrNoRows when there is none.
+func (as *AuthService) GetPendingEmailChange(ctx context.Context, userID int32) (*queries.EmailChange, error) {
+	change, err := as.dbQueries.GetEmailChange(ctx, queries.GetEmailChangeParams{
+		UserID: userID,
+		Expiry: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// RequestEmailChange mails a confirmation link to the new address and a link to
+// cancel the change to the current one. The user's email stays the same until the
+// new address is confirmed. A new request replaces the one before.
+func (as *AuthService) RequestEmailChange(ctx context.Context, user *queries.User, newEmail, baseURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	newEmail = strings.TrimSpace(newEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrEmailUnchanged
+	}
+
+	if err := as.checkEmailAvailable(ctx, as.dbQueries, newEmail); err != nil {
+		return err
+	}
+
+	// links of an earlier request stop working
+	if err := as.deleteEmailChange(ctx, as.dbQueries, user.ID); err != nil {
+		return err
+	}
+
+	err := as.dbQueries.UpsertEmailChange(ctx, queries.UpsertEmailChangeParams{
+		UserID:   user.ID,
+		NewEmail: newEmail,
+		Expiry:   time.Now().Add(emailChangeTokenTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	confirmToken, err := as.GenerateToken(ctx, int64(user.ID), emailChangeTokenTTL, config.ScopeEmailChange)
+	if err != nil {
+		return err
+	}
+
+	cancelToken, err := as.GenerateToken(ctx, int64(user.ID), emailChangeTokenTTL, config.ScopeEmailChangeCancel)
+	if err != nil {
+		return err
+	}
+
+	err = as.mailer.SendMessage(ctx, mailer.NewMessage(mailer.EmailChangeEmail{
+		Name:      user.Name,
+		NewEmail:  newEmail,
+		Link:      fmt.Sprintf("%s/email/confirm?token=%s", baseURL, confirmToken),
+		ExpiresIn: emailChangeTokenTTL,
+	}).To(newEmail))
+	if err != nil {
+		return err
+	}
+
+	return as.mailer.SendMessage(ctx, mailer.NewMessage(mailer.EmailChangeNoticeEmail{
+		Name:       user.Name,
+		NewEmail:   newEmail,
+		CancelLink: fmt.Sprintf("%s/email/cancel?token=%s", baseURL, cancelToken),
+		ExpiresIn:  emailChangeTokenTTL,
+	}).To(user.Email))
+}
+
+// ConfirmEmailChange switches the user to the new address behind the token. Following
+// the link proves ownership of the new address, so it counts as verified.
+func (as *AuthService) ConfirmEmailChange(ctx context.Context, token string) (*queries.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	tokenHash := sha256.Sum256([]byte(token))
+
+	var user queries.User
+
+	err := as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		row, err := qtx.GetUserByToken(ctx, queries.GetUserByTokenParams{
+			Hash:   tokenHash[:],
+			Scope:  config.ScopeEmailChange,
+			Expiry: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		change, err := qtx.GetEmailChange(ctx, queries.GetEmailChangeParams{
+			UserID: row.User.ID,
+			Expiry: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		// someone may have signed up with the address since the change was requested
+		if err := as.checkEmailAvailable(ctx, qtx, change.NewEmail); err != nil {
+			return err
+		}
+
+		user, err = qtx.UpdateUserEmail(ctx, queries.UpdateUserEmailParams{
+			ID:    row.User.ID,
+			Email: change.NewEmail,
+		})
+		if err != nil {
+			return err
+		}
+
+		return as.deleteEmailChange(ctx, qtx, user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// CancelEmailChange drops the pending change with the token mailed to the current
+// address, which also invalidates the confirmation link.
+func (as *AuthService) CancelEmailChange(ctx context.Context, token string) (*queries.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	user, err := as.getTokenUser(ctx, token, config.ScopeEmailChangeCancel)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, as.deleteEmailChange(ctx, as.dbQueries, user.ID)
+}
+
+// CancelPendingEmailChange drops the pending change of a logged in user.
+func (as *AuthService) CancelPendingEmailChange(ctx context.Context, userID int32) error {
+	return as.deleteEmailChange(ctx, as.dbQueries, userID)
+}
+
+func (as *AuthService) checkEmailAvailable(ctx context.Context, q *queries.Queries, email string) error {
+	_, err := q.GetUserByEmail(ctx, email)
+	if err == nil {
+		return ErrEmailTaken
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	return err
+}
+
+// deleteEmailChange removes the pending change along with the tokens of both links.
+func (as *AuthService) deleteEmailChange(ctx context.Context, q *queries.Queries, userID int32) error {
+	for _, scope := range []string{config.ScopeEmailChange, config.ScopeEmailChangeCancel} {
+		err := q.DeleteAllForUser(ctx, queries.DeleteAllForUserParams{
+			Scope:  scope,
+			UserID: int64(userID),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return q.DeleteEmailChange(ctx, userID)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/queries"
+	"time"
+)
+
+var ErrImpersonationNotAllowed = errors.New("only other users without a role can be impersonated")
+
+// StartImpersonation checks that the admin may log in as the user and records the
+// start in the impersonations audit trail. Users with a role can't be impersonated,
+// their permissions would be handed to the admin.
+func (as *AuthService) StartImpersonation(ctx context.Context, admin *queries.User, userID int32, ipAddress, userAgent string) (queries.User, queries.Impersonation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	var impersonation queries.Impersonation
+
+	if admin.ID == userID {
+		return queries.User{}, impersonation, ErrImpersonationNotAllowed
+	}
+
+	user, err := as.getUser(ctx, userID)
+	if err != nil {
+		return user, impersonation, err
+	}
+
+	if err := as.checkDisabled(ctx, userID); err != nil {
+		return user, impersonation, err
+	}
+
+	roles, err := as.dbQueries.ListRolesForUser(ctx, userID)
+	if err != nil {
+		return user, impersonation, err
+	}
+	if len(roles) > 0 {
+		return user, impersonation, ErrImpersonationNotAllowed
+	}
+
+	err = as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		impersonation, err = qtx.CreateImpersonation(ctx, queries.CreateImpersonationParams{
+			AdminID:   sql.NullInt32{Int32: admin.ID, Valid: true},
+			UserID:    sql.NullInt32{Int32: userID, Valid: true},
+			IpAddress: ipAddress,
+			UserAgent: userAgent,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, qtx, AuditEvent{
+			Action:   config.AuditImpersonationStart,
+			ActorID:  admin.ID,
+			TargetID: userID,
+			Metadata: map[string]any{"impersonation_id": impersonation.ID},
+		})
+	})
+
+	return user, impersonation, err
+}
+
+// StopImpersonation records the end of the impersonation of the user by the admin
+// in the audit trail
+func (as *AuthService) StopImpersonation(ctx context.Context, admin *queries.User, userID, impersonationID int32) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	return as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		if err := qtx.EndImpersonation(ctx, impersonationID); err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, qtx, AuditEvent{
+			Action:   config.AuditImpersonationStop,
+			ActorID:  admin.ID,
+			TargetID: userID,
+			Metadata: map[string]any{"impersonation_id": impersonationID},
+		})
+	})
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/outbox"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/types"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+var (
+	ErrInvitationNotFound = errors.New("invitation not found or expired")
+	ErrInvitationEmail    = errors.New("invitation was sent to another email address")
+	ErrAlreadyMember      = errors.New("email address belongs to a member of the organization")
+	ErrAlreadyInvited     = errors.New("email address has a pending invitation")
+)
+
+// Invite mails an invitation to join the organization of the membership. The
+// email is added to the outbox with the invitation and sent once it is stored.
+func (orgs *OrganizationService) Invite(ctx context.Context, inviter *queries.User, membership types.Membership, email, role, baseURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	email = strings.TrimSpace(email)
+
+	member, err := orgs.dbQueries.IsMemberByEmail(ctx, queries.IsMemberByEmailParams{
+		OrganizationID: membership.OrganizationID,
+		Email:          email,
+	})
+	if err != nil {
+		return err
+	}
+	if member {
+		return ErrAlreadyMember
+	}
+
+	invited, err := orgs.dbQueries.HasPendingInvitation(ctx, queries.HasPendingInvitationParams{
+		OrganizationID: membership.OrganizationID,
+		Email:          email,
+		Now:            time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if invited {
+		return ErrAlreadyInvited
+	}
+
+	return orgs.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := orgs.dbQueries.WithTx(tx)
+
+		plaintext, hash, err := createToken(ctx, qtx, int64(inviter.ID), invitationTTL, config.ScopeInvitation)
+		if err != nil {
+			return err
+		}
+
+		invitation, err := qtx.CreateInvitation(ctx, queries.CreateInvitationParams{
+			OrganizationID: membership.OrganizationID,
+			Email:          email,
+			Role:           role,
+			InvitedBy:      inviter.ID,
+			TokenHash:      hash,
+			ExpiresAt:      time.Now().Add(invitationTTL),
+		})
+		if err != nil {
+			return err
+		}
+
+		return queueInvitation(ctx, qtx, invitation.ID, hash, inviter, membership, email, role, plaintext, baseURL)
+	})
+}
+
+// ResendInvitation mails a pending invitation again with a new link, which also
+// revives expired invitations. The link sent before stops working.
+func (orgs *OrganizationService) ResendInvitation(ctx context.Context, inviter *queries.User, membership types.Membership, id int32, baseURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	return orgs.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := orgs.dbQueries.WithTx(tx)
+
+		invitation, err := getPendingInvitation(ctx, qtx, membership.OrganizationID, id)
+		if err != nil {
+			return err
+		}
+
+		if invitation.TokenHash != nil {
+			if err := qtx.DeleteToken(ctx, invitation.TokenHash); err != nil {
+				return err
+			}
+		}
+
+		plaintext, hash, err := createToken(ctx, qtx, int64(inviter.ID), invitationTTL, config.ScopeInvitation)
+		if err != nil {
+			return err
+		}
+
+		_, err = qtx.RenewInvitation(ctx, queries.RenewInvitationParams{
+			OrganizationID: membership.OrganizationID,
+			ID:             invitation.ID,
+			TokenHash:      hash,
+			ExpiresAt:      time.Now().Add(invitationTTL),
+			InvitedBy:      inviter.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return queueInvitation(ctx, qtx, invitation.ID, hash, inviter, membership, invitation.Email, invitation.Role, plaintext, baseURL)
+	})
+}
+
+// RevokeInvitation withdraws a pending invitation of the organization. It stays
+// in the list of invitations as revoked.
+func (orgs *OrganizationService) RevokeInvitation(ctx context.Context, membership types.Membership, id int32) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	return orgs.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := orgs.dbQueries.WithTx(tx)
+
+		invitation, err := getPendingInvitation(ctx, qtx, membership.OrganizationID, id)
+		if err != nil {
+			return err
+		}
+
+		return respondToInvitation(ctx, qtx, invitation.ID, invitation.TokenHash, config.InvitationRevoked)
+	})
+}
+
+// GetInvitation returns the pending invitation of the emailed token
+func (orgs *OrganizationService) GetInvitation(ctx context.Context, token string) (*queries.GetInvitationByTokenRow, error) {
+	invitation, err := getInvitationByToken(ctx, orgs.dbQueries, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// HasAccount reports whether someone signed up with the email address already, so
+// invitees are asked to log in rather than to create an account.
+func (orgs *OrganizationService) HasAccount(ctx context.Context, email string) (bool, error) {
+	_, err := orgs.dbQueries.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// AcceptInvitation adds the user to the organization of the invitation. Only the
+// user with the invited email address can accept it.
+func (orgs *OrganizationService) AcceptInvitation(ctx context.Context, token string, user *queries.User) (types.Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	var membership types.Membership
+
+	err := orgs.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := orgs.dbQueries.WithTx(tx)
+
+		invitation, err := getInvitationByToken(ctx, qtx, token)
+		if err != nil {
+			return err
+		}
+
+		membership, err = acceptInvitation(ctx, qtx, invitation, *user)
+		return err
+	})
+
+	return membership, err
+}
+
+// DeclineInvitation turns the invitation down. Anyone with the link can decline,
+// the inviter sees the invitation as declined.
+func (orgs *OrganizationService) DeclineInvitation(ctx context.Context, token string) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	return orgs.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := orgs.dbQueries.WithTx(tx)
+
+		invitation, err := getInvitationByToken(ctx, qtx, token)
+		if err != nil {
+			return err
+		}
+
+		return respondToInvitation(ctx, qtx, invitation.ID, invitation.TokenHash, config.InvitationDeclined)
+	})
+}
+
+// queueInvitation adds the invitation email with the token to the outbox. Every
+// token is a new email, so resending an invitation isn't taken for a duplicate.
+func queueInvitation(ctx context.Context, qtx *queries.Queries, id int32, tokenHash []byte, inviter *queries.User, membership types.Membership, email, role, token, baseURL string) error {
+	msg := mailer.NewMessage(mailer.InvitationEmail{
+		InviterName:      inviter.Name,
+		OrganizationName: membership.Name,
+		Role:             role,
+		Link:             fmt.Sprintf("%s/invitations/accept?token=%s", baseURL, url.QueryEscape(token)),
+		ExpiresIn:        invitationTTL,
+	}).To(email).ReplyTo(inviter.Email) // replies go to whoever invited them
+
+	_, err := outbox.Add(ctx, qtx, fmt.Sprintf("invitation:%d:%x", id, tokenHash[:8]), msg)
+	return err
+}
+
+func getInvitationByToken(ctx context.Context, q *queries.Queries, token string) (queries.GetInvitationByTokenRow, error) {
+	tokenHash := sha256.Sum256([]byte(token))
+
+	invitation, err := q.GetInvitationByToken(ctx, queries.GetInvitationByTokenParams{
+		TokenHash: tokenHash[:],
+		ExpiresAt: time.Now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return invitation, ErrInvitationNotFound
+	}
+
+	return invitation, err
+}
+
+// getPendingInvitation returns a pending invitation of the organization, expired
+// or not
+func getPendingInvitation(ctx context.Context, q *queries.Queries, organizationID, id int32) (queries.Invitation, error) {
+	invitation, err := q.GetInvitation(ctx, queries.GetInvitationParams{
+		OrganizationID: organizationID,
+		ID:             id,
+	})
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && invitation.Status != config.InvitationPending) {
+		return invitation, ErrInvitationNotFound
+	}
+
+	return invitation, err
+}
+
+// acceptInvitation makes the user a member with the role of the invitation. Users
+// that are members already keep their role.
+func acceptInvitation(ctx context.Context, qtx *queries.Queries, invitation queries.GetInvitationByTokenRow, user queries.User) (types.Membership, error) {
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		return types.Membership{}, ErrInvitationEmail
+	}
+
+	err := qtx.CreateMembership(ctx, queries.CreateMembershipParams{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         user.ID,
+		Role:           invitation.Role,
+	})
+	if err != nil {
+		return types.Membership{}, err
+	}
+
+	err = respondToInvitation(ctx, qtx, invitation.ID, invitation.TokenHash, config.InvitationAccepted)
+	if err != nil {
+		return types.Membership{}, err
+	}
+
+	return types.Membership{
+		OrganizationID: invitation.OrganizationID,
+		Name:           invitation.OrganizationName,
+		Role:           invitation.Role,
+	}, nil
+}
+
+// respondToInvitation closes a pending invitation and deletes its token
+func respondToInvitation(ctx context.Context, qtx *queries.Queries, id int32, tokenHash []byte, status string) error {
+	updated, err := qtx.SetInvitationStatus(ctx, queries.SetInvitationStatusParams{
+		ID:     id,
+		Status: status,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrInvitationNotFound
+	}
+
+	if tokenHash == nil {
+		return nil
+	}
+
+	return qtx.DeleteToken(ctx, tokenHash)
+}
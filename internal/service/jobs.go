@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-web-starter/internal/jobs"
+)
+
+// ActivationEmailJob sends a user the link to verify their email address. The
+// token is created when the job runs, so that no plaintext token is stored.
+type ActivationEmailJob struct {
+	UserID  int32  `json:"user_id"`
+	BaseURL string `json:"base_url"`
+}
+
+func (ActivationEmailJob) Kind() string {
+	return "email.activation"
+}
+
+// RegisterJobs registers the handlers of the jobs of the service with the worker
+func (as *AuthService) RegisterJobs(w *jobs.Worker) {
+	jobs.Handle(w, as.sendQueuedActivationEmail)
+}
+
+func (as *AuthService) sendQueuedActivationEmail(ctx context.Context, job ActivationEmailJob) error {
+	user, err := as.dbQueries.GetUserById(ctx, job.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = as.SendActivationEmail(ctx, &user, job.BaseURL)
+	// the user followed a link sent before, e.g. with a resend
+	if errors.Is(err, ErrEmailAlreadyVerified) {
+		return nil
+	}
+
+	return err
+}
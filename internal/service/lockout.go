@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/queries"
+	"log"
+	"strings"
+	"time"
+)
+
+var ErrAccountLocked = errors.New("account is temporarily locked")
+
+// loginAttemptKey normalizes an email address so failed logins are counted per
+// address regardless of how it was typed.
+func loginAttemptKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// twoFactorAttemptKey counts the failed two-factor codes of an account apart from
+// its failed logins, so that the correct password of the first step doesn't reset
+// them.
+func twoFactorAttemptKey(email string) string {
+	return "2fa:" + loginAttemptKey(email)
+}
+
+// checkLockout returns ErrAccountLocked while the email address is locked.
+func (as *AuthService) checkLockout(ctx context.Context, email string) error {
+	attempt, err := as.dbQueries.GetLoginAttempt(ctx, loginAttemptKey(email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if attempt.LockedUntil.Valid && attempt.LockedUntil.Time.After(time.Now()) {
+		return ErrAccountLocked
+	}
+
+	return nil
+}
+
+// recordFailedLogin counts a failed login for the email address and locks it once
+// the threshold is reached. user is nil when no account exists for the address, it
+// is locked all the same but there is nobody to notify.
+func (as *AuthService) recordFailedLogin(ctx context.Context, email string, user *queries.User, baseURL string) error {
+	attempt, err := as.dbQueries.RecordFailedLogin(ctx, queries.RecordFailedLoginParams{
+		Email:       loginAttemptKey(email),
+		ResetBefore: time.Now().Add(-as.config.LockoutMaxDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	if int(attempt.FailedCount) < as.config.LockoutThreshold {
+		return nil
+	}
+
+	lockedFor := as.lockoutDuration(int(attempt.FailedCount))
+
+	err = as.dbQueries.LockLogin(ctx, queries.LockLoginParams{
+		Email:       attempt.Email,
+		LockedUntil: sql.NullTime{Time: time.Now().Add(lockedFor), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	// only the first lockout is mailed, later ones would flood the inbox during an attack
+	if user != nil && int(attempt.FailedCount) == as.config.LockoutThreshold {
+		if err := as.sendLockoutEmail(ctx, user, lockedFor, baseURL); err != nil {
+			log.Println("sending lockout email:", err)
+		}
+	}
+
+	return nil
+}
+
+// lockoutDuration doubles the lockout for every failed login past the threshold.
+func (as *AuthService) lockoutDuration(failedCount int) time.Duration {
+	duration := as.config.LockoutDuration
+	for i := as.config.LockoutThreshold; i < failedCount && duration < as.config.LockoutMaxDuration; i++ {
+		duration *= 2
+	}
+
+	return min(duration, as.config.LockoutMaxDuration)
+}
+
+// sendLockoutEmail tells the user about the lockout. The password reset link in
+// the email also unlocks the account, see ResetPassword.
+func (as *AuthService) sendLockoutEmail(ctx context.Context, user *queries.User, lockedFor time.Duration, baseURL string) error {
+	passwordResetLink, err := as.GetPasswordResetLink(ctx, user.Email, baseURL)
+	if err != nil {
+		return err
+	}
+
+	return as.mailer.SendMessage(ctx, mailer.NewMessage(mailer.AccountLockedEmail{
+		Name:              user.Name,
+		LockedFor:         lockedFor,
+		PasswordResetLink: passwordResetLink,
+	}).To(user.Email))
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"go-web-starter/internal/config"
+	"testing"
+	"time"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	as := &AuthService{config: config.Auth{
+		LockoutThreshold:   5,
+		LockoutDuration:    time.Minute,
+		LockoutMaxDuration: time.Hour,
+	}}
+
+	tests := []struct {
+		failedCount int
+		want        time.Duration
+	}{
+		{failedCount: 5, want: time.Minute},
+		{failedCount: 6, want: 2 * time.Minute},
+		{failedCount: 7, want: 4 * time.Minute},
+		{failedCount: 10, want: 32 * time.Minute},
+		{failedCount: 11, want: time.Hour},
+		{failedCount: 1000, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := as.lockoutDuration(tt.failedCount); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %v; want %v", tt.failedCount, got, tt.want)
+		}
+	}
+}
+
+func TestLoginAttemptKey(t *testing.T) {
+	if got := loginAttemptKey("  Jane.Doe@Example.com "); got != "jane.doe@example.com" {
+		t.Errorf("loginAttemptKey() = %q; want %q", got, "jane.doe@example.com")
+	}
+}
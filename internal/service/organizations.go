@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/database"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/types"
+	"time"
+)
+
+var ErrNotAMember = errors.New("not a member of the organization")
+
+// OrganizationService manages the organizations, the tenants of the app, and the
+// memberships of users in them. Tenant data is reached through a Tenant.
+type OrganizationService struct {
+	dbQueries *queries.Queries
+	dbService database.Service
+}
+
+func NewOrganizationService(dbQueries *queries.Queries, db database.Service) *OrganizationService {
+	return &OrganizationService{
+		dbQueries: dbQueries,
+		dbService: db,
+	}
+}
+
+// ListMemberships returns the organizations of the user, the personal one first
+func (orgs *OrganizationService) ListMemberships(ctx context.Context, userID int32) ([]types.Membership, error) {
+	rows, err := orgs.dbQueries.ListMembershipsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships := make([]types.Membership, 0, len(rows))
+	for _, row := range rows {
+		memberships = append(memberships, types.Membership{
+			OrganizationID: row.ID,
+			Name:           row.Name,
+			Personal:       row.Personal,
+			Role:           row.Role,
+		})
+	}
+
+	return memberships, nil
+}
+
+// CreateOrganization creates an organization owned by the user
+func (orgs *OrganizationService) CreateOrganization(ctx context.Context, userID int32, name string) (types.Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	var membership types.Membership
+
+	err := orgs.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		var err error
+		membership, err = createOrganization(ctx, orgs.dbQueries.WithTx(tx), userID, name, false)
+		return err
+	})
+
+	return membership, err
+}
+
+// Tenant returns the data of the organization of the membership
+func (orgs *OrganizationService) Tenant(membership types.Membership) *Tenant {
+	return &Tenant{
+		Membership: membership,
+		dbQueries:  orgs.dbQueries,
+	}
+}
+
+// createPersonalOrganization gives a new user an organization of their own, so they
+// can start working before being invited anywhere
+func createPersonalOrganization(ctx context.Context, qtx *queries.Queries, user queries.User) error {
+	_, err := createOrganization(ctx, qtx, user.ID, user.Name, true)
+	return err
+}
+
+func createOrganization(ctx context.Context, qtx *queries.Queries, userID int32, name string, personal bool) (types.Membership, error) {
+	org, err := qtx.CreateOrganization(ctx, queries.CreateOrganizationParams{
+		Name:     name,
+		Personal: personal,
+	})
+	if err != nil {
+		return types.Membership{}, err
+	}
+
+	err = qtx.CreateMembership(ctx, queries.CreateMembershipParams{
+		OrganizationID: org.ID,
+		UserID:         userID,
+		Role:           config.OrgRoleOwner,
+	})
+	if err != nil {
+		return types.Membership{}, err
+	}
+
+	return types.Membership{
+		OrganizationID: org.ID,
+		Name:           org.Name,
+		Personal:       org.Personal,
+		Role:           config.OrgRoleOwner,
+	}, nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-web-starter/internal/queries"
+	"time"
+)
+
+var ErrOutboxMessageNotFound = errors.New("outbox message not found")
+
+// outboxListLimit is the number of messages listed by ListOutboxMessages
+const outboxListLimit = 100
+
+// ListOutboxMessages returns the latest emails of the outbox, newest first, of
+// the status unless it is empty
+func (as *AuthService) ListOutboxMessages(ctx context.Context, status string) ([]queries.OutboxMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	return as.dbQueries.ListOutboxMessages(ctx, queries.ListOutboxMessagesParams{
+		Status: status,
+		Limit:  outboxListLimit,
+	})
+}
+
+// GetOutboxMessage returns the email of the outbox with its delivery attempts
+func (as *AuthService) GetOutboxMessage(ctx context.Context, id int64) (queries.OutboxMessage, []queries.OutboxAttempt, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	message, err := as.dbQueries.GetOutboxMessage(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return message, nil, ErrOutboxMessageNotFound
+		}
+		return message, nil, err
+	}
+
+	attempts, err := as.dbQueries.ListOutboxAttempts(ctx, id)
+	return message, attempts, err
+}
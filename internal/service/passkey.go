@@ -0,0 +1,317 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/queries"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const maxPasskeyNameLength = 64
+
+var (
+	ErrPasskeysNotConfigured = errors.New("passkeys are not configured")
+	ErrPasskeyNotFound       = errors.New("passkey not found")
+)
+
+// newWebAuthn builds the relying party used for passkey ceremonies. Passkeys are
+// disabled when the config is invalid instead of failing the whole service.
+// Passkeys are registered with user verification, a PIN or biometric, and logins
+// require it as well, as a passkey login stands in for the second factor.
+func newWebAuthn(cfg config.Auth) *webauthn.WebAuthn {
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthnRPID,
+		RPDisplayName: cfg.WebAuthnRPDisplayName,
+		RPOrigins:     cfg.WebAuthnOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			UserVerification: protocol.VerificationRequired,
+		},
+	})
+	if err != nil {
+		log.Println("passkeys disabled:", err)
+		return nil
+	}
+
+	return wa
+}
+
+// passkeyUser adapts a user and their stored credentials to webauthn.User
+type passkeyUser struct {
+	user        queries.User
+	credentials []webauthn.Credential
+}
+
+// WebAuthnID is the user handle stored on the authenticator. It is used to find the
+// user again when logging in with a discoverable credential.
+func (u *passkeyUser) WebAuthnID() []byte {
+	return []byte(strconv.Itoa(int(u.user.ID)))
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	return u.user.Name
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+func (as *AuthService) loadPasskeyUser(ctx context.Context, user queries.User) (*passkeyUser, error) {
+	rows, err := as.dbQueries.ListWebAuthnCredentialsForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([]webauthn.Credential, 0, len(rows))
+	for _, row := range rows {
+		credentials = append(credentials, credentialFromRow(row))
+	}
+
+	return &passkeyUser{user: user, credentials: credentials}, nil
+}
+
+func (as *AuthService) ListPasskeys(ctx context.Context, userID int32) ([]queries.WebauthnCredential, error) {
+	return as.dbQueries.ListWebAuthnCredentialsForUser(ctx, userID)
+}
+
+// BeginPasskeyRegistration returns the options for navigator.credentials.create() and
+// the ceremony state that has to be passed to FinishPasskeyRegistration.
+func (as *AuthService) BeginPasskeyRegistration(ctx context.Context, user *queries.User) (*protocol.CredentialCreation, []byte, error) {
+	if as.webAuthn == nil {
+		return nil, nil, ErrPasskeysNotConfigured
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	pu, err := as.loadPasskeyUser(ctx, *user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	creation, session, err := as.webAuthn.BeginRegistration(pu,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithExclusions(webauthn.Credentials(pu.credentials).CredentialDescriptors()),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state, err := json.Marshal(session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return creation, state, nil
+}
+
+// FinishPasskeyRegistration verifies the authenticator response read from body and
+// stores the new credential under the given name.
+func (as *AuthService) FinishPasskeyRegistration(ctx context.Context, user *queries.User, state []byte, name string, body io.Reader) (queries.WebauthnCredential, error) {
+	if as.webAuthn == nil {
+		return queries.WebauthnCredential{}, ErrPasskeysNotConfigured
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal(state, &session); err != nil {
+		return queries.WebauthnCredential{}, fmt.Errorf("invalid passkey registration state: %w", err)
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(body)
+	if err != nil {
+		return queries.WebauthnCredential{}, err
+	}
+
+	pu, err := as.loadPasskeyUser(ctx, *user)
+	if err != nil {
+		return queries.WebauthnCredential{}, err
+	}
+
+	credential, err := as.webAuthn.CreateCredential(pu, session, parsed)
+	if err != nil {
+		return queries.WebauthnCredential{}, err
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	return as.dbQueries.CreateWebAuthnCredential(ctx, queries.CreateWebAuthnCredentialParams{
+		UserID:          user.ID,
+		Name:            normalizePasskeyName(name),
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		Aaguid:          credential.Authenticator.AAGUID,
+		Flags:           int16(credential.Flags.ProtocolValue()),
+		SignCount:       int64(credential.Authenticator.SignCount),
+	})
+}
+
+// BeginPasskeyLogin returns the options for navigator.credentials.get(). No user is
+// known yet, the authenticator picks one of its discoverable credentials.
+func (as *AuthService) BeginPasskeyLogin() (*protocol.CredentialAssertion, []byte, error) {
+	if as.webAuthn == nil {
+		return nil, nil, ErrPasskeysNotConfigured
+	}
+
+	assertion, session, err := as.webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state, err := json.Marshal(session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return assertion, state, nil
+}
+
+// FinishPasskeyLogin verifies the assertion read from body and returns the user the
+// credential belongs to.
+func (as *AuthService) FinishPasskeyLogin(ctx context.Context, state []byte, body io.Reader) (*queries.User, error) {
+	if as.webAuthn == nil {
+		return nil, ErrPasskeysNotConfigured
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal(state, &session); err != nil {
+		return nil, fmt.Errorf("invalid passkey login state: %w", err)
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var pu *passkeyUser
+	findUser := func(rawID, userHandle []byte) (webauthn.User, error) {
+		row, err := as.dbQueries.GetWebAuthnCredentialByCredentialID(ctx, rawID)
+		if err != nil {
+			return nil, err
+		}
+
+		// the user handle on the authenticator has to match the owner of the credential
+		if !bytes.Equal(userHandle, []byte(strconv.Itoa(int(row.UserID)))) {
+			return nil, errors.New("user handle does not match the credential owner")
+		}
+
+		user, err := as.dbQueries.GetUserById(ctx, row.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		pu, err = as.loadPasskeyUser(ctx, user)
+		return pu, err
+	}
+
+	credential, err := as.webAuthn.ValidateDiscoverableLogin(findUser, session, parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	if credential.Authenticator.CloneWarning {
+		return nil, errors.New("passkey signature counter went backwards, the authenticator may be cloned")
+	}
+
+	err = as.dbQueries.UpdateWebAuthnCredentialUsage(ctx, queries.UpdateWebAuthnCredentialUsageParams{
+		CredentialID: credential.ID,
+		SignCount:    int64(credential.Authenticator.SignCount),
+		Flags:        int16(credential.Flags.ProtocolValue()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pu.user, nil
+}
+
+func (as *AuthService) RenamePasskey(ctx context.Context, userID, id int32, name string) error {
+	renamed, err := as.dbQueries.RenameWebAuthnCredential(ctx, queries.RenameWebAuthnCredentialParams{
+		ID:     id,
+		UserID: userID,
+		Name:   normalizePasskeyName(name),
+	})
+	if err != nil {
+		return err
+	}
+	if renamed == 0 {
+		return ErrPasskeyNotFound
+	}
+
+	return nil
+}
+
+func (as *AuthService) DeletePasskey(ctx context.Context, userID, id int32) error {
+	deleted, err := as.dbQueries.DeleteWebAuthnCredential(ctx, queries.DeleteWebAuthnCredentialParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrPasskeyNotFound
+	}
+
+	return nil
+}
+
+func credentialFromRow(row queries.WebauthnCredential) webauthn.Credential {
+	var transports []protocol.AuthenticatorTransport
+	for transport := range strings.SplitSeq(row.Transports, ",") {
+		if transport != "" {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+	}
+
+	return webauthn.Credential{
+		ID:              row.CredentialID,
+		PublicKey:       row.PublicKey,
+		AttestationType: row.AttestationType,
+		Transport:       transports,
+		Flags:           webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(row.Flags)),
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    row.Aaguid,
+			SignCount: uint32(row.SignCount),
+		},
+	}
+}
+
+func normalizePasskeyName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "Passkey"
+	}
+
+	if runes := []rune(name); len(runes) > maxPasskeyNameLength {
+		name = string(runes[:maxPasskeyNameLength])
+	}
+
+	return name
+}
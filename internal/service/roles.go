@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/queries"
+	"time"
+)
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// ListPermissions returns the names of the permissions the roles of the user grant
+func (as *AuthService) ListPermissions(ctx context.Context, userID int32) ([]string, error) {
+	return as.dbQueries.ListPermissionsForUser(ctx, userID)
+}
+
+func (as *AuthService) ListRoles(ctx context.Context, userID int32) ([]queries.Role, error) {
+	return as.dbQueries.ListRolesForUser(ctx, userID)
+}
+
+// AssignRole gives the role to the user. Assigning a role the user has is a no-op.
+func (as *AuthService) AssignRole(ctx context.Context, userID int32, roleName string) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	role, err := as.getRole(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	return as.dbQueries.AssignRole(ctx, queries.AssignRoleParams{
+		UserID: userID,
+		RoleID: role.ID,
+	})
+}
+
+// RemoveRole takes the role away from the user
+func (as *AuthService) RemoveRole(ctx context.Context, userID int32, roleName string) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	role, err := as.getRole(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	_, err = as.dbQueries.RemoveRole(ctx, queries.RemoveRoleParams{
+		UserID: userID,
+		RoleID: role.ID,
+	})
+
+	return err
+}
+
+// PromoteToAdmin gives the admin role to the user with the email address
+func (as *AuthService) PromoteToAdmin(ctx context.Context, email string) (*queries.User, error) {
+	user, err := as.dbQueries.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if err := as.AssignRole(ctx, user.ID, config.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (as *AuthService) getRole(ctx context.Context, name string) (queries.Role, error) {
+	role, err := as.dbQueries.GetRoleByName(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return role, ErrRoleNotFound
+	}
+
+	return role, err
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/types"
+	"strings"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// RecordSession adds a freshly logged in session to the session index of the user.
+// Sessions missing from the index are treated as logged out by the authenticate
+// middleware. It fails with ErrUserDisabled for disabled users. The login is written
+// to the audit log along with method, e.g. password or the social provider.
+func (as *AuthService) RecordSession(ctx context.Context, userID int32, token, userAgent, ipAddress, method string, lifetime time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	// every way to log in ends here, so disabled users are turned away here too
+	if err := as.checkDisabled(ctx, userID); err != nil {
+		return err
+	}
+
+	// expired sessions are cleaned up whenever the user logs in again
+	if err := as.dbQueries.DeleteExpiredUserSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	_, err := as.dbQueries.CreateUserSession(ctx, queries.CreateUserSessionParams{
+		Token:     token,
+		UserID:    userID,
+		UserAgent: userAgent,
+		IpAddress: ipAddress,
+		ExpiresAt: time.Now().Add(lifetime),
+	})
+	if err != nil {
+		return err
+	}
+
+	return as.RecordAuditEvent(ctx, AuditEvent{
+		Action:   config.AuditLoginSucceeded,
+		ActorID:  userID,
+		TargetID: userID,
+		Metadata: map[string]any{"method": method},
+	})
+}
+
+func (as *AuthService) ListSessions(ctx context.Context, userID int32, currentToken string) ([]types.ActiveSession, error) {
+	rows, err := as.dbQueries.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]types.ActiveSession, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, types.ActiveSession{
+			ID:         row.ID,
+			Device:     describeUserAgent(row.UserAgent),
+			IPAddress:  row.IpAddress,
+			CreatedAt:  row.CreatedAt,
+			LastSeenAt: row.LastSeenAt,
+			Current:    row.Token == currentToken,
+		})
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession signs out one session of the user.
+func (as *AuthService) RevokeSession(ctx context.Context, userID, id int32) error {
+	deleted, err := as.dbQueries.DeleteUserSession(ctx, queries.DeleteUserSessionParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrSessionNotFound
+	}
+
+	return as.RecordAuditEvent(ctx, AuditEvent{
+		Action:   config.AuditSessionRevoked,
+		ActorID:  userID,
+		TargetID: userID,
+		Metadata: map[string]any{"session_id": id},
+	})
+}
+
+// RevokeOtherSessions signs out every session of the user except the current one.
+func (as *AuthService) RevokeOtherSessions(ctx context.Context, userID int32, currentToken string) error {
+	err := as.dbQueries.DeleteOtherUserSessions(ctx, queries.DeleteOtherUserSessionsParams{
+		UserID: userID,
+		Token:  currentToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	return as.RecordAuditEvent(ctx, AuditEvent{
+		Action:   config.AuditSessionsRevoked,
+		ActorID:  userID,
+		TargetID: userID,
+	})
+}
+
+// EndSession removes a session from the index, e.g. on logout.
+func (as *AuthService) EndSession(ctx context.Context, token string) error {
+	return as.dbQueries.DeleteUserSessionByToken(ctx, token)
+}
+
+// describeUserAgent turns a User-Agent header into a short description like
+// "Chrome on macOS". It only knows the common browsers and operating systems.
+func describeUserAgent(userAgent string) string {
+	browser := ""
+	switch {
+	case strings.Contains(userAgent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(userAgent, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(userAgent, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Chrome/"), strings.Contains(userAgent, "CriOS/"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Safari/"):
+		browser = "Safari"
+	}
+
+	os := ""
+	switch {
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"):
+		os = "iOS"
+	case strings.Contains(userAgent, "Android"):
+		os = "Android"
+	case strings.Contains(userAgent, "Windows"):
+		os = "Windows"
+	case strings.Contains(userAgent, "Mac OS X"):
+		os = "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		os = "Linux"
+	}
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	case userAgent != "":
+		return userAgent
+	default:
+		return "Unknown device"
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import "testing"
+
+func TestDescribeUserAgent(t *testing.T) {
+	testCases := []struct {
+		userAgent string
+		want      string
+	}{
+		{
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			want:      "Chrome on macOS",
+		},
+		{
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0",
+			want:      "Edge on Windows",
+		},
+		{
+			userAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:127.0) Gecko/20100101 Firefox/127.0",
+			want:      "Firefox on Linux",
+		},
+		{
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+			want:      "Safari on iOS",
+		},
+		{
+			userAgent: "Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36",
+			want:      "Chrome on Android",
+		},
+		{
+			userAgent: "curl/8.7.1",
+			want:      "curl/8.7.1",
+		},
+		{
+			userAgent: "",
+			want:      "Unknown device",
+		},
+	}
+
+	for _, tc := range testCases {
+		if got := describeUserAgent(tc.userAgent); got != tc.want {
+			t.Errorf("describeUserAgent(%q) = %q; want %q", tc.userAgent, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/types"
+	"net/url"
+	"time"
+
+	"github.com/markbates/goth"
+)
+
+const accountLinkTokenTTL = 24 * time.Hour
+
+var (
+	ErrAccountLinkPending       = errors.New("account link waits for email confirmation")
+	ErrSocialAccountInUse       = errors.New("social account is connected to another user")
+	ErrProviderAlreadyConnected = errors.New("provider is already connected")
+	ErrSocialAccountNotFound    = errors.New("social account not found")
+	ErrLastLoginMethod          = errors.New("cannot remove the last way to log in")
+)
+
+// ListConnectedAccounts returns the social accounts of the user, without the
+// email and password account.
+func (as *AuthService) ListConnectedAccounts(ctx context.Context, userID int32) ([]queries.Account, error) {
+	accounts, err := as.dbQueries.ListAccountsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	social := make([]queries.Account, 0, len(accounts))
+	for _, account := range accounts {
+		if account.ProviderID.Valid {
+			social = append(social, account)
+		}
+	}
+
+	return social, nil
+}
+
+// ConnectSocialAccount connects a provider account to a logged in user. Logging in
+// with the provider proves ownership, so the emails don't have to match.
+func (as *AuthService) ConnectSocialAccount(ctx context.Context, userID int32, gothUser goth.User, provider string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		if err := as.linkAccount(ctx, qtx, userID, provider, gothUser.UserID); err != nil {
+			return err
+		}
+
+		return as.updateOAuthTokens(ctx, qtx, userID, gothUser, provider)
+	})
+}
+
+// ConfirmAccountLink connects the social account waiting behind the emailed token
+// and returns the user it was connected to. Following the link proves ownership of
+// the address, so the email is marked verified.
+func (as *AuthService) ConfirmAccountLink(ctx context.Context, token string) (*queries.User, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	tokenHash := sha256.Sum256([]byte(token))
+
+	var user queries.User
+	var provider string
+
+	err := as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		link, err := qtx.GetPendingAccountLink(ctx, queries.GetPendingAccountLinkParams{
+			TokenHash: tokenHash[:],
+			Scope:     config.ScopeAccountLink,
+			Expiry:    time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		provider = link.ProviderID
+
+		// the pending link is deleted along with the token
+		if err := qtx.DeleteToken(ctx, tokenHash[:]); err != nil {
+			return err
+		}
+
+		if err := as.linkAccount(ctx, qtx, int32(link.UserID), link.ProviderID, link.AccountID); err != nil {
+			return err
+		}
+
+		user, err = qtx.GetUserById(ctx, int32(link.UserID))
+		if err != nil {
+			return err
+		}
+
+		if !user.EmailVerified {
+			user, err = qtx.VerifyUserEmail(ctx, user.ID)
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &user, provider, nil
+}
+
+// DisconnectSocialAccount removes a provider account from the user, unless it is the
+// only way left for them to log in.
+func (as *AuthService) DisconnectSocialAccount(ctx context.Context, userID int32, provider string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		deleted, err := qtx.DeleteAccountByUserIdAndProvider(ctx, queries.DeleteAccountByUserIdAndProviderParams{
+			UserID:     userID,
+			ProviderID: sql.NullString{String: provider, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return ErrSocialAccountNotFound
+		}
+
+		// counted after the delete, inside the transaction, so two concurrent
+		// disconnects can't remove the last two methods
+		methods, err := as.countLoginMethods(ctx, qtx, userID)
+		if err != nil {
+			return err
+		}
+		if methods == 0 {
+			return ErrLastLoginMethod
+		}
+
+		return recordAuditEvent(ctx, qtx, AuditEvent{
+			Action:   config.AuditSocialUnlinked,
+			ActorID:  userID,
+			TargetID: userID,
+			Metadata: map[string]any{"provider": provider},
+		})
+	})
+}
+
+// countLoginMethods counts the password, social accounts and passkeys of the user.
+func (as *AuthService) countLoginMethods(ctx context.Context, qtx *queries.Queries, userID int32) (int64, error) {
+	accounts, err := qtx.ListAccountsForUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var methods int64
+	for _, account := range accounts {
+		if account.ProviderID.Valid || account.Password.Valid {
+			methods++
+		}
+	}
+
+	passkeys, err := qtx.CountWebAuthnCredentialsForUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return methods + passkeys, nil
+}
+
+func (as *AuthService) linkAccount(ctx context.Context, qtx *queries.Queries, userID int32, provider, accountID string) error {
+	existing, err := qtx.GetAccountByProvider(ctx, queries.GetAccountByProviderParams{
+		ProviderID: sql.NullString{String: provider, Valid: true},
+		AccountID:  accountID,
+	})
+	if err == nil {
+		if existing.UserID == userID {
+			return ErrProviderAlreadyConnected
+		}
+		return ErrSocialAccountInUse
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	_, err = qtx.GetAccountByUserIdAndProvider(ctx, queries.GetAccountByUserIdAndProviderParams{
+		UserID:     userID,
+		ProviderID: sql.NullString{String: provider, Valid: true},
+	})
+	if err == nil {
+		// another account of the same provider is connected already
+		return ErrProviderAlreadyConnected
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	_, err = qtx.CreateAccount(ctx, queries.CreateAccountParams{
+		UserID:     userID,
+		AccountID:  accountID,
+		ProviderID: sql.NullString{String: provider, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	return recordAuditEvent(ctx, qtx, AuditEvent{
+		Action:   config.AuditSocialLinked,
+		ActorID:  userID,
+		TargetID: userID,
+		Metadata: map[string]any{"provider": provider},
+	})
+}
+
+// sendAccountLinkEmail asks the owner of the email address to confirm connecting the
+// provider account they (or someone else) just logged in with.
+func (as *AuthService) sendAccountLinkEmail(ctx context.Context, user *queries.User, gothUser goth.User, provider types.SocialProvider, baseURL string) error {
+	plaintext, err := as.GenerateToken(ctx, int64(user.ID), accountLinkTokenTTL, config.ScopeAccountLink)
+	if err != nil {
+		return err
+	}
+
+	tokenHash := sha256.Sum256([]byte(plaintext))
+
+	err = as.dbQueries.CreatePendingAccountLink(ctx, queries.CreatePendingAccountLinkParams{
+		TokenHash:  tokenHash[:],
+		ProviderID: provider.Name,
+		AccountID:  gothUser.UserID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return as.mailer.SendMessage(ctx, mailer.NewMessage(mailer.AccountLinkEmail{
+		Name:      user.Name,
+		Provider:  provider.DisplayName,
+		Link:      fmt.Sprintf("%s/connections/confirm?%s", baseURL, url.Values{"token": {plaintext}}.Encode()),
+		ExpiresIn: accountLinkTokenTTL,
+	}).To(user.Email))
+}
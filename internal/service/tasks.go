@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/scheduler"
+	"time"
+)
+
+// finishedJobsRetention is how long done jobs are kept, dead jobs are kept until
+// they are looked into
+const finishedJobsRetention = 7 * 24 * time.Hour
+
+// sentEmailsRetention is how long sent emails are kept in the outbox with their
+// attempts, dead ones are kept until they are looked into
+const sentEmailsRetention = 30 * 24 * time.Hour
+
+// purgeBatchSize is the number of unverified accounts read at a time by purgeUnverifiedAccounts
+const purgeBatchSize = 100
+
+// RegisterTasks registers the recurring chores of the service with the scheduler
+func (as *AuthService) RegisterTasks(s *scheduler.Scheduler) error {
+	err := errors.Join(
+		s.Register("expired-sessions", "*/15 * * * *", as.deleteExpiredSessions),
+		s.Register("expired-tokens", "@hourly", as.deleteExpiredTokens),
+		s.Register("finished-jobs", "30 3 * * *", as.deleteFinishedJobs),
+		s.Register("sent-emails", "45 3 * * *", as.deleteSentEmails),
+	)
+
+	if as.config.UnverifiedAccountTTL > 0 {
+		err = errors.Join(err, s.Register("unverified-accounts", "0 4 * * *", as.purgeUnverifiedAccounts))
+	}
+
+	return err
+}
+
+// deleteExpiredSessions removes the expired login sessions and the records of them
+func (as *AuthService) deleteExpiredSessions(ctx context.Context) error {
+	if _, err := as.dbQueries.DeleteExpiredSessions(ctx); err != nil {
+		return err
+	}
+
+	_, err := as.dbQueries.DeleteAllExpiredUserSessions(ctx)
+	return err
+}
+
+func (as *AuthService) deleteExpiredTokens(ctx context.Context) error {
+	_, err := as.dbQueries.DeleteExpiredTokens(ctx)
+	return err
+}
+
+func (as *AuthService) deleteFinishedJobs(ctx context.Context) error {
+	_, err := as.dbQueries.DeleteFinishedJobs(ctx, time.Now().Add(-finishedJobsRetention))
+	return err
+}
+
+func (as *AuthService) deleteSentEmails(ctx context.Context) error {
+	_, err := as.dbQueries.DeleteSentOutboxMessages(ctx, time.Now().Add(-sentEmailsRetention))
+	return err
+}
+
+// purgeUnverifiedAccounts deletes the accounts whose email address is still
+// unverified after config.UnverifiedAccountTTL
+func (as *AuthService) purgeUnverifiedAccounts(ctx context.Context) error {
+	createdBefore := time.Now().Add(-as.config.UnverifiedAccountTTL)
+
+	for {
+		users, err := as.dbQueries.ListUnverifiedUsersCreatedBefore(ctx, queries.ListUnverifiedUsersCreatedBeforeParams{
+			CreatedAt: createdBefore,
+			Limit:     purgeBatchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			err := as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+				qtx := as.dbQueries.WithTx(tx)
+
+				if err := deleteUser(ctx, qtx, user.ID); err != nil {
+					return err
+				}
+
+				return recordAuditEvent(ctx, qtx, AuditEvent{
+					Action:   config.AuditUnverifiedPurged,
+					TargetID: user.ID,
+					Metadata: map[string]any{"email": user.Email},
+				})
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(users) < purgeBatchSize {
+			return nil
+		}
+	}
+}
+
+// ListScheduledTasks returns the scheduled tasks with their last runs, by name
+func (as *AuthService) ListScheduledTasks(ctx context.Context) ([]queries.ScheduledTask, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	return as.dbQueries.ListScheduledTasks(ctx)
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/types"
+)
+
+var ErrProjectNotFound = errors.New("project not found")
+
+// Tenant reads and writes the data of one organization. Every query it runs is
+// filtered by the organization of its Membership, so handlers that get their Tenant
+// from the current organization can't reach the rows of another one.
+type Tenant struct {
+	Membership types.Membership
+	dbQueries  *queries.Queries
+}
+
+func (t *Tenant) ListProjects(ctx context.Context) ([]queries.Project, error) {
+	return t.dbQueries.ListProjects(ctx, t.Membership.OrganizationID)
+}
+
+func (t *Tenant) GetProject(ctx context.Context, id int32) (queries.Project, error) {
+	project, err := t.dbQueries.GetProject(ctx, queries.GetProjectParams{
+		OrganizationID: t.Membership.OrganizationID,
+		ID:             id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return project, ErrProjectNotFound
+	}
+
+	return project, err
+}
+
+func (t *Tenant) CreateProject(ctx context.Context, userID int32, name, description string) (queries.Project, error) {
+	return t.dbQueries.CreateProject(ctx, queries.CreateProjectParams{
+		OrganizationID: t.Membership.OrganizationID,
+		Name:           name,
+		Description:    description,
+		CreatedBy:      sql.NullInt32{Int32: userID, Valid: true},
+	})
+}
+
+// DeleteProject deletes a project of the organization. Projects of other
+// organizations are reported as not found.
+func (t *Tenant) DeleteProject(ctx context.Context, id int32) error {
+	deleted, err := t.dbQueries.DeleteProject(ctx, queries.DeleteProjectParams{
+		OrganizationID: t.Membership.OrganizationID,
+		ID:             id,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
+func (t *Tenant) ListMembers(ctx context.Context) ([]queries.ListMembersRow, error) {
+	return t.dbQueries.ListMembers(ctx, t.Membership.OrganizationID)
+}
+
+// ListInvitations returns every invitation of the organization, newest first,
+// including the ones that were accepted, declined, revoked or expired.
+func (t *Tenant) ListInvitations(ctx context.Context) ([]queries.ListInvitationsRow, error) {
+	return t.dbQueries.ListInvitations(ctx, t.Membership.OrganizationID)
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"go-web-starter/internal/queries"
+	"go-web-starter/internal/types"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpPeriod        = 30
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+)
+
+var totpOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// IsTwoFactorEnabled reports whether the user has a confirmed TOTP secret.
+func (as *AuthService) IsTwoFactorEnabled(ctx context.Context, userID int32) (bool, error) {
+	secret, err := as.dbQueries.GetTOTPSecret(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return secret.ConfirmedAt.Valid, nil
+}
+
+func (as *AuthService) GetTwoFactorStatus(ctx context.Context, userID int32) (types.TwoFactorStatus, error) {
+	enabled, err := as.IsTwoFactorEnabled(ctx, userID)
+	if err != nil || !enabled {
+		return types.TwoFactorStatus{}, err
+	}
+
+	left, err := as.dbQueries.CountUnusedRecoveryCodes(ctx, userID)
+
+	return types.TwoFactorStatus{Enabled: true, RecoveryCodesLeft: left}, err
+}
+
+// BeginTwoFactorSetup stores a new, unconfirmed TOTP secret for the user. Two-factor
+// authentication is only switched on once ConfirmTwoFactor gets a valid code.
+func (as *AuthService) BeginTwoFactorSetup(ctx context.Context, user *queries.User) (types.TwoFactorSetup, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	enabled, err := as.IsTwoFactorEnabled(ctx, user.ID)
+	if err != nil {
+		return types.TwoFactorSetup{}, err
+	}
+	if enabled {
+		return types.TwoFactorSetup{}, errors.New("two-factor authentication is already enabled")
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      as.config.TwoFactorIssuer,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return types.TwoFactorSetup{}, err
+	}
+
+	_, err = as.dbQueries.UpsertTOTPSecret(ctx, queries.UpsertTOTPSecretParams{
+		UserID: user.ID,
+		Secret: key.Secret(),
+	})
+	if err != nil {
+		return types.TwoFactorSetup{}, err
+	}
+
+	return twoFactorSetupFromKey(key)
+}
+
+// GetPendingTwoFactorSetup rebuilds the setup data of an unconfirmed secret, e.g. to
+// show the QR code again after a wrong confirmation code.
+func (as *AuthService) GetPendingTwoFactorSetup(ctx context.Context, user *queries.User) (types.TwoFactorSetup, error) {
+	secret, err := as.dbQueries.GetTOTPSecret(ctx, user.ID)
+	if err != nil {
+		return types.TwoFactorSetup{}, err
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      as.config.TwoFactorIssuer,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+		Secret:      mustDecodeSecret(secret.Secret),
+	})
+	if err != nil {
+		return types.TwoFactorSetup{}, err
+	}
+
+	return twoFactorSetupFromKey(key)
+}
+
+// ConfirmTwoFactor switches two-factor authentication on once the user proves their
+// authenticator app produces valid codes. It returns a fresh set of recovery codes.
+func (as *AuthService) ConfirmTwoFactor(ctx context.Context, userID int32, code string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	var recoveryCodes []string
+	err := as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		secret, err := qtx.GetTOTPSecret(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		step, ok := validateTOTP(secret.Secret, code, secret.LastUsedStep, time.Now())
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+
+		if _, err := qtx.UpdateTOTPLastUsedStep(ctx, queries.UpdateTOTPLastUsedStepParams{UserID: userID, LastUsedStep: step}); err != nil {
+			return err
+		}
+
+		if err := qtx.ConfirmTOTPSecret(ctx, userID); err != nil {
+			return err
+		}
+
+		recoveryCodes, err = createRecoveryCodes(ctx, qtx, userID)
+		return err
+	})
+
+	return recoveryCodes, err
+}
+
+// VerifyTwoFactor checks a TOTP code or an unused recovery code for the user.
+// Accepted codes can't be used a second time.
+func (as *AuthService) VerifyTwoFactor(ctx context.Context, userID int32, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	secret, err := as.dbQueries.GetTOTPSecret(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !secret.ConfirmedAt.Valid) {
+		return ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+
+	code = strings.TrimSpace(code)
+
+	if step, ok := validateTOTP(secret.Secret, code, secret.LastUsedStep, time.Now()); ok {
+		// a concurrent request may have used the same code in the meantime
+		updated, err := as.dbQueries.UpdateTOTPLastUsedStep(ctx, queries.UpdateTOTPLastUsedStepParams{UserID: userID, LastUsedStep: step})
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := as.dbQueries.UseRecoveryCode(ctx, queries.UseRecoveryCodeParams{
+		Hash:   hashRecoveryCode(code),
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// VerifyLoginTwoFactor is VerifyTwoFactor for the second step of a login. Invalid
+// codes count like failed logins, enough of them lock the second factor of the
+// account with the same backoff and notify the owner with a password reset link
+// built from baseURL.
+func (as *AuthService) VerifyLoginTwoFactor(ctx context.Context, userID int32, code, baseURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	u, err := as.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	user := &u
+
+	key := twoFactorAttemptKey(user.Email)
+
+	if err := as.checkLockout(ctx, key); err != nil {
+		if errors.Is(err, ErrAccountLocked) {
+			if auditErr := as.recordFailedLoginEvent(ctx, user.Email, user, "locked"); auditErr != nil {
+				return auditErr
+			}
+		}
+		return err
+	}
+
+	err = as.VerifyTwoFactor(ctx, user.ID, code)
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		if recordErr := as.recordFailedLogin(ctx, key, user, baseURL); recordErr != nil {
+			return recordErr
+		}
+
+		if auditErr := as.recordFailedLoginEvent(ctx, user.Email, user, "invalid_two_factor_code"); auditErr != nil {
+			return auditErr
+		}
+
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	return as.dbQueries.DeleteLoginAttempt(ctx, key)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user.
+func (as *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID int32) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	enabled, err := as.IsTwoFactorEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	var recoveryCodes []string
+	err = as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		recoveryCodes, err = createRecoveryCodes(ctx, as.dbQueries.WithTx(tx), userID)
+		return err
+	})
+
+	return recoveryCodes, err
+}
+
+// DisableTwoFactor removes the TOTP secret and recovery codes after checking the password.
+func (as *AuthService) DisableTwoFactor(ctx context.Context, userID int32, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	account, err := as.dbQueries.GetPasswordAccountByUserId(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !checkPasswordHash(account.Password.String, password) {
+		return errors.New("invalid password")
+	}
+
+	return as.dbService.WithTransaction(ctx, func(tx *sql.Tx) error {
+		qtx := as.dbQueries.WithTx(tx)
+
+		if err := qtx.DeleteRecoveryCodesForUser(ctx, userID); err != nil {
+			return err
+		}
+
+		return qtx.DeleteTOTPSecret(ctx, userID)
+	})
+}
+
+// validateTOTP checks a code against the secret and allows one time step of clock skew.
+// It returns the matching time step, which has to be newer than lastUsedStep so that
+// a code can't be replayed.
+func validateTOTP(secret, code string, lastUsedStep int64, now time.Time) (int64, bool) {
+	if len(code) != int(otp.DigitsSix) {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for _, step := range []int64{current - 1, current, current + 1} {
+		if step <= lastUsedStep {
+			continue
+		}
+
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totpOpts)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// createRecoveryCodes replaces the recovery codes of a user. Only the SHA-256 hashes
+// are stored, the plaintext codes are shown to the user once.
+func createRecoveryCodes(ctx context.Context, qtx *queries.Queries, userID int32) ([]string, error) {
+	if err := qtx.DeleteRecoveryCodesForUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		randomBytes := make([]byte, 10)
+		if _, err := rand.Read(randomBytes); err != nil {
+			return nil, err
+		}
+
+		// e.g. "k3j2h-4lmn5"
+		plaintext := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes))[:10]
+		code := plaintext[:5] + "-" + plaintext[5:]
+
+		err := qtx.CreateRecoveryCode(ctx, queries.CreateRecoveryCodeParams{
+			Hash:   hashRecoveryCode(code),
+			UserID: userID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+func hashRecoveryCode(code string) []byte {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	hash := sha256.Sum256([]byte(normalized))
+
+	return hash[:]
+}
+
+func twoFactorSetupFromKey(key *otp.Key) (types.TwoFactorSetup, error) {
+	img, err := key.Image(200, 200)
+	if err != nil {
+		return types.TwoFactorSetup{}, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return types.TwoFactorSetup{}, err
+	}
+
+	return types.TwoFactorSetup{
+		Secret: key.Secret(),
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+func mustDecodeSecret(secret string) []byte {
+	decoded, _ := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	return decoded
+}
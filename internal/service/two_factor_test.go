@@ -0,0 +1,63 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+func TestValidateTOTP(t *testing.T) {
+	const secret = "JBSWY3DPEHPK3PXP"
+
+	now := time.Unix(1_700_000_000, 0)
+	currentStep := now.Unix() / totpPeriod
+
+	codeAt := func(t *testing.T, at time.Time) string {
+		t.Helper()
+
+		code, err := totp.GenerateCodeCustom(secret, at, totpOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name         string
+		code         string
+		lastUsedStep int64
+		expectStep   int64
+		expectValid  bool
+	}{
+		{"current code", codeAt(t, now), 0, currentStep, true},
+		{"previous step is allowed for clock skew", codeAt(t, now.Add(-totpPeriod*time.Second)), 0, currentStep - 1, true},
+		{"next step is allowed for clock skew", codeAt(t, now.Add(totpPeriod*time.Second)), 0, currentStep + 1, true},
+		{"code outside of the skew window", codeAt(t, now.Add(-2*totpPeriod*time.Second)), 0, 0, false},
+		{"replayed code", codeAt(t, now), currentStep, 0, false},
+		{"wrong length", "12345", 0, 0, false},
+		{"empty code", "", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := validateTOTP(secret, tt.code, tt.lastUsedStep, now)
+			if ok != tt.expectValid {
+				t.Fatalf("validateTOTP(%q) valid = %v, want %v", tt.code, ok, tt.expectValid)
+			}
+			if ok && step != tt.expectStep {
+				t.Errorf("validateTOTP(%q) step = %d, want %d", tt.code, step, tt.expectStep)
+			}
+		})
+	}
+}
+
+func TestHashRecoveryCodeNormalizes(t *testing.T) {
+	expected := hashRecoveryCode("abcde-fghij")
+
+	for _, code := range []string{"ABCDE-FGHIJ", "abcdefghij", "abcde fghij"} {
+		if string(hashRecoveryCode(code)) != string(expected) {
+			t.Errorf("hashRecoveryCode(%q) does not match the normalized hash", code)
+		}
+	}
+}
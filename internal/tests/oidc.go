@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// OIDCUser is the account a FakeOIDCProvider signs in
+type OIDCUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// FakeOIDCProvider is a minimal OpenID Connect provider for testing social logins.
+// It approves every authorization request for its current User. ID tokens are not
+// signed, goth does not check the signature.
+type FakeOIDCProvider struct {
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	mu    sync.Mutex
+	user  OIDCUser
+	codes map[string]OIDCUser
+}
+
+// NewFakeOIDCProvider starts a fake provider that is shut down when the test ends.
+func NewFakeOIDCProvider(t *testing.T) *FakeOIDCProvider {
+	t.Helper()
+
+	p := &FakeOIDCProvider{
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		codes:        make(map[string]OIDCUser),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("POST /token", p.token)
+	mux.HandleFunc("GET /userinfo", p.userinfo)
+
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Server.Close)
+
+	return p
+}
+
+// DiscoveryURL is the value for OIDC_DISCOVERY_URL
+func (p *FakeOIDCProvider) DiscoveryURL() string {
+	return p.Server.URL + "/.well-known/openid-configuration"
+}
+
+// SetUser changes the account that is signed in by the next authorization
+func (p *FakeOIDCProvider) SetUser(user OIDCUser) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.user = user
+}
+
+// Authorize plays the user approving the authorization request at authURL, the
+// Location the app redirected to. It returns the path and query of the callback
+// the provider would redirect the browser to.
+func (p *FakeOIDCProvider) Authorize(t *testing.T, authURL string) string {
+	t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("invalid authorization URL %q: %v", authURL, err)
+	}
+
+	if u.Scheme+"://"+u.Host != p.Server.URL || u.Path != "/authorize" {
+		t.Fatalf("expected a redirect to the provider; got %q", authURL)
+	}
+
+	query := u.Query()
+	if query.Get("client_id") != p.ClientID {
+		t.Fatalf("expected client_id %q; got %q", p.ClientID, query.Get("client_id"))
+	}
+
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirectURI.Path == "" {
+		t.Fatalf("invalid redirect_uri %q", query.Get("redirect_uri"))
+	}
+
+	code := rand.Text()
+
+	p.mu.Lock()
+	p.codes[code] = p.user
+	p.mu.Unlock()
+
+	callback := url.Values{
+		"code":  {code},
+		"state": {query.Get("state")},
+	}
+
+	return redirectURI.Path + "?" + callback.Encode()
+}
+
+func (p *FakeOIDCProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{
+		"issuer":                 p.Server.URL,
+		"authorization_endpoint": p.Server.URL + "/authorize",
+		"token_endpoint":         p.Server.URL + "/token",
+		"userinfo_endpoint":      p.Server.URL + "/userinfo",
+	})
+}
+
+func (p *FakeOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	if clientID != p.ClientID || clientSecret != p.ClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+
+	code := r.PostFormValue("code")
+
+	p.mu.Lock()
+	user, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	if !ok {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		// the access token is the subject so userinfo knows who is asking
+		"access_token": user.Subject,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     p.idToken(user),
+	})
+}
+
+func (p *FakeOIDCProvider) userinfo(w http.ResponseWriter, r *http.Request) {
+	subject := r.Header.Get("Authorization")
+	if len(subject) <= len("Bearer ") {
+		http.Error(w, "missing access token", http.StatusUnauthorized)
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"sub": subject[len("Bearer "):],
+	})
+}
+
+func (p *FakeOIDCProvider) idToken(user OIDCUser) string {
+	claims, _ := json.Marshal(map[string]any{
+		"iss":            p.Server.URL,
+		"aud":            p.ClientID,
+		"sub":            user.Subject,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"name":           user.Name,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	})
+
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + encode(claims) + "."
+}
+
+func writeJSON(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(data)
+}
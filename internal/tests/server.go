@@ -17,6 +17,7 @@ import (
 	"go-web-starter/internal/config"
 	"go-web-starter/internal/database"
 	"go-web-starter/internal/jsonlog"
+	"go-web-starter/internal/mailer"
 	"go-web-starter/internal/queries"
 	"go-web-starter/internal/server"
 
@@ -39,7 +40,7 @@ type TestServer struct {
 	Session     *scs.SessionManager
 	Config      config.Config
 	HTTPServer  *server.Server
-	Mailer      *MockMailer
+	Mailer      *mailer.MemoryMailer
 	PgContainer testcontainers.Container // nil for SQLite
 }
 
@@ -53,6 +54,7 @@ func NewTestServer(t *testing.T) *TestServer {
 	cfg := getTestConfig()
 
 	cfg.AppEnv = "test"
+	cfg.DevTools = true
 
 	db, container := setupTestDatabase(t)
 
@@ -64,11 +66,11 @@ func NewTestServer(t *testing.T) *TestServer {
 
 	logger := jsonlog.New(io.Discard, jsonlog.LevelInfo)
 
-	mockMailer := NewMockMailer()
+	memoryMailer := mailer.NewMemory(cfg.Mailer.Sender, mailer.NewBrand(cfg))
 
 	sessionManager := setupTestSessionManager()
 
-	s := server.NewServer(cfg, dbService, q, logger, mockMailer, sessionManager)
+	s := server.NewServer(cfg, dbService, q, logger, memoryMailer, sessionManager)
 
 	ts := httptest.NewServer(s.RegisterRoutes())
 
@@ -88,7 +90,7 @@ func NewTestServer(t *testing.T) *TestServer {
 		Session:     sessionManager,
 		Config:      cfg,
 		HTTPServer:  s,
-		Mailer:      mockMailer,
+		Mailer:      memoryMailer,
 		PgContainer: container,
 	}
 }
@@ -217,9 +219,30 @@ func setupPostgresTestDatabase(t *testing.T) (*sql.DB, testcontainers.Container)
 func cleanTestDatabase(t *testing.T, db *sql.DB) {
 	t.Helper()
 
-	// Tables to clean in reverse order of foreign key dependencies
+	// Tables to clean in reverse order of foreign key dependencies. roles and
+	// permissions are seeded by the migrations and kept.
 	tables := []string{
+		"scheduled_tasks",
+		"jobs",
+		"outbox_attempts",
+		"outbox_messages",
+		"invitations",
+		"projects",
+		"memberships",
+		"organizations",
+		"audit_events",
+		"impersonations",
+		"disabled_users",
+		"user_roles",
+		"webauthn_credentials",
+		"recovery_codes",
+		"totp_secrets",
+		"personal_access_tokens",
+		"email_changes",
+		"pending_account_links",
 		"tokens",
+		"login_attempts",
+		"user_sessions",
 		"sessions",
 		"accounts",
 		"users",
@@ -20,6 +20,25 @@ func (ts *TestServer) CleanDatabase(t *testing.T) {
 	cleanTestDatabase(t, ts.DB)
 }
 
+// RunJobs delivers the emails of the outbox and runs the background jobs that are
+// due, e.g. to send the emails queued by a request, and returns how many emails
+// and jobs there were
+func (ts *TestServer) RunJobs(t *testing.T) int {
+	t.Helper()
+
+	sent, err := ts.HTTPServer.NewDispatcher().DispatchPending(context.Background())
+	if err != nil {
+		t.Fatalf("failed to deliver the outbox: %v", err)
+	}
+
+	count, err := ts.HTTPServer.NewWorker().RunPending(context.Background())
+	if err != nil {
+		t.Fatalf("failed to run jobs: %v", err)
+	}
+
+	return sent + count
+}
+
 func (ts *TestServer) WithTransaction(t *testing.T, fn func(*testing.T, *sql.Tx)) {
 	t.Helper()
 
@@ -63,6 +82,54 @@ func (ts *TestServer) Get(t *testing.T, urlPath string) (int, http.Header, strin
 	return resp.StatusCode, resp.Header, string(body)
 }
 
+// DoWithToken makes a request authenticated with an access token in the
+// Authorization: Bearer header, without cookies
+func (ts *TestServer) DoWithToken(t *testing.T, method, urlPath, token string, body io.Reader) (int, http.Header, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, ts.Server.URL+urlPath, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := ts.Client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	defer resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, resp.Header, string(respBody)
+}
+
+// DoJSON makes an API request with data encoded as the JSON body, authenticated with
+// token unless it is empty
+func (ts *TestServer) DoJSON(t *testing.T, method, urlPath, token string, data any) (int, http.Header, string) {
+	t.Helper()
+
+	var body io.Reader
+	if data != nil {
+		jsonBytes, err := json.Marshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = bytes.NewReader(jsonBytes)
+	}
+
+	return ts.DoWithToken(t, method, urlPath, token, body)
+}
+
 // PostForm makes a POST request with form data to the test server
 func (ts *TestServer) PostForm(t *testing.T, urlPath string, form map[string]string) (int, http.Header, string) {
 	t.Helper()
@@ -127,6 +194,31 @@ func (ts *TestServer) PostJSON(t *testing.T, urlPath string, jsonData interface{
 	return resp.StatusCode, resp.Header, string(body)
 }
 
+// PostJSONWithClient makes a POST request with a raw JSON body and a specific client
+func (ts *TestServer) PostJSONWithClient(t *testing.T, client *http.Client, urlPath string, jsonBody string) (int, http.Header, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, ts.Server.URL+urlPath, strings.NewReader(jsonBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, resp.Header, string(body)
+}
+
 // CreateTestUser creates a test user in the database
 func (ts *TestServer) CreateTestUser(t *testing.T, name, email, password string) *queries.User {
 	t.Helper()
@@ -0,0 +1,255 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+)
+
+// authenticator data flags, see https://www.w3.org/TR/webauthn-3/#authdata-flags
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+)
+
+// SoftAuthenticator is a software WebAuthn authenticator. It creates discoverable
+// ES256 credentials with "none" attestation and signs assertions, so passkey
+// ceremonies can be tested end to end without a browser.
+type SoftAuthenticator struct {
+	Origin string
+	// NoUserVerification makes it act like a security key without a PIN or
+	// biometric, it only reports the presence of the user
+	NoUserVerification bool
+	credentials        []*softCredential
+}
+
+type softCredential struct {
+	id         []byte
+	rpID       string
+	userHandle []byte
+	key        *ecdsa.PrivateKey
+	signCount  uint32
+}
+
+// NewSoftAuthenticator returns an authenticator that reports origin in the client data,
+// as a browser on that origin would.
+func NewSoftAuthenticator(origin string) *SoftAuthenticator {
+	return &SoftAuthenticator{Origin: origin}
+}
+
+// publicKeyOptions holds the fields of the creation and request options the
+// authenticator needs.
+type publicKeyOptions struct {
+	PublicKey struct {
+		Challenge string `json:"challenge"`
+		RPID      string `json:"rpId"`
+		RP        struct {
+			ID string `json:"id"`
+		} `json:"rp"`
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+		AllowCredentials []struct {
+			ID string `json:"id"`
+		} `json:"allowCredentials"`
+	} `json:"publicKey"`
+}
+
+// CreateCredential answers the options of navigator.credentials.create() and returns
+// the JSON the browser would post back to the server.
+func (a *SoftAuthenticator) CreateCredential(t *testing.T, optionsJSON string) string {
+	t.Helper()
+
+	var options publicKeyOptions
+	if err := json.Unmarshal([]byte(optionsJSON), &options); err != nil {
+		t.Fatalf("invalid credential creation options: %v", err)
+	}
+
+	userHandle, err := base64.RawURLEncoding.DecodeString(options.PublicKey.User.ID)
+	if err != nil {
+		t.Fatalf("invalid user id in creation options: %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credential := &softCredential{
+		id:         randomBytes(t, 32),
+		rpID:       options.PublicKey.RP.ID,
+		userHandle: userHandle,
+		key:        key,
+	}
+
+	clientData := a.clientDataJSON(t, "webauthn.create", options.PublicKey.Challenge)
+
+	// attested credential data: AAGUID (all zero), credential id length, credential id, COSE key
+	var authData bytes.Buffer
+	authData.Write(credential.authDataPrefix(a.userFlags() | flagAttestedCredentialData))
+	authData.Write(make([]byte, 16))
+	_ = binary.Write(&authData, binary.BigEndian, uint16(len(credential.id)))
+	authData.Write(credential.id)
+	authData.Write(credential.coseKey(t))
+
+	attestationObject, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData.Bytes(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a.credentials = append(a.credentials, credential)
+
+	return mustJSON(t, map[string]any{
+		"id":    encode(credential.id),
+		"rawId": encode(credential.id),
+		"type":  "public-key",
+		"response": map[string]any{
+			"clientDataJSON":    encode(clientData),
+			"attestationObject": encode(attestationObject),
+			"transports":        []string{"internal"},
+		},
+	})
+}
+
+// GetAssertion answers the options of navigator.credentials.get() with the most
+// recently created matching credential and returns the JSON the browser would post.
+func (a *SoftAuthenticator) GetAssertion(t *testing.T, optionsJSON string) string {
+	t.Helper()
+
+	var options publicKeyOptions
+	if err := json.Unmarshal([]byte(optionsJSON), &options); err != nil {
+		t.Fatalf("invalid credential request options: %v", err)
+	}
+
+	var allowed []string
+	for _, c := range options.PublicKey.AllowCredentials {
+		allowed = append(allowed, c.ID)
+	}
+
+	var credential *softCredential
+	for _, c := range slices.Backward(a.credentials) {
+		if c.rpID == options.PublicKey.RPID && (len(allowed) == 0 || slices.Contains(allowed, encode(c.id))) {
+			credential = c
+			break
+		}
+	}
+	if credential == nil {
+		t.Fatalf("soft authenticator has no credential for relying party %q", options.PublicKey.RPID)
+	}
+
+	credential.signCount++
+
+	clientData := a.clientDataJSON(t, "webauthn.get", options.PublicKey.Challenge)
+	authData := credential.authDataPrefix(a.userFlags())
+
+	clientDataHash := sha256.Sum256(clientData)
+	signed := sha256.Sum256(append(slices.Clone(authData), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, credential.key, signed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return mustJSON(t, map[string]any{
+		"id":    encode(credential.id),
+		"rawId": encode(credential.id),
+		"type":  "public-key",
+		"response": map[string]any{
+			"clientDataJSON":    encode(clientData),
+			"authenticatorData": encode(authData),
+			"signature":         encode(signature),
+			"userHandle":        encode(credential.userHandle),
+		},
+	})
+}
+
+func (a *SoftAuthenticator) clientDataJSON(t *testing.T, ceremony, challenge string) []byte {
+	t.Helper()
+
+	return []byte(mustJSON(t, map[string]any{
+		"type":        ceremony,
+		"challenge":   challenge,
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	}))
+}
+
+// userFlags are the flags about the user the authenticator reports
+func (a *SoftAuthenticator) userFlags() byte {
+	if a.NoUserVerification {
+		return flagUserPresent
+	}
+
+	return flagUserPresent | flagUserVerified
+}
+
+// authDataPrefix returns the RP ID hash, flags and signature counter
+func (c *softCredential) authDataPrefix(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(c.rpID))
+
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, c.signCount)
+}
+
+// coseKey encodes the public key as a COSE_Key map (EC2, ES256, P-256)
+func (c *softCredential) coseKey(t *testing.T) []byte {
+	t.Helper()
+
+	pub, err := c.key.PublicKey.ECDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// uncompressed point: 0x04 || x || y
+	point := pub.Bytes()
+
+	key, err := webauthncbor.Marshal(map[int]any{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: point[1:33],
+		-3: point[33:],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -1,6 +1,13 @@
 package types
 
-import "go-web-starter/internal/queries"
+import (
+	"database/sql"
+	"go-web-starter/internal/config"
+	"go-web-starter/internal/mailer"
+	"go-web-starter/internal/queries"
+	"slices"
+	"time"
+)
 
 // TemplateData contains common data passed to all templates
 type TemplateData struct {
@@ -13,6 +20,21 @@ type TemplateData struct {
 	AppName         string
 	AppEnv          string
 	CurrentPath     string
+	// SocialProviders are the social logins that can be used
+	SocialProviders []SocialProvider
+	// Organization is the current organization of the user, nil when they have none
+	Organization *Membership
+	// Organizations are all organizations the user is a member of
+	Organizations []Membership
+	// Impersonator is the admin logged in as the user, nil when the user is logged in themselves
+	Impersonator *queries.User
+}
+
+// SocialProvider is a configured social login provider
+type SocialProvider struct {
+	// Name identifies the provider in /auth/{provider}
+	Name        string
+	DisplayName string
 }
 
 // PageData wraps template data with page-specific data
@@ -20,3 +42,137 @@ type PageData struct {
 	Template TemplateData
 	Data     any
 }
+
+// TwoFactorStatus describes the two-factor authentication settings of a user
+type TwoFactorStatus struct {
+	Enabled           bool
+	RecoveryCodesLeft int64
+}
+
+// TwoFactorSetup holds what a user needs to add the account to an authenticator app
+type TwoFactorSetup struct {
+	Secret string
+	// QRCode is a data URI of a PNG image encoding the otpauth:// URL
+	QRCode string
+}
+
+// ActiveSession is a logged in session of a user as listed on the profile page
+type ActiveSession struct {
+	ID         int32
+	Device     string
+	IPAddress  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	// Current is true for the session of the request that lists the sessions
+	Current bool
+}
+
+// ConnectedAccount is a social login provider as listed on the profile page
+type ConnectedAccount struct {
+	Provider SocialProvider
+	// Connected is false for configured providers the user hasn't connected yet
+	Connected   bool
+	ConnectedAt time.Time
+}
+
+// Membership is an organization of a user along with their role in it
+type Membership struct {
+	OrganizationID int32
+	Name           string
+	// Personal organizations are created with the user
+	Personal bool
+	Role     string
+}
+
+// HasRole reports whether the role of the member is role or a more privileged one
+func (m Membership) HasRole(role string) bool {
+	have := slices.Index(config.OrgRoles, m.Role)
+	want := slices.Index(config.OrgRoles, role)
+
+	return have != -1 && want != -1 && have <= want
+}
+
+// UserFilter narrows down the user list of the back office
+type UserFilter struct {
+	// Search matches part of the name or the email address
+	Search string
+	// Status is one of config.UserStatuses, empty for all users
+	Status string
+	Page   int
+}
+
+// UserPage is a page of the user list of the back office
+type UserPage struct {
+	Users []queries.ListUsersRow
+	// Filter is the filter of the page, with the page number within the total pages
+	Filter     UserFilter
+	Total      int64
+	TotalPages int
+}
+
+// UserDetails is a user as shown in the back office
+type UserDetails struct {
+	User queries.User
+	// DisabledAt is set for users that can't log in
+	DisabledAt sql.NullTime
+	Accounts   []queries.Account
+	Sessions   []ActiveSession
+	Roles      []queries.Role
+	// Impersonations are the latest times admins logged in as the user
+	Impersonations []queries.ListImpersonationsOfUserRow
+}
+
+// Client is who sent a request, as recorded in the audit log
+type Client struct {
+	IPAddress string
+	UserAgent string
+}
+
+// AuditFilter narrows down the audit log
+type AuditFilter struct {
+	// Action is one of config.AuditActions, empty for all actions
+	Action string
+	// Search matches part of the email address of the actor or the target, or of
+	// the IP address
+	Search string
+	// Since and Until are dates like 2006-01-02, both days included. Empty or
+	// invalid dates don't narrow down the log.
+	Since string
+	Until string
+	Page  int
+}
+
+// AuditPage is a page of the audit log
+type AuditPage struct {
+	Events []queries.ListAuditEventsRow
+	// Filter is the filter of the page, with the page number within the total pages
+	Filter     AuditFilter
+	Total      int64
+	TotalPages int
+}
+
+// Tabs of an email in the mail preview
+const (
+	MailTabSubject = "subject"
+	MailTabPlain   = "plain"
+	MailTabHTML    = "html"
+)
+
+// MailPreview is the page of the development mail preview
+type MailPreview struct {
+	// Samples are the names of all email types, see mailer.Samples
+	Samples []string
+	// Inbox are the emails captured by the mail driver, oldest first. It is nil
+	// when the driver doesn't keep them.
+	Inbox []mailer.Message
+	// Sample is the name of the previewed sample, Message the 1-based position of
+	// the previewed email of the inbox. At most one of them is set.
+	Sample  string
+	Message int
+	// Email is the previewed email, nil when none is picked
+	Email *mailer.Message
+	// Error is why the sample can't be rendered
+	Error string
+	// Tab is one of the MailTab* constants
+	Tab string
+}
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- tokens for scripts and integrations calling the app with an Authorization: Bearer
+-- header. Like tokens, only the sha256 hash of the token is stored.
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+	id SERIAL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	hash bytea NOT NULL UNIQUE,
+	-- space separated, like OAuth scopes
+	scopes TEXT NOT NULL DEFAULT '',
+	-- NULL for tokens that never expire
+	expires_at timestamptz,
+	last_used_at timestamptz,
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX personal_access_tokens_user_id_idx ON personal_access_tokens (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS personal_access_tokens;
+-- +goose StatementEnd
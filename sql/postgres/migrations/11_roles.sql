@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+-- role based access control: users get roles, roles grant permissions. Routes check
+-- permissions, never roles, so roles can be reshaped without touching the code.
+CREATE TABLE IF NOT EXISTS roles (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	description TEXT NOT NULL DEFAULT '',
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+	id SERIAL PRIMARY KEY,
+	-- resource.action, e.g. users.manage
+	name TEXT NOT NULL UNIQUE,
+	description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+	role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+	permission_id INT NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+	PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX user_roles_role_id_idx ON user_roles (role_id);
+
+-- default roles, admin has every permission
+INSERT INTO permissions (name, description) VALUES
+	('users.view', 'See all users and their accounts'),
+	('users.manage', 'Change, disable and delete users'),
+	('roles.manage', 'Give roles to users and take them away');
+
+INSERT INTO roles (name, description) VALUES
+	('admin', 'Full access to the back office'),
+	('support', 'Read only access to users');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles, permissions
+WHERE roles.name = 'admin'
+   OR (roles.name = 'support' AND permissions.name = 'users.view');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
+-- +goose StatementEnd
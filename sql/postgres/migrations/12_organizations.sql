@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+-- organizations are the tenants of the app. Users work in them through memberships,
+-- each with its own role. Tenant data like projects belongs to an organization.
+CREATE TABLE IF NOT EXISTS organizations (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	-- personal organizations are created with every user and can't be left
+	personal BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS memberships (
+	organization_id INT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	role TEXT NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX memberships_user_id_idx ON memberships (user_id);
+
+CREATE TABLE IF NOT EXISTS projects (
+	id SERIAL PRIMARY KEY,
+	organization_id INT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	created_by INT REFERENCES users(id) ON DELETE SET NULL,
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX projects_organization_id_idx ON projects (organization_id);
+
+-- every existing user gets a personal organization, like new users do
+ALTER TABLE organizations ADD COLUMN owner_id INT;
+
+INSERT INTO organizations (name, personal, owner_id)
+SELECT users.name, TRUE, users.id FROM users;
+
+INSERT INTO memberships (organization_id, user_id, role)
+SELECT organizations.id, organizations.owner_id, 'owner' FROM organizations;
+
+ALTER TABLE organizations DROP COLUMN owner_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS memberships;
+DROP TABLE IF EXISTS organizations;
+-- +goose StatementEnd
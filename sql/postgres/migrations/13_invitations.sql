@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- invitations to join an organization, sent by email. The link carries a token in
+-- tokens with the invitation scope, issued to the inviter. The invitation outlives
+-- its token so the inviter still sees declined and expired invitations.
+CREATE TABLE IF NOT EXISTS invitations (
+	id SERIAL PRIMARY KEY,
+	organization_id INT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+	email TEXT NOT NULL,
+	role TEXT NOT NULL CHECK (role IN ('admin', 'member')),
+	invited_by INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	-- NULL once the invitation is accepted, declined or revoked
+	token_hash bytea UNIQUE REFERENCES tokens (hash) ON DELETE SET NULL,
+	status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'revoked')),
+	expires_at timestamptz NOT NULL,
+	responded_at timestamptz,
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX invitations_organization_id_idx ON invitations (organization_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS invitations;
+-- +goose StatementEnd
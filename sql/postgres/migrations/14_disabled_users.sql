@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- users disabled in the back office. They can't log in, their sessions are signed
+-- out and their access tokens stop working until they are enabled again.
+CREATE TABLE IF NOT EXISTS disabled_users (
+	user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	-- the admin who disabled the user
+	disabled_by INT REFERENCES users(id) ON DELETE SET NULL,
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS disabled_users;
+-- +goose StatementEnd
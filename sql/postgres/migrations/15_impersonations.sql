@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- audit trail of admins logging in as another user. A row is written when the
+-- impersonation starts and ended when the admin stops it.
+CREATE TABLE IF NOT EXISTS impersonations (
+	id SERIAL PRIMARY KEY,
+	-- NULL once the admin is deleted, the trail is kept
+	admin_id INT REFERENCES users(id) ON DELETE SET NULL,
+	user_id INT REFERENCES users(id) ON DELETE SET NULL,
+	ip_address TEXT NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	started_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	-- NULL while the impersonation is active, or when the session expired before it was stopped
+	ended_at timestamptz
+);
+
+CREATE INDEX impersonations_user_id_idx ON impersonations (user_id);
+
+INSERT INTO permissions (name, description) VALUES
+	('users.impersonate', 'Log in as another user to see what they see');
+
+-- support engineers need to see what a customer sees too
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles, permissions
+WHERE roles.name IN ('admin', 'support') AND permissions.name = 'users.impersonate';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'users.impersonate';
+DROP TABLE IF EXISTS impersonations;
+-- +goose StatementEnd
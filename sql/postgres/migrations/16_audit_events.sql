@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- security-relevant events like logins, password changes and admin actions. The
+-- user IDs have no foreign keys, the events of deleted users are kept.
+CREATE TABLE IF NOT EXISTS audit_events (
+	id BIGSERIAL PRIMARY KEY,
+	-- config.AuditAction*, e.g. login.failed
+	action TEXT NOT NULL,
+	-- the user who did it, NULL for logged out visitors
+	actor_id INT,
+	-- the user it was done to
+	target_id INT,
+	ip_address TEXT NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	metadata JSONB NOT NULL DEFAULT '{}',
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at);
+CREATE INDEX audit_events_actor_id_idx ON audit_events (actor_id);
+CREATE INDEX audit_events_target_id_idx ON audit_events (target_id);
+
+INSERT INTO permissions (name, description) VALUES
+	('audit.view', 'See the audit log of security-relevant events');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles, permissions
+WHERE roles.name = 'admin' AND permissions.name = 'audit.view';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'audit.view';
+DROP TABLE IF EXISTS audit_events;
+-- +goose StatementEnd
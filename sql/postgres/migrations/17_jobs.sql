@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- background jobs, picked by the workers with FOR UPDATE SKIP LOCKED. Failed jobs
+-- are retried with exponential backoff until they are dead.
+CREATE TABLE IF NOT EXISTS jobs (
+	id BIGSERIAL PRIMARY KEY,
+	-- jobs.Job.Kind, picks the handler
+	kind TEXT NOT NULL,
+	payload JSONB NOT NULL DEFAULT '{}',
+	-- pending, running, done or dead
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	max_attempts INT NOT NULL DEFAULT 5,
+	-- pending jobs don't run before run_at, the backoff of retries moves it
+	run_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	-- when the running attempt started, running jobs locked for too long are picked again
+	locked_at timestamptz,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	finished_at timestamptz
+);
+
+CREATE INDEX jobs_runnable_idx ON jobs (run_at) WHERE status IN ('pending', 'running');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS jobs;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the recurring tasks of the scheduler and their last run. A replica runs a task
+-- when it holds its advisory lock and moves next_run_at past now.
+CREATE TABLE IF NOT EXISTS scheduled_tasks (
+	name TEXT PRIMARY KEY,
+	-- cron expression or descriptor, e.g. "0 3 * * *" or "@hourly"
+	schedule TEXT NOT NULL,
+	next_run_at timestamptz NOT NULL,
+	-- running, succeeded or failed, empty before the first run
+	last_status TEXT NOT NULL DEFAULT '',
+	last_error TEXT NOT NULL DEFAULT '',
+	last_started_at timestamptz,
+	last_finished_at timestamptz,
+	last_duration_ms BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO permissions (name, description) VALUES
+	('tasks.view', 'See the scheduled tasks and their last runs');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles, permissions
+WHERE roles.name = 'admin' AND permissions.name = 'tasks.view';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'tasks.view';
+DROP TABLE IF EXISTS scheduled_tasks;
+-- +goose StatementEnd
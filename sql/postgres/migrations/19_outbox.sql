@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+-- emails written in the transaction of the change they are about, and delivered
+-- by the dispatcher once it is committed. Failed deliveries are retried with
+-- exponential backoff until the message is dead.
+CREATE TABLE IF NOT EXISTS outbox_messages (
+	id BIGSERIAL PRIMARY KEY,
+	-- a message is only added once per key, e.g. welcome:42
+	idempotency_key TEXT NOT NULL UNIQUE,
+	-- mailer.Name of the email, e.g. WelcomeEmail
+	email_type TEXT NOT NULL,
+	-- the addresses the message goes to, comma separated
+	recipients TEXT NOT NULL,
+	-- mailer.MessageBuilder as JSON, cleared once sent as links may hold tokens
+	message JSONB NOT NULL DEFAULT '{}',
+	-- pending, sending, sent or dead
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	max_attempts INT NOT NULL DEFAULT 8,
+	-- pending messages aren't sent before next_attempt_at, the backoff of retries moves it
+	next_attempt_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	-- when the running attempt started, messages locked for too long are picked again
+	locked_at timestamptz,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	sent_at timestamptz
+);
+
+CREATE INDEX outbox_messages_pending_idx ON outbox_messages (next_attempt_at) WHERE status IN ('pending', 'sending');
+
+-- every delivery attempt of an outbox message
+CREATE TABLE IF NOT EXISTS outbox_attempts (
+	id BIGSERIAL PRIMARY KEY,
+	message_id BIGINT NOT NULL REFERENCES outbox_messages (id) ON DELETE CASCADE,
+	attempt INT NOT NULL,
+	started_at timestamptz NOT NULL,
+	finished_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	-- empty when the message was sent
+	error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX outbox_attempts_message_idx ON outbox_attempts (message_id, attempt);
+
+INSERT INTO permissions (name, description) VALUES
+	('outbox.view', 'See the outbox of emails and their delivery attempts');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT roles.id, permissions.id FROM roles, permissions
+WHERE roles.name = 'admin' AND permissions.name = 'outbox.view';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'outbox.view';
+DROP TABLE IF EXISTS outbox_attempts;
+DROP TABLE IF EXISTS outbox_messages;
+-- +goose StatementEnd
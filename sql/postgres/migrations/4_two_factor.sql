@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS totp_secrets (
+	user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	secret TEXT NOT NULL,
+	-- NULL until the user has entered a valid code from their authenticator app
+	confirmed_at timestamptz,
+	-- time step of the last accepted code, used to prevent replaying codes
+	last_used_step BIGINT NOT NULL DEFAULT 0,
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+	hash bytea PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	used_at timestamptz,
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS recovery_codes_user_id_idx;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS totp_secrets;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+	id SERIAL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	-- name given by the user, e.g. "MacBook" or "YubiKey"
+	name TEXT NOT NULL,
+	credential_id bytea NOT NULL UNIQUE,
+	public_key bytea NOT NULL,
+	attestation_type TEXT NOT NULL DEFAULT '',
+	-- comma separated list of transports reported by the authenticator
+	transports TEXT NOT NULL DEFAULT '',
+	aaguid bytea,
+	-- authenticator data flags (user verified, backup eligible, backup state)
+	flags SMALLINT NOT NULL DEFAULT 0,
+	sign_count BIGINT NOT NULL DEFAULT 0,
+	last_used_at timestamptz,
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX webauthn_credentials_user_id_idx ON webauthn_credentials (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS webauthn_credentials_user_id_idx;
+DROP TABLE IF EXISTS webauthn_credentials;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- index of the logged in sessions of a user, the session data itself stays in sessions
+CREATE TABLE IF NOT EXISTS user_sessions (
+	id SERIAL PRIMARY KEY,
+	-- session token, the same as sessions.token when the postgres session store is used
+	token TEXT NOT NULL UNIQUE,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	user_agent TEXT NOT NULL DEFAULT '',
+	ip_address TEXT NOT NULL DEFAULT '',
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_seen_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX user_sessions_user_id_idx ON user_sessions (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS user_sessions_user_id_idx;
+DROP TABLE IF EXISTS user_sessions;
+-- +goose StatementEnd
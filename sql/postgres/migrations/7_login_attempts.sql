@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- failed logins per email address, also for addresses without an account so
+-- lockouts don't reveal which emails are registered
+CREATE TABLE IF NOT EXISTS login_attempts (
+	email TEXT PRIMARY KEY,
+	failed_count INT NOT NULL DEFAULT 0,
+	last_failed_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	locked_until timestamptz
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS login_attempts;
+-- +goose StatementEnd
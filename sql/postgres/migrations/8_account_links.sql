@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a provider account can only be connected to one user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_provider_account ON accounts (provider_id, account_id)
+WHERE provider_id IS NOT NULL;
+
+-- social accounts waiting for the user to confirm the link by email. The token
+-- in tokens carries the user and expiry, this table the account to connect.
+CREATE TABLE IF NOT EXISTS pending_account_links (
+	token_hash bytea PRIMARY KEY REFERENCES tokens (hash) ON DELETE CASCADE,
+	provider_id TEXT NOT NULL,
+	account_id TEXT NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS pending_account_links;
+DROP INDEX IF EXISTS idx_accounts_provider_account;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- email addresses users asked to change to. users.email only changes once the new
+-- address is confirmed with the email-change token.
+CREATE TABLE IF NOT EXISTS email_changes (
+	user_id INT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+	new_email TEXT NOT NULL,
+	expiry timestamp(0) with time zone NOT NULL,
+	created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS email_changes;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (user_id, name, hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetPersonalAccessTokenByHash :one
+-- tokens of disabled users don't work, they are kept for when the user is enabled
+SELECT * FROM personal_access_tokens
+WHERE hash = $1
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND NOT EXISTS (
+    SELECT 1 FROM disabled_users WHERE disabled_users.user_id = personal_access_tokens.user_id
+  );
+
+-- name: ListPersonalAccessTokens :many
+SELECT * FROM personal_access_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: TouchPersonalAccessToken :exec
+UPDATE personal_access_tokens SET last_used_at = NOW() WHERE id = $1;
+
+-- name: DeletePersonalAccessToken :execrows
+DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2;
@@ -3,8 +3,18 @@ INSERT INTO accounts (account_id, user_id, password, provider_id)
 VALUES ( $1, $2, $3, $4)
 RETURNING *;
 
--- name: GetAccountByUserId :one
-SELECT * FROM accounts WHERE user_id = $1;
+-- name: GetPasswordAccountByUserId :one
+-- the email and password account, social accounts have a provider
+SELECT * FROM accounts WHERE user_id = $1 AND provider_id IS NULL;
+
+-- name: GetAccountByProvider :one
+SELECT * FROM accounts
+WHERE provider_id = $1 AND account_id = $2;
+
+-- name: ListAccountsForUser :many
+SELECT * FROM accounts
+WHERE user_id = $1
+ORDER BY created_at;
 
 -- name: GetAccountByUserIdAndProvider :one
 SELECT * FROM accounts
@@ -25,3 +35,18 @@ WHERE user_id = $4 AND provider_id = $5;
 
 -- name: DeleteAccountsByUserId :exec
 DELETE FROM accounts WHERE user_id = $1;
+
+-- name: DeleteAccountByUserIdAndProvider :execrows
+DELETE FROM accounts WHERE user_id = $1 AND provider_id = $2;
+
+-- name: CreatePendingAccountLink :exec
+INSERT INTO pending_account_links (token_hash, provider_id, account_id)
+VALUES ($1, $2, $3);
+
+-- name: GetPendingAccountLink :one
+SELECT tokens.user_id, pending_account_links.provider_id, pending_account_links.account_id
+FROM pending_account_links
+JOIN tokens ON tokens.hash = pending_account_links.token_hash
+WHERE pending_account_links.token_hash = $1
+  AND tokens.scope = $2
+  AND tokens.expiry > $3;
@@ -0,0 +1,37 @@
+-- name: ListUsers :many
+-- users of the back office, newest first. search is an ILIKE pattern matched
+-- against the name and the email address, empty for all users. status is one of
+-- config.UserStatuses, empty for all users.
+SELECT users.*, disabled_users.created_at AS disabled_at FROM users
+LEFT JOIN disabled_users ON disabled_users.user_id = users.id
+WHERE (sqlc.arg(search)::text = ''
+       OR users.name ILIKE sqlc.arg(search)::text
+       OR users.email ILIKE sqlc.arg(search)::text)
+  AND (sqlc.arg(status)::text = ''
+       OR (sqlc.arg(status)::text = 'verified' AND users.email_verified AND disabled_users.user_id IS NULL)
+       OR (sqlc.arg(status)::text = 'unverified' AND NOT users.email_verified AND disabled_users.user_id IS NULL)
+       OR (sqlc.arg(status)::text = 'disabled' AND disabled_users.user_id IS NOT NULL))
+ORDER BY users.created_at DESC, users.id DESC
+LIMIT sqlc.arg(page_size)::int OFFSET sqlc.arg(page_offset)::int;
+
+-- name: CountUsers :one
+SELECT COUNT(*) FROM users
+LEFT JOIN disabled_users ON disabled_users.user_id = users.id
+WHERE (sqlc.arg(search)::text = ''
+       OR users.name ILIKE sqlc.arg(search)::text
+       OR users.email ILIKE sqlc.arg(search)::text)
+  AND (sqlc.arg(status)::text = ''
+       OR (sqlc.arg(status)::text = 'verified' AND users.email_verified AND disabled_users.user_id IS NULL)
+       OR (sqlc.arg(status)::text = 'unverified' AND NOT users.email_verified AND disabled_users.user_id IS NULL)
+       OR (sqlc.arg(status)::text = 'disabled' AND disabled_users.user_id IS NOT NULL));
+
+-- name: GetDisabledUser :one
+SELECT * FROM disabled_users WHERE user_id = $1;
+
+-- name: DisableUser :exec
+INSERT INTO disabled_users (user_id, disabled_by)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO NOTHING;
+
+-- name: EnableUser :exec
+DELETE FROM disabled_users WHERE user_id = $1;
@@ -0,0 +1,36 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (action, actor_id, target_id, ip_address, user_agent, metadata)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListAuditEvents :many
+-- events of the back office, newest first. action is empty for all actions, search
+-- an ILIKE pattern matched against the email addresses of the actor and the target
+-- and the IP address. The events between since and until are listed, both optional.
+SELECT audit_events.id, audit_events.action, audit_events.actor_id, audit_events.target_id,
+       audit_events.ip_address, audit_events.user_agent, audit_events.metadata, audit_events.created_at,
+       COALESCE(actors.email, '')::text AS actor_email,
+       COALESCE(targets.email, '')::text AS target_email
+FROM audit_events
+LEFT JOIN users actors ON actors.id = audit_events.actor_id
+LEFT JOIN users targets ON targets.id = audit_events.target_id
+WHERE (sqlc.arg(action)::text = '' OR audit_events.action = sqlc.arg(action)::text)
+  AND (sqlc.arg(search)::text = ''
+       OR actors.email ILIKE sqlc.arg(search)::text
+       OR targets.email ILIKE sqlc.arg(search)::text
+       OR audit_events.ip_address ILIKE sqlc.arg(search)::text)
+  AND (sqlc.narg(since)::timestamptz IS NULL OR audit_events.created_at >= sqlc.narg(since)::timestamptz)
+  AND (sqlc.narg(until)::timestamptz IS NULL OR audit_events.created_at < sqlc.narg(until)::timestamptz)
+ORDER BY audit_events.created_at DESC, audit_events.id DESC
+LIMIT sqlc.arg(page_size)::int OFFSET sqlc.arg(page_offset)::int;
+
+-- name: CountAuditEvents :one
+SELECT COUNT(*) FROM audit_events
+LEFT JOIN users actors ON actors.id = audit_events.actor_id
+LEFT JOIN users targets ON targets.id = audit_events.target_id
+WHERE (sqlc.arg(action)::text = '' OR audit_events.action = sqlc.arg(action)::text)
+  AND (sqlc.arg(search)::text = ''
+       OR actors.email ILIKE sqlc.arg(search)::text
+       OR targets.email ILIKE sqlc.arg(search)::text
+       OR audit_events.ip_address ILIKE sqlc.arg(search)::text)
+  AND (sqlc.narg(since)::timestamptz IS NULL OR audit_events.created_at >= sqlc.narg(since)::timestamptz)
+  AND (sqlc.narg(until)::timestamptz IS NULL OR audit_events.created_at < sqlc.narg(until)::timestamptz);
@@ -0,0 +1,11 @@
+-- name: UpsertEmailChange :exec
+INSERT INTO email_changes (user_id, new_email, expiry)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET new_email = EXCLUDED.new_email, expiry = EXCLUDED.expiry, created_at = NOW();
+
+-- name: GetEmailChange :one
+SELECT * FROM email_changes WHERE user_id = $1 AND expiry > $2;
+
+-- name: DeleteEmailChange :exec
+DELETE FROM email_changes WHERE user_id = $1;
@@ -0,0 +1,16 @@
+-- name: CreateImpersonation :one
+INSERT INTO impersonations (admin_id, user_id, ip_address, user_agent)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: EndImpersonation :exec
+UPDATE impersonations SET ended_at = NOW()
+WHERE id = $1 AND ended_at IS NULL;
+
+-- name: ListImpersonationsOfUser :many
+SELECT impersonations.*, COALESCE(admins.name, '') AS admin_name
+FROM impersonations
+LEFT JOIN users admins ON admins.id = impersonations.admin_id
+WHERE impersonations.user_id = $1
+ORDER BY impersonations.started_at DESC
+LIMIT 10;
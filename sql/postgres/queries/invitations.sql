@@ -0,0 +1,39 @@
+-- name: CreateInvitation :one
+INSERT INTO invitations (organization_id, email, role, invited_by, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListInvitations :many
+SELECT invitations.*, users.name AS inviter_name
+FROM invitations
+JOIN users ON users.id = invitations.invited_by
+WHERE invitations.organization_id = $1
+ORDER BY invitations.created_at DESC, invitations.id DESC;
+
+-- name: GetInvitation :one
+SELECT * FROM invitations
+WHERE organization_id = $1 AND id = $2;
+
+-- name: HasPendingInvitation :one
+SELECT EXISTS (
+	SELECT 1 FROM invitations
+	WHERE organization_id = $1 AND lower(email) = lower(sqlc.arg(email))
+		AND status = 'pending' AND expires_at > sqlc.arg(now)
+);
+
+-- name: GetInvitationByToken :one
+SELECT invitations.*, organizations.name AS organization_name, users.name AS inviter_name
+FROM invitations
+JOIN organizations ON organizations.id = invitations.organization_id
+JOIN users ON users.id = invitations.invited_by
+WHERE invitations.token_hash = $1 AND invitations.status = 'pending' AND invitations.expires_at > $2;
+
+-- name: RenewInvitation :execrows
+UPDATE invitations
+SET token_hash = $3, expires_at = $4, invited_by = $5
+WHERE organization_id = $1 AND id = $2 AND status = 'pending';
+
+-- name: SetInvitationStatus :execrows
+UPDATE invitations
+SET status = $2, token_hash = NULL, responded_at = NOW()
+WHERE id = $1 AND status = 'pending';
@@ -0,0 +1,41 @@
+-- name: EnqueueJob :one
+INSERT INTO jobs (kind, payload, max_attempts, run_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ClaimJob :one
+-- the next runnable job, locked for the worker. Running jobs locked before
+-- stale_before belong to a worker that died and are picked again.
+UPDATE jobs
+SET status = 'running', attempts = attempts + 1, locked_at = CURRENT_TIMESTAMP
+WHERE id = (
+	SELECT id FROM jobs
+	WHERE (status = 'pending' AND run_at <= CURRENT_TIMESTAMP)
+	   OR (status = 'running' AND locked_at < sqlc.arg(stale_before)::timestamptz)
+	ORDER BY run_at, id
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteJob :exec
+UPDATE jobs
+SET status = 'done', locked_at = NULL, last_error = '', finished_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: RetryJob :exec
+UPDATE jobs
+SET status = 'pending', locked_at = NULL, run_at = $2, last_error = $3
+WHERE id = $1;
+
+-- name: KillJob :exec
+-- moves the job to the dead letters, it isn't retried anymore
+UPDATE jobs
+SET status = 'dead', locked_at = NULL, last_error = $2, finished_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: GetJob :one
+SELECT * FROM jobs WHERE id = $1;
+
+-- name: DeleteFinishedJobs :execrows
+DELETE FROM jobs WHERE status = 'done' AND finished_at < sqlc.arg(before)::timestamptz;
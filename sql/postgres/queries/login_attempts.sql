@@ -0,0 +1,24 @@
+-- name: GetLoginAttempt :one
+SELECT * FROM login_attempts
+WHERE email = $1;
+
+-- name: RecordFailedLogin :one
+-- failures older than reset_before are forgotten and counting starts over
+INSERT INTO login_attempts (email, failed_count, last_failed_at)
+VALUES (sqlc.arg(email), 1, NOW())
+ON CONFLICT (email) DO UPDATE
+SET failed_count = CASE
+		WHEN login_attempts.last_failed_at < sqlc.arg(reset_before) THEN 1
+		ELSE login_attempts.failed_count + 1
+	END,
+	last_failed_at = NOW()
+RETURNING *;
+
+-- name: LockLogin :exec
+UPDATE login_attempts
+SET locked_until = $2
+WHERE email = $1;
+
+-- name: DeleteLoginAttempt :exec
+DELETE FROM login_attempts
+WHERE email = $1;
@@ -0,0 +1,31 @@
+-- name: CreateOrganization :one
+INSERT INTO organizations (name, personal)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: CreateMembership :exec
+INSERT INTO memberships (organization_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (organization_id, user_id) DO NOTHING;
+
+-- name: ListMembershipsForUser :many
+SELECT organizations.id, organizations.name, organizations.personal, memberships.role
+FROM memberships
+JOIN organizations ON organizations.id = memberships.organization_id
+WHERE memberships.user_id = $1
+ORDER BY organizations.personal DESC, organizations.name, organizations.id;
+
+-- name: ListMembers :many
+SELECT users.id, users.name, users.email, memberships.role, memberships.created_at
+FROM memberships
+JOIN users ON users.id = memberships.user_id
+WHERE memberships.organization_id = $1
+ORDER BY memberships.created_at, users.id;
+
+-- name: IsMemberByEmail :one
+SELECT EXISTS (
+	SELECT 1
+	FROM memberships
+	JOIN users ON users.id = memberships.user_id
+	WHERE memberships.organization_id = $1 AND lower(users.email) = lower(sqlc.arg(email))
+);
@@ -0,0 +1,60 @@
+-- name: AddOutboxMessage :one
+-- adds the message unless one with the key was added before, then no row is returned
+INSERT INTO outbox_messages (idempotency_key, email_type, recipients, message)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (idempotency_key) DO NOTHING
+RETURNING *;
+
+-- name: GetOutboxMessage :one
+SELECT * FROM outbox_messages WHERE id = $1;
+
+-- name: GetOutboxMessageByKey :one
+SELECT * FROM outbox_messages WHERE idempotency_key = $1;
+
+-- name: ClaimOutboxMessage :one
+-- the next message due, locked for the dispatcher. Messages locked before
+-- stale_before belong to a dispatcher that died and are picked again.
+UPDATE outbox_messages
+SET status = 'sending', attempts = attempts + 1, locked_at = CURRENT_TIMESTAMP
+WHERE id = (
+	SELECT id FROM outbox_messages
+	WHERE (status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP)
+	   OR (status = 'sending' AND locked_at < sqlc.arg(stale_before)::timestamptz)
+	ORDER BY next_attempt_at, id
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxMessageSent :exec
+UPDATE outbox_messages
+SET status = 'sent', message = '{}', locked_at = NULL, last_error = '', sent_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: RetryOutboxMessage :exec
+UPDATE outbox_messages
+SET status = 'pending', locked_at = NULL, next_attempt_at = $2, last_error = $3
+WHERE id = $1;
+
+-- name: KillOutboxMessage :exec
+-- gives up on the message, it isn't retried anymore
+UPDATE outbox_messages
+SET status = 'dead', locked_at = NULL, last_error = $2
+WHERE id = $1;
+
+-- name: ListOutboxMessages :many
+-- the latest messages, of the status unless it is empty
+SELECT * FROM outbox_messages
+WHERE sqlc.arg(status)::text = '' OR status = sqlc.arg(status)::text
+ORDER BY id DESC
+LIMIT $1;
+
+-- name: DeleteSentOutboxMessages :execrows
+DELETE FROM outbox_messages WHERE status = 'sent' AND sent_at < sqlc.arg(before)::timestamptz;
+
+-- name: AddOutboxAttempt :exec
+INSERT INTO outbox_attempts (message_id, attempt, started_at, error)
+VALUES ($1, $2, $3, $4);
+
+-- name: ListOutboxAttempts :many
+SELECT * FROM outbox_attempts WHERE message_id = $1 ORDER BY attempt, id;
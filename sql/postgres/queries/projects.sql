@@ -0,0 +1,19 @@
+-- Every project query is filtered by organization_id, see service.Tenant.
+
+-- name: ListProjects :many
+SELECT * FROM projects
+WHERE organization_id = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: GetProject :one
+SELECT * FROM projects
+WHERE organization_id = $1 AND id = $2;
+
+-- name: CreateProject :one
+INSERT INTO projects (organization_id, name, description, created_by)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: DeleteProject :execrows
+DELETE FROM projects
+WHERE organization_id = $1 AND id = $2;
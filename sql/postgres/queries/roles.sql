@@ -0,0 +1,27 @@
+-- name: ListRoles :many
+SELECT * FROM roles
+ORDER BY name;
+
+-- name: GetRoleByName :one
+SELECT * FROM roles WHERE name = $1;
+
+-- name: ListRolesForUser :many
+SELECT roles.* FROM roles
+JOIN user_roles ON user_roles.role_id = roles.id
+WHERE user_roles.user_id = $1
+ORDER BY roles.name;
+
+-- name: ListPermissionsForUser :many
+SELECT DISTINCT permissions.name FROM permissions
+JOIN role_permissions ON role_permissions.permission_id = permissions.id
+JOIN user_roles ON user_roles.role_id = role_permissions.role_id
+WHERE user_roles.user_id = $1
+ORDER BY permissions.name;
+
+-- name: AssignRole :exec
+INSERT INTO user_roles (user_id, role_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveRole :execrows
+DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2;
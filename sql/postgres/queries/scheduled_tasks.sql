@@ -0,0 +1,31 @@
+-- name: RegisterScheduledTask :exec
+-- a changed schedule starts over with its next run
+INSERT INTO scheduled_tasks (name, schedule, next_run_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE
+SET schedule = EXCLUDED.schedule,
+	next_run_at = CASE
+		WHEN scheduled_tasks.schedule = EXCLUDED.schedule THEN scheduled_tasks.next_run_at
+		ELSE EXCLUDED.next_run_at
+	END;
+
+-- name: TryLockScheduledTask :one
+-- session level, the lock must be released on the same connection
+SELECT pg_try_advisory_lock(hashtext('scheduled_tasks'), hashtext(sqlc.arg(name)::text))::boolean AS locked;
+
+-- name: UnlockScheduledTask :exec
+SELECT pg_advisory_unlock(hashtext('scheduled_tasks'), hashtext(sqlc.arg(name)::text));
+
+-- name: ClaimScheduledTask :execrows
+-- no rows when the task isn't due, e.g. because another replica just ran it
+UPDATE scheduled_tasks
+SET next_run_at = $2, last_status = 'running', last_started_at = CURRENT_TIMESTAMP
+WHERE name = $1 AND next_run_at <= CURRENT_TIMESTAMP;
+
+-- name: FinishScheduledTask :exec
+UPDATE scheduled_tasks
+SET last_status = $2, last_error = $3, last_duration_ms = $4, last_finished_at = CURRENT_TIMESTAMP
+WHERE name = $1;
+
+-- name: ListScheduledTasks :many
+SELECT * FROM scheduled_tasks ORDER BY name;
@@ -1,5 +1,44 @@
 -- name: GetSessionByToken :one
 SELECT * FROM sessions
 WHERE token = $1
-  AND revoked_at IS NULL
+  AND expiry > NOW();
+
+-- name: CreateUserSession :one
+INSERT INTO user_sessions (token, user_id, user_agent, ip_address, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetUserSessionByToken :one
+SELECT * FROM user_sessions
+WHERE token = $1
   AND expires_at > NOW();
+
+-- name: TouchUserSession :exec
+UPDATE user_sessions SET last_seen_at = NOW() WHERE id = $1;
+
+-- name: ListUserSessions :many
+SELECT * FROM user_sessions
+WHERE user_id = $1
+  AND expires_at > NOW()
+ORDER BY last_seen_at DESC;
+
+-- name: DeleteUserSession :execrows
+DELETE FROM user_sessions WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteUserSessionByToken :exec
+DELETE FROM user_sessions WHERE token = $1;
+
+-- name: DeleteOtherUserSessions :exec
+DELETE FROM user_sessions WHERE user_id = $1 AND token <> $2;
+
+-- name: DeleteAllUserSessions :exec
+DELETE FROM user_sessions WHERE user_id = $1;
+
+-- name: DeleteExpiredUserSessions :exec
+DELETE FROM user_sessions WHERE user_id = $1 AND expires_at <= NOW();
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions WHERE expiry < CURRENT_TIMESTAMP;
+
+-- name: DeleteAllExpiredUserSessions :execrows
+DELETE FROM user_sessions WHERE expires_at < CURRENT_TIMESTAMP;
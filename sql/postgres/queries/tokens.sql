@@ -12,3 +12,11 @@ DELETE FROM tokens WHERE hash = $1;
 
 -- name: DeleteTokensByUserId :exec
 DELETE FROM tokens WHERE user_id = $1;
+
+-- name: ConsumeToken :one
+DELETE FROM tokens
+WHERE hash = $1 AND scope = $2 AND expiry > $3
+RETURNING user_id;
+
+-- name: DeleteExpiredTokens :execrows
+DELETE FROM tokens WHERE expiry < CURRENT_TIMESTAMP;
@@ -0,0 +1,32 @@
+-- name: UpsertTOTPSecret :one
+INSERT INTO totp_secrets (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, confirmed_at = NULL, last_used_step = 0, created_at = NOW()
+RETURNING *;
+
+-- name: GetTOTPSecret :one
+SELECT * FROM totp_secrets WHERE user_id = $1;
+
+-- name: ConfirmTOTPSecret :exec
+UPDATE totp_secrets SET confirmed_at = NOW() WHERE user_id = $1;
+
+-- name: UpdateTOTPLastUsedStep :execrows
+UPDATE totp_secrets SET last_used_step = $2
+WHERE user_id = $1 AND last_used_step < $2;
+
+-- name: DeleteTOTPSecret :exec
+DELETE FROM totp_secrets WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (hash, user_id) VALUES ($1, $2);
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes SET used_at = NOW()
+WHERE hash = $1 AND user_id = $2 AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodesForUser :exec
+DELETE FROM recovery_codes WHERE user_id = $1;
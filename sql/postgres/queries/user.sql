@@ -22,3 +22,15 @@ WHERE tokens.hash = $1
 
 -- name: DeleteUser :exec
 DELETE FROM users WHERE id = $1;
+
+-- name: VerifyUserEmail :one
+UPDATE users SET email_verified = TRUE, updated_at = NOW() WHERE id = $1 RETURNING *;
+
+-- name: UpdateUserEmail :one
+UPDATE users SET email = $1, email_verified = TRUE, updated_at = NOW() WHERE id = $2 RETURNING *;
+
+-- name: ListUnverifiedUsersCreatedBefore :many
+SELECT * FROM users
+WHERE email_verified = false AND created_at < $1
+ORDER BY id
+LIMIT $2;
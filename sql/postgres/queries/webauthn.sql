@@ -0,0 +1,28 @@
+-- name: CreateWebAuthnCredential :one
+INSERT INTO webauthn_credentials (user_id, name, credential_id, public_key, attestation_type, transports, aaguid, flags, sign_count)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: ListWebAuthnCredentialsForUser :many
+SELECT * FROM webauthn_credentials
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: CountWebAuthnCredentialsForUser :one
+SELECT COUNT(*) FROM webauthn_credentials WHERE user_id = $1;
+
+-- name: GetWebAuthnCredentialByCredentialID :one
+SELECT * FROM webauthn_credentials WHERE credential_id = $1;
+
+-- name: UpdateWebAuthnCredentialUsage :exec
+UPDATE webauthn_credentials
+SET sign_count = $2, flags = $3, last_used_at = NOW()
+WHERE credential_id = $1;
+
+-- name: RenameWebAuthnCredential :execrows
+UPDATE webauthn_credentials SET name = $3
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteWebAuthnCredential :execrows
+DELETE FROM webauthn_credentials
+WHERE id = $1 AND user_id = $2;